@@ -0,0 +1,154 @@
+// Command ratectl runs maintenance tasks against the exchange rate service's
+// stores.
+//
+//	ratectl backfill -start 2024-01-01 -end 2024-03-31 -bases USD,EUR
+//	ratectl backfill -start 2024-01-01 -end 2024-03-31 -bases USD -file rates.csv
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/repository"
+	"exchange-rate-service/internal/service"
+	"exchange-rate-service/internal/utils"
+)
+
+const usage = `usage: ratectl <command> [flags]
+
+commands:
+  backfill   fill the history store for a date range from a provider or a file
+
+Run "ratectl <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "backfill":
+		err = backfill(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "ratectl: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ratectl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func backfill(args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	configPath := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file (env CONFIG_FILE)")
+	start := flags.String("start", "", "first date to fill, YYYY-MM-DD (required)")
+	end := flags.String("end", "", "last date to fill, YYYY-MM-DD (defaults to yesterday)")
+	bases := flags.String("bases", "", "comma-separated base currencies (required)")
+	targets := flags.String("targets", "", "comma-separated target currencies to keep (default all)")
+	providerName := flags.String("provider", "", "configured provider to fetch from (default the first)")
+	file := flags.String("file", "", "import rates from this CSV, JSON or NDJSON file instead of a provider")
+	format := flags.String("format", "", "format of -file, csv, json or ndjson (default from the extension)")
+	onConflict := flags.String("on-conflict", "overwrite", "for -file, how to treat rates that differ from stored ones: skip, overwrite or fail")
+	rps := flags.Float64("rps", 1, "maximum provider requests per second")
+	stateFile := flags.String("state", "data/backfill-state.json", "checkpoint file for resuming an interrupted backfill")
+	force := flags.Bool("force", false, "refetch dates that are already stored and ignore the checkpoint")
+	flags.Parse(args)
+
+	if *start == "" || *bases == "" {
+		flags.Usage()
+		return fmt.Errorf("-start and -bases are required")
+	}
+	job := service.BackfillJob{
+		Bases:             strings.Split(*bases, ","),
+		RequestsPerSecond: *rps,
+		StateFile:         *stateFile,
+		Force:             *force,
+		OnConflict:        *onConflict,
+	}
+	if *targets != "" {
+		job.Targets = strings.Split(*targets, ",")
+	}
+
+	var err error
+	if job.Start, err = time.Parse("2006-01-02", *start); err != nil {
+		return fmt.Errorf("invalid -start: %w", err)
+	}
+	if *end == "" {
+		now := time.Now().UTC()
+		job.End = time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.UTC)
+	} else if job.End, err = time.Parse("2006-01-02", *end); err != nil {
+		return fmt.Errorf("invalid -end: %w", err)
+	}
+
+	cfg, err := configs.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	logger, _ := utils.NewLogger(cfg.Log.Level, cfg.Secrets()...)
+
+	if *file != "" {
+		if *format == "" {
+			*format = service.FormatFromPath(*file)
+		}
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if job.Rates, err = service.DecodeHistoricalRates(f, *format, *file); err != nil {
+			return err
+		}
+	} else {
+		providerCfg, err := findProvider(cfg, *providerName)
+		if err != nil {
+			return err
+		}
+		job.Provider = repository.NewOpenERAPIClient(providerCfg, logger, repository.NewMetrics())
+		job.Validation = cfg.Validation
+	}
+
+	// Stop between dates on interrupt; the checkpoint lets a rerun resume
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store := repository.NewHistoryStore(cfg, logger)
+	result, err := service.Backfill(ctx, store, job, logger)
+	if result != nil {
+		fmt.Printf("fetched %d tables, stored %d rates, skipped %d, missing %d, rejected %d\n",
+			result.Fetched, result.Stored, result.Skipped, result.Missing, result.Rejected)
+	}
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("interrupted, rerun the same command to resume: %w", err)
+	}
+	return err
+}
+
+// findProvider returns the named provider, or the first configured one
+func findProvider(cfg *configs.Config, name string) (configs.ProviderConfig, error) {
+	if len(cfg.Providers) == 0 {
+		return configs.ProviderConfig{}, fmt.Errorf("no providers configured")
+	}
+	if name == "" {
+		return cfg.Providers[0], nil
+	}
+	for _, p := range cfg.Providers {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return configs.ProviderConfig{}, fmt.Errorf("provider %q is not configured", name)
+}
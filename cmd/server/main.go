@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,32 +13,94 @@ import (
 
 	"exchange-rate-service/configs"
 	"exchange-rate-service/internal/api"
+	"exchange-rate-service/internal/calendar"
 	"exchange-rate-service/internal/repository"
 	"exchange-rate-service/internal/service"
+	"exchange-rate-service/internal/transport"
 	"exchange-rate-service/internal/utils"
+
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file (env CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	watchConfig := flag.Duration("watch-config", 0, "reload the config file when it changes, checking at this interval (0 disables)")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := configs.Load()
+	cfg, err := configs.Load(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Initialize logger
-	logger := utils.NewLogger()
+	if *printConfig {
+		if err := cfg.WriteRedacted(os.Stdout); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		return
+	}
+
+	if _, err := calendar.Get(cfg.History.Calendar); err != nil {
+		log.Fatalf("Invalid history.calendar: %v", err)
+	}
+
+	// Initialize logger (API keys and tokens are redacted from every line)
+	logger, logSettings := utils.NewLogger(cfg.Log.Level, cfg.Secrets()...)
 
 	// Initialize repositories
-	rateRepo := repository.NewRateRepository(cfg, logger)
+	rateRepo := repository.NewRateRepository(cfg, logger, repository.NewMetrics())
 
 	// Initialize service layer
-	exchangeService := service.NewExchangeService(rateRepo, logger)
+	exchangeService := service.NewExchangeService(rateRepo, cfg.History, logger)
+	adminService := service.NewAdminService(rateRepo, logger)
+	rateHub := service.NewRateHub(rateRepo, cfg.Streaming, logger)
+
+	// Refresh streamed currencies and evaluate alerts until shutdown
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go rateHub.Run(bgCtx)
+
+	var alertService service.AlertService
+	if cfg.Features.Alerts {
+		alertService = service.NewAlertService(rateRepo, repository.NewAlertStore(cfg, logger),
+			repository.NewWebhookClient(cfg.Alerts, logger), cfg.Alerts, logger)
+		go alertService.Run(bgCtx)
+	}
 
 	// Initialize HTTP handlers
-	handlers := api.NewHandlers(exchangeService, logger)
+	handlers := api.NewHandlers(exchangeService, adminService, alertService, rateHub, logger)
 
 	// Setup routes
-	router := api.NewRouter(handlers)
+	limiter := api.NewRateLimiter(cfg.Limits)
+	httpCache := api.NewHTTPCache(cfg.Cache)
+	compressor := api.NewCompressor(cfg.Compression)
+	router := api.NewRouter(handlers, cfg, limiter, httpCache, compressor)
+
+	// Reload the configuration on SIGHUP and, if enabled, when the file changes
+	reloads := &reloader{
+		path:        *configPath,
+		logger:      logger,
+		logSettings: logSettings,
+		rateRepo:    rateRepo,
+		limiter:     limiter,
+		httpCache:   httpCache,
+		compressor:  compressor,
+		current:     cfg,
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloads.reload("SIGHUP")
+		}
+	}()
+	stopWatch := make(chan struct{})
+	defer close(stopWatch)
+	if *watchConfig > 0 && *configPath != "" {
+		go reloads.watch(*watchConfig, stopWatch)
+	}
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -46,26 +110,62 @@ func main() {
 
 	// Start server in goroutine
 	go func() {
-		logger.Log("msg", "Starting server", "port", cfg.Server.Port)
+		level.Info(logger).Log("msg", "Starting server", "port", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Log("err", err)
+			level.Error(logger).Log("err", err)
 		}
 	}()
 
+	// Start the gRPC server on its own port, served by the same endpoints
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != "" {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.Server.GRPCPort))
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		grpcServer = transport.NewGRPCServer(transport.MakeEndpoints(exchangeService, logger), logger)
+		go func() {
+			level.Info(logger).Log("msg", "Starting gRPC server", "port", cfg.Server.GRPCPort)
+			if err := grpcServer.Serve(lis); err != nil {
+				level.Error(logger).Log("err", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Log("msg", "Shutting down server...")
+	level.Info(logger).Log("msg", "Shutting down server...")
 
-	// Graceful shutdown
+	// Graceful shutdown; ending the hub closes open streams so they don't
+	// hold up Shutdown
+	stopBackground()
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Log("err", "Server forced to shutdown", "error", err)
+		level.Error(logger).Log("err", "Server forced to shutdown", "error", err)
+	}
+
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			level.Error(logger).Log("err", "gRPC server forced to shutdown")
+			grpcServer.Stop()
+		}
+	}
+
+	if err := rateRepo.FlushHistory(context.Background()); err != nil {
+		level.Error(logger).Log("error", err, "msg", "failed to write buffered history")
 	}
 
-	logger.Log("msg", "Server exited")
+	level.Info(logger).Log("msg", "Server exited")
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/api"
+	"exchange-rate-service/internal/repository"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// reloader re-reads the configuration and applies it to the running service:
+// provider clients, cache TTLs and HTTP cache lifetimes, aggregation and
+// validation settings, rate limits, compression and the log level. An invalid configuration
+// is rejected and the previous one stays in effect.
+type reloader struct {
+	path        string
+	logger      log.Logger
+	logSettings *utils.LogSettings
+	rateRepo    repository.RateRepository
+	limiter     *api.RateLimiter
+	httpCache   *api.HTTPCache
+	compressor  *api.Compressor
+
+	mu      sync.Mutex
+	current *configs.Config
+}
+
+func (r *reloader) reload(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := configs.Load(r.path)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "config reload rejected, keeping previous configuration", "trigger", trigger, "error", err)
+		return
+	}
+
+	if sections := r.current.RestartRequired(next); len(sections) > 0 {
+		level.Warn(r.logger).Log("msg", "changed settings only take effect after a restart", "sections", strings.Join(sections, ","))
+	}
+
+	// Secrets go first so a new API key is redacted before anything logs it;
+	// the old ones stay redacted as in-flight requests may still use them
+	r.logSettings.Update(next.Log.Level, append(r.current.Secrets(), next.Secrets()...)...)
+	r.rateRepo.Reconfigure(next)
+	r.limiter.Update(next.Limits)
+	r.httpCache.Update(next.Cache)
+	r.compressor.Update(next.Compression)
+	r.current = next
+
+	level.Info(r.logger).Log("msg", "configuration reloaded", "trigger", trigger, "path", r.path)
+}
+
+// watch reloads the configuration whenever the config file's modification
+// time or size changes, checking every interval until stop is closed
+func (r *reloader) watch(interval time.Duration, stop <-chan struct{}) {
+	last, _ := os.Stat(r.path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.path)
+			if err != nil {
+				continue
+			}
+			if last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+				last = info
+				r.reload("file change")
+			}
+		}
+	}
+}
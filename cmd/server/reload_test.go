@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/api"
+	"exchange-rate-service/internal/repository"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/go-kit/log/level"
+)
+
+func TestReloadChangesLogLevel(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig := func(port, logLevel string) {
+		t.Helper()
+		config := fmt.Sprintf(`
+server:
+  port: %q
+log:
+  level: %s
+redis:
+  addr: 127.0.0.1:1
+history:
+  file: %s
+alerts:
+  store_file: %s
+admin:
+  overrides_file: %s
+`, port, logLevel, filepath.Join(dir, "history.json"), filepath.Join(dir, "alerts.json"), filepath.Join(dir, "overrides.json"))
+		if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("8080", "info")
+
+	cfg, err := configs.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	logger, logSettings := utils.NewLoggerTo(&out, cfg.Log.Level)
+	r := &reloader{
+		path:        path,
+		logger:      logger,
+		logSettings: logSettings,
+		rateRepo:    repository.NewRateRepository(cfg, logger, repository.NopMetrics()),
+		limiter:     api.NewRateLimiter(cfg.Limits),
+		httpCache:   api.NewHTTPCache(cfg.Cache),
+		compressor:  api.NewCompressor(cfg.Compression),
+		current:     cfg,
+	}
+
+	logged := func() string {
+		out.Reset()
+		level.Debug(logger).Log("msg", "debug line")
+		level.Info(logger).Log("msg", "info line")
+		level.Warn(logger).Log("msg", "warn line")
+		return out.String()
+	}
+	if got := logged(); strings.Contains(got, "debug line") || !strings.Contains(got, "info line") || !strings.Contains(got, "warn line") {
+		t.Errorf("at info, logged:\n%s", got)
+	}
+
+	// Raising the level also hides the reload's own confirmation, but not
+	// the warning about a setting that needs a restart
+	writeConfig("8081", "warn")
+	out.Reset()
+	r.reload("test")
+	if got := out.String(); !strings.Contains(got, `level=warn`) || !strings.Contains(got, "sections=server") || strings.Contains(got, "configuration reloaded") {
+		t.Errorf("reload to warn logged:\n%s", got)
+	}
+	if got := logged(); strings.Contains(got, "debug line") || strings.Contains(got, "info line") || !strings.Contains(got, "warn line") {
+		t.Errorf("at warn, logged:\n%s", got)
+	}
+
+	writeConfig("8081", "debug")
+	r.reload("test")
+	if got := logged(); !strings.Contains(got, "debug line") || !strings.Contains(got, "info line") {
+		t.Errorf("at debug, logged:\n%s", got)
+	}
+
+	// A rejected configuration keeps the previous level
+	writeConfig("8081", "verbose")
+	out.Reset()
+	r.reload("test")
+	if got := out.String(); !strings.Contains(got, "config reload rejected") {
+		t.Errorf("invalid reload logged:\n%s", got)
+	}
+	if got := logged(); !strings.Contains(got, "debug line") {
+		t.Errorf("after a rejected reload, logged:\n%s", got)
+	}
+}
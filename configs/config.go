@@ -1,30 +1,262 @@
 package configs
 
 import (
+	"reflect"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// Config is the effective service configuration: defaults, then the config
+// file, then environment overrides. Fields tagged secret are redacted when the
+// configuration is printed.
 type Config struct {
-	Server    ServerConfig
-	Redis     RedisConfig
-	Providers ProviderConfig
+	Server      ServerConfig      `yaml:"server"`
+	Log         LogConfig         `yaml:"log"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Cache       CacheConfig       `yaml:"cache"`
+	History     HistoryConfig     `yaml:"history"`
+	Providers   []ProviderConfig  `yaml:"providers"`
+	Aggregation AggregationConfig `yaml:"aggregation"`
+	Validation  ValidationConfig  `yaml:"validation"`
+	Limits      LimitsConfig      `yaml:"limits"`
+	Compression CompressionConfig `yaml:"compression"`
+	Features    FeaturesConfig    `yaml:"features"`
+	Streaming   StreamingConfig   `yaml:"streaming"`
+	Alerts      AlertsConfig      `yaml:"alerts"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Health      HealthConfig      `yaml:"health"`
 }
 
+// ServerConfig sets the listening ports. The gRPC API is disabled while
+// GRPCPort is empty.
 type ServerConfig struct {
-	Port            string
-	ShutdownTimeout time.Duration
+	Port            string        `yaml:"port"`
+	GRPCPort        string        `yaml:"grpc_port"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// LogConfig sets the minimum level written: debug, info, warn or error
+type LogConfig struct {
+	Level string `yaml:"level"`
 }
 
 type RedisConfig struct {
-	Addr     string
-	Password string
-	DB       int
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password" secret:"true"`
+	DB       int    `yaml:"db"`
+}
+
+// CacheConfig sets how long fetched data is cached. StaleTTL applies to a
+// previously accepted table served while fresh provider data is quarantined.
+type CacheConfig struct {
+	LatestTTL     time.Duration `yaml:"latest_ttl"`
+	StaleTTL      time.Duration `yaml:"stale_ttl"`
+	HistoricalTTL time.Duration `yaml:"historical_ttl"`
+	CurrenciesTTL time.Duration `yaml:"currencies_ttl"`
+}
+
+// HistoryConfig locates the historical rate store and bounds time series
+// requests. Daily rates are kept in Redis without expiry, or in File when
+// Redis is unavailable; rates stored within FlushInterval are written to the
+// file together (zero writes every rate through). A daily time series may
+// span at most MaxRangeDays and is fetched by FetchWorkers concurrent
+// lookups; series bucketed by week or longer are read from the store and may
+// span MaxBucketRangeDays, and exports may span MaxExportRangeDays. Calendar and Fill are the default business-day
+// calendar and fill policy for dates without a rate.
+type HistoryConfig struct {
+	File               string        `yaml:"file"`
+	FlushInterval      time.Duration `yaml:"flush_interval"`
+	MaxRangeDays       int           `yaml:"max_range_days"`
+	MaxBucketRangeDays int           `yaml:"max_bucket_range_days"`
+	MaxExportRangeDays int           `yaml:"max_export_range_days"`
+	FetchWorkers       int           `yaml:"fetch_workers"`
+	Calendar           string        `yaml:"calendar"`
+	Fill               string        `yaml:"fill"`
+}
+
+// LimitsConfig throttles the public API. RequestsPerSecond is shared by all
+// clients of a replica, with bursts of up to Burst requests; 0 disables it.
+type LimitsConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// CompressionConfig controls response compression. Responses of at least
+// MinSize bytes are compressed with the best encoding the client accepts, at
+// Level from 1 (fastest) to 9 (smallest).
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	MinSize int  `yaml:"min_size"`
+	Level   int  `yaml:"level"`
+}
+
+// FeaturesConfig switches optional parts of the API on or off
+type FeaturesConfig struct {
+	AdminAPI        bool `yaml:"admin_api"`
+	Metrics         bool `yaml:"metrics"`
+	HistoricalRates bool `yaml:"historical_rates"`
+	Alerts          bool `yaml:"alerts"`
+}
+
+// StreamingConfig controls live rate streams. Each subscribed base currency
+// is refreshed every RefreshInterval; idle streams get a heartbeat every
+// HeartbeatInterval. The last HistorySize updates are kept so reconnecting
+// clients can resume, and a subscriber more than SubscriberBuffer updates
+// behind is disconnected. A subscription may watch at most MaxBases base
+// currencies. WebSocket clients are pinged every PingInterval and dropped
+// when they miss two pongs. WebSocket upgrades are accepted from the
+// service's own origin and from AllowedOrigins ("*" allows any).
+type StreamingConfig struct {
+	RefreshInterval   time.Duration `yaml:"refresh_interval"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	PingInterval      time.Duration `yaml:"ping_interval"`
+	HistorySize       int           `yaml:"history_size"`
+	SubscriberBuffer  int           `yaml:"subscriber_buffer"`
+	MaxBases          int           `yaml:"max_bases"`
+	AllowedOrigins    []string      `yaml:"allowed_origins"`
+}
+
+// AlertsConfig controls rate alerts. Alert definitions and delivery logs live
+// in Redis, or in StoreFile without it. The base currencies of all alerts are
+// re-read every EvaluationInterval so alerts fire even when no client asks for
+// their rates. Webhooks are sent by Workers goroutines, each POST bounded by
+// Timeout and retried per Retry; the last DeliveryLogSize deliveries of each
+// alert are kept. Webhooks to loopback, private and link-local addresses are
+// refused unless AllowPrivateWebhooks is set.
+type AlertsConfig struct {
+	StoreFile            string        `yaml:"store_file"`
+	EvaluationInterval   time.Duration `yaml:"evaluation_interval"`
+	Workers              int           `yaml:"workers"`
+	Timeout              time.Duration `yaml:"timeout"`
+	Retry                RetryConfig   `yaml:"retry"`
+	DeliveryLogSize      int           `yaml:"delivery_log_size"`
+	AllowPrivateWebhooks bool          `yaml:"allow_private_webhooks"`
+}
+
+// HealthConfig controls health reporting. Provider probes are cached for
+// ProviderCacheTTL so readiness checks don't call upstream on every request.
+type HealthConfig struct {
+	ProviderCacheTTL time.Duration `yaml:"provider_cache_ttl"`
 }
 
+// AdminConfig secures the admin API. Admin routes reject every request while
+// Token is empty. OverridesFile stores pinned rates when Redis is unavailable.
+type AdminConfig struct {
+	Token         string `yaml:"token" secret:"true"`
+	OverridesFile string `yaml:"overrides_file"`
+}
+
+// AggregationConfig selects how rates from several providers are combined.
+// Mode "failover" uses the first provider that answers; "consensus" queries all
+// healthy providers, drops values more than MaxDeviation percent away from the
+// median and requires at least MinProviders surviving values.
+type AggregationConfig struct {
+	Mode         string  `yaml:"mode"`
+	MaxDeviation float64 `yaml:"max_deviation"`
+	MinProviders int     `yaml:"min_providers"`
+}
+
+// ValidationConfig bounds the provider data accepted into the cache. Rates
+// outside [MinRate, MaxRate] are rejected; a table in which any rate moved more
+// than MaxChangePercent versus the last accepted table (kept for BaselineTTL)
+// is quarantined for QuarantineTTL instead of being served.
+type ValidationConfig struct {
+	MinRate          float64       `yaml:"min_rate"`
+	MaxRate          float64       `yaml:"max_rate"`
+	MaxChangePercent float64       `yaml:"max_change_percent"`
+	BaselineTTL      time.Duration `yaml:"baseline_ttl"`
+	QuarantineTTL    time.Duration `yaml:"quarantine_ttl"`
+}
+
+// ProviderTypeOpenERAPI is the open.er-api.com / exchangerate-api.com v6 API
+const ProviderTypeOpenERAPI = "open.er-api"
+
+// ProviderConfig describes one exchange rate provider. BaseURL serves keyless
+// (free tier) requests; once APIKey is set requests go to PaidBaseURL instead,
+// with the key sent in the URL path or in APIKeyHeader as APIKeyLocation says.
+// Lower Priority values are tried first.
 type ProviderConfig struct {
-	Name     string
-	BaseURL  string
-	APIKey   string
-	Timeout  time.Duration
-	Priority int
-}
\ No newline at end of file
+	Name           string               `yaml:"name"`
+	Type           string               `yaml:"type"`
+	BaseURL        string               `yaml:"base_url"`
+	PaidBaseURL    string               `yaml:"paid_base_url"`
+	APIKey         string               `yaml:"api_key" secret:"true"`
+	APIKeyLocation string               `yaml:"api_key_location"`
+	APIKeyHeader   string               `yaml:"api_key_header"`
+	Timeout        time.Duration        `yaml:"timeout"`
+	Priority       int                  `yaml:"priority"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	Retry          RetryConfig          `yaml:"retry"`
+}
+
+// UnmarshalYAML decodes a provider entry on top of the provider defaults, so
+// a config file only has to list the settings it changes
+func (p *ProviderConfig) UnmarshalYAML(value *yaml.Node) error {
+	type plain ProviderConfig
+	decoded := plain(DefaultProvider())
+	if err := value.Decode(&decoded); err != nil {
+		return err
+	}
+	*p = ProviderConfig(decoded)
+	return nil
+}
+
+// CircuitBreakerConfig controls when calls to a provider are short-circuited.
+// FailureThreshold consecutive failures open the breaker; after OpenTimeout it
+// lets HalfOpenRequests trial calls through before closing again.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"`
+	OpenTimeout      time.Duration `yaml:"open_timeout"`
+	HalfOpenRequests int           `yaml:"half_open_requests"`
+}
+
+// RetryConfig controls how failed provider calls are retried. Backoff grows
+// from InitialBackoff by Multiplier up to MaxBackoff, randomised by +/- Jitter
+// (a fraction of the delay). A Retry-After header on a retryable response takes
+// precedence over the computed backoff.
+type RetryConfig struct {
+	MaxAttempts          int           `yaml:"max_attempts"`
+	InitialBackoff       time.Duration `yaml:"initial_backoff"`
+	MaxBackoff           time.Duration `yaml:"max_backoff"`
+	Multiplier           float64       `yaml:"multiplier"`
+	Jitter               float64       `yaml:"jitter"`
+	RetryableStatusCodes []int         `yaml:"retryable_status_codes"`
+}
+
+// Secrets returns every credential in the configuration, for log redaction
+func (c *Config) Secrets() []string {
+	secrets := []string{c.Redis.Password, c.Admin.Token}
+	for _, p := range c.Providers {
+		secrets = append(secrets, p.APIKey)
+	}
+	return secrets
+}
+
+// RestartRequired names the sections that differ between c and next but are
+// only read at startup, so reloading next can't apply them
+func (c *Config) RestartRequired(next *Config) []string {
+	var sections []string
+	if c.Server != next.Server {
+		sections = append(sections, "server")
+	}
+	if c.Redis != next.Redis {
+		sections = append(sections, "redis")
+	}
+	if c.History != next.History {
+		sections = append(sections, "history")
+	}
+	if c.Features != next.Features {
+		sections = append(sections, "features")
+	}
+	if c.Admin != next.Admin {
+		sections = append(sections, "admin")
+	}
+	if !reflect.DeepEqual(c.Streaming, next.Streaming) {
+		sections = append(sections, "streaming")
+	}
+	if !reflect.DeepEqual(c.Alerts, next.Alerts) {
+		sections = append(sections, "alerts")
+	}
+	return sections
+}
@@ -0,0 +1,180 @@
+package configs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayersFileAndEnvironmentOverDefaults(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: "8081"
+log:
+  level: debug
+providers:
+  - name: open.er-api.com
+    timeout: 3s
+  - name: backup
+    base_url: https://backup.example.com
+    priority: 2
+`)
+	t.Setenv("PORT", "9000")
+	t.Setenv("OPEN_ER_API_KEY", "k3y")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != "9000" {
+		t.Errorf("server.port = %q, want the environment to win", cfg.Server.Port)
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("log.level = %q, want debug from the file", cfg.Log.Level)
+	}
+	if cfg.Cache.LatestTTL != Default().Cache.LatestTTL {
+		t.Errorf("cache.latest_ttl = %v, want the default", cfg.Cache.LatestTTL)
+	}
+
+	if len(cfg.Providers) != 2 {
+		t.Fatalf("got %d providers, want 2", len(cfg.Providers))
+	}
+	primary, backup := cfg.Providers[0], cfg.Providers[1]
+	if primary.Timeout != 3*time.Second || primary.APIKey != "k3y" {
+		t.Errorf("primary provider = %+v, want the file timeout and the environment key", primary)
+	}
+	// Provider entries start from the provider defaults
+	if backup.Retry.MaxAttempts != DefaultProvider().Retry.MaxAttempts || backup.APIKey != "" {
+		t.Errorf("backup provider = %+v, want provider defaults without the primary's key", backup)
+	}
+}
+
+func TestLoadExampleMatchesDefaults(t *testing.T) {
+	cfg, err := Load("config.example.yaml")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Error("config.example.yaml differs from the defaults; regenerate it with --print-config")
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  prot: "8080"
+cache:
+  latest_ttl: 1m
+providers:
+  - name: open.er-api.com
+    retry:
+      max_attemps: 3
+typo: true
+`)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Load accepted unknown fields")
+	}
+	for _, want := range []string{"server.prot: unknown field (line 3)", "providers[0].retry.max_attemps: unknown field (line 9)", "typo: unknown field (line 10)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadReportsBadEnvironment(t *testing.T) {
+	t.Setenv("REDIS_DB", "zero")
+	t.Setenv("CACHE_LATEST_TTL", "5")
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("Load accepted unparseable environment variables")
+	}
+	for _, want := range []string{`REDIS_DB="zero"`, `CACHE_LATEST_TTL="5"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("defaults do not validate: %v", err)
+	}
+
+	cfg := Default()
+	cfg.Server.Port = "http"
+	cfg.Log.Level = "verbose"
+	cfg.Cache.LatestTTL = 0
+	cfg.Providers = append(cfg.Providers, cfg.Providers[0])
+	cfg.Providers[0].BaseURL = "open.er-api.com"
+	cfg.Streaming.AllowedOrigins = []string{"*", "https://app.example.com/path"}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid configuration validated")
+	}
+	for _, want := range []string{
+		`server.port: must be a port number between 1 and 65535, got "http"`,
+		`log.level: must be one of debug, info, warn, error, got "verbose"`,
+		"cache.latest_ttl: must be greater than 0",
+		`providers[1].name: duplicate provider name "open.er-api.com"`,
+		"providers[0].base_url: must be an absolute http(s) URL",
+		`streaming.allowed_origins[1]: must be an origin such as https://app.example.com or *, got "https://app.example.com/path"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not report %q", err, want)
+		}
+	}
+}
+
+func TestWriteRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Redis.Password = "redis-pass"
+	cfg.Admin.Token = "admin-token"
+	cfg.Providers[0].APIKey = "k3y"
+
+	var buf bytes.Buffer
+	if err := cfg.WriteRedacted(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, secret := range cfg.Secrets() {
+		if strings.Contains(out, secret) {
+			t.Errorf("printed config contains the secret %q", secret)
+		}
+	}
+	for _, want := range []string{"password: '[REDACTED]'", "api_key: '[REDACTED]'", "latest_ttl: 5m0s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printed config lacks %q:\n%s", want, out)
+		}
+	}
+
+	// Unset secrets stay empty, so the output shows they are not configured
+	var empty bytes.Buffer
+	Default().WriteRedacted(&empty)
+	if strings.Contains(empty.String(), "[REDACTED]") {
+		t.Error("empty secrets were printed as redacted")
+	}
+
+	// The printed configuration loads back, minus the secrets
+	loaded, err := Load(writeConfig(t, out))
+	if err != nil {
+		t.Fatalf("loading printed config: %v", err)
+	}
+	if loaded.Cache != cfg.Cache || loaded.Admin.Token != redacted {
+		t.Errorf("printed config did not round-trip: %+v", loaded.Cache)
+	}
+}
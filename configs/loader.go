@@ -1,63 +1,425 @@
 package configs
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
-)
-
-func Load() (*Config, error) {
-	port := getEnv("PORT", "8080")
-	shutdownTimeout := getEnvAsDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
 
-	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
-	redisPassword := getEnv("REDIS_PASSWORD", "")
-	redisDB := getEnvAsInt("REDIS_DB", 0)
+	"gopkg.in/yaml.v3"
+)
 
-	// Provider configurations - only using open.er-api.com
-	provider := &ProviderConfig{
-			Name:     "open.er-api.com",
-			BaseURL:  getEnv("OPEN_ER_API_URL", "https://open.er-api.com/v6"),
-			APIKey:   getEnv("OPEN_ER_API_KEY", ""),
-			Timeout:  getEnvAsDuration("OPEN_ER_API_TIMEOUT", 10*time.Second),
-			Priority: 1,
-	}
+// DefaultProviderName is the provider configured when the config file lists none.
+// The legacy OPEN_ER_API_* environment variables apply to it.
+const DefaultProviderName = "open.er-api.com"
 
+// Default returns the built-in configuration
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:            port,
-			ShutdownTimeout: shutdownTimeout,
+			Port:            "8080",
+			GRPCPort:        "9090",
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Log: LogConfig{
+			Level: "info",
 		},
 		Redis: RedisConfig{
-			Addr:     redisAddr,
-			Password: redisPassword,
-			DB:       redisDB,
+			Addr: "localhost:6379",
+		},
+		Cache: CacheConfig{
+			LatestTTL:     5 * time.Minute,
+			StaleTTL:      time.Minute,
+			HistoricalTTL: 24 * time.Hour,
+			CurrenciesTTL: 24 * time.Hour,
+		},
+		History: HistoryConfig{
+			File:               "data/history.json",
+			FlushInterval:      2 * time.Second,
+			MaxRangeDays:       366,
+			MaxBucketRangeDays: 3660,
+			MaxExportRangeDays: 3660,
+			FetchWorkers:       4,
+			Calendar:           "weekends",
+			Fill:               "none",
+		},
+		Providers: []ProviderConfig{DefaultProvider()},
+		Aggregation: AggregationConfig{
+			Mode:         "failover",
+			MaxDeviation: 1.0,
+			MinProviders: 1,
+		},
+		Validation: ValidationConfig{
+			MinRate:          1e-9,
+			MaxRate:          1e9,
+			MaxChangePercent: 10,
+			BaselineTTL:      24 * time.Hour,
+			QuarantineTTL:    time.Hour,
+		},
+		Limits: LimitsConfig{
+			Burst: 20,
+		},
+		Compression: CompressionConfig{
+			Enabled: true,
+			MinSize: 1024,
+			Level:   6,
+		},
+		Features: FeaturesConfig{
+			AdminAPI:        true,
+			Metrics:         true,
+			HistoricalRates: true,
+			Alerts:          true,
+		},
+		Streaming: StreamingConfig{
+			RefreshInterval:   30 * time.Second,
+			HeartbeatInterval: 15 * time.Second,
+			PingInterval:      30 * time.Second,
+			HistorySize:       256,
+			SubscriberBuffer:  32,
+			MaxBases:          10,
+			AllowedOrigins:    []string{},
 		},
-		Providers: *provider,
-	}, nil
+		Alerts: AlertsConfig{
+			StoreFile:          "data/alerts.json",
+			EvaluationInterval: time.Minute,
+			Workers:            4,
+			Timeout:            10 * time.Second,
+			Retry: RetryConfig{
+				MaxAttempts:          5,
+				InitialBackoff:       time.Second,
+				MaxBackoff:           time.Minute,
+				Multiplier:           2,
+				Jitter:               0.2,
+				RetryableStatusCodes: []int{408, 429, 500, 502, 503, 504},
+			},
+			DeliveryLogSize: 50,
+		},
+		Admin: AdminConfig{
+			OverridesFile: "data/overrides.json",
+		},
+		Health: HealthConfig{
+			ProviderCacheTTL: 30 * time.Second,
+		},
+	}
+}
+
+// DefaultProvider returns the defaults every provider entry starts from
+func DefaultProvider() ProviderConfig {
+	return ProviderConfig{
+		Name:           DefaultProviderName,
+		Type:           ProviderTypeOpenERAPI,
+		BaseURL:        "https://open.er-api.com/v6",
+		PaidBaseURL:    "https://v6.exchangerate-api.com/v6",
+		APIKeyLocation: "path",
+		APIKeyHeader:   "Authorization",
+		Timeout:        10 * time.Second,
+		Priority:       1,
+		CircuitBreaker: CircuitBreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+			HalfOpenRequests: 1,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:          3,
+			InitialBackoff:       200 * time.Millisecond,
+			MaxBackoff:           5 * time.Second,
+			Multiplier:           2,
+			Jitter:               0.2,
+			RetryableStatusCodes: []int{429, 500, 502, 503, 504},
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path (if
+// path is not empty) and environment overrides, then validates it
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile decodes a YAML config file over cfg. Unknown keys are rejected so a
+// typo doesn't silently fall back to a default.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+
+	if errs := unknownFields(root.Content[0], reflect.TypeOf(Config{}), ""); len(errs) > 0 {
+		return fmt.Errorf("invalid config file %s: %w", path, errors.Join(errs...))
+	}
+	if err := root.Decode(cfg); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// unknownFields reports mapping keys in node that have no matching yaml tag in t
+func unknownFields(node *yaml.Node, t reflect.Type, prefix string) []error {
+	switch {
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		var errs []error
+		for i, item := range node.Content {
+			errs = append(errs, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+		return errs
+	case t.Kind() != reflect.Struct || node.Kind != yaml.MappingNode:
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		fields[name] = t.Field(i).Type
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := joinPath(prefix, key.Value)
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown field (line %d)", path, key.Line))
+			continue
+		}
+		errs = append(errs, unknownFields(value, fieldType, path)...)
+	}
+	return errs
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
 	}
-	return defaultValue
+	return prefix + "." + name
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// applyEnv layers environment variables over cfg. Variables that are set but
+// can't be parsed are reported rather than ignored.
+func applyEnv(cfg *Config) error {
+	env := &envOverrides{}
+
+	env.string(&cfg.Server.Port, "PORT")
+	env.string(&cfg.Server.GRPCPort, "GRPC_PORT")
+	env.duration(&cfg.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	env.string(&cfg.Log.Level, "LOG_LEVEL")
+
+	env.string(&cfg.Redis.Addr, "REDIS_ADDR")
+	env.string(&cfg.Redis.Password, "REDIS_PASSWORD")
+	env.int(&cfg.Redis.DB, "REDIS_DB")
+
+	env.duration(&cfg.Cache.LatestTTL, "CACHE_LATEST_TTL")
+	env.duration(&cfg.Cache.StaleTTL, "CACHE_STALE_TTL")
+	env.duration(&cfg.Cache.HistoricalTTL, "CACHE_HISTORICAL_TTL")
+	env.duration(&cfg.Cache.CurrenciesTTL, "CACHE_CURRENCIES_TTL")
+
+	for i := range cfg.Providers {
+		if cfg.Providers[i].Name != DefaultProviderName {
+			continue
 		}
+		provider := &cfg.Providers[i]
+		env.string(&provider.BaseURL, "OPEN_ER_API_URL")
+		env.string(&provider.PaidBaseURL, "OPEN_ER_API_PAID_URL")
+		env.string(&provider.APIKey, "OPEN_ER_API_KEY")
+		env.string(&provider.APIKeyLocation, "OPEN_ER_API_KEY_LOCATION")
+		env.string(&provider.APIKeyHeader, "OPEN_ER_API_KEY_HEADER")
+		env.duration(&provider.Timeout, "OPEN_ER_API_TIMEOUT")
+		env.int(&provider.CircuitBreaker.FailureThreshold, "OPEN_ER_API_CB_FAILURE_THRESHOLD")
+		env.duration(&provider.CircuitBreaker.OpenTimeout, "OPEN_ER_API_CB_OPEN_TIMEOUT")
+		env.int(&provider.CircuitBreaker.HalfOpenRequests, "OPEN_ER_API_CB_HALF_OPEN_REQUESTS")
+		env.int(&provider.Retry.MaxAttempts, "OPEN_ER_API_RETRY_MAX_ATTEMPTS")
+		env.duration(&provider.Retry.InitialBackoff, "OPEN_ER_API_RETRY_INITIAL_BACKOFF")
+		env.duration(&provider.Retry.MaxBackoff, "OPEN_ER_API_RETRY_MAX_BACKOFF")
+		env.float(&provider.Retry.Multiplier, "OPEN_ER_API_RETRY_MULTIPLIER")
+		env.float(&provider.Retry.Jitter, "OPEN_ER_API_RETRY_JITTER")
+		env.intSlice(&provider.Retry.RetryableStatusCodes, "OPEN_ER_API_RETRY_STATUS_CODES")
+	}
+
+	env.string(&cfg.Aggregation.Mode, "RATE_AGGREGATION_MODE")
+	env.float(&cfg.Aggregation.MaxDeviation, "RATE_CONSENSUS_MAX_DEVIATION")
+	env.int(&cfg.Aggregation.MinProviders, "RATE_CONSENSUS_MIN_PROVIDERS")
+
+	env.float(&cfg.Validation.MinRate, "RATE_VALIDATION_MIN_RATE")
+	env.float(&cfg.Validation.MaxRate, "RATE_VALIDATION_MAX_RATE")
+	env.float(&cfg.Validation.MaxChangePercent, "RATE_VALIDATION_MAX_CHANGE")
+	env.duration(&cfg.Validation.BaselineTTL, "RATE_VALIDATION_BASELINE_TTL")
+	env.duration(&cfg.Validation.QuarantineTTL, "RATE_VALIDATION_QUARANTINE_TTL")
+
+	env.string(&cfg.History.File, "HISTORY_FILE")
+	env.duration(&cfg.History.FlushInterval, "HISTORY_FLUSH_INTERVAL")
+	env.int(&cfg.History.MaxRangeDays, "HISTORY_MAX_RANGE_DAYS")
+	env.int(&cfg.History.MaxBucketRangeDays, "HISTORY_MAX_BUCKET_RANGE_DAYS")
+	env.int(&cfg.History.MaxExportRangeDays, "HISTORY_MAX_EXPORT_RANGE_DAYS")
+	env.int(&cfg.History.FetchWorkers, "HISTORY_FETCH_WORKERS")
+	env.string(&cfg.History.Calendar, "HISTORY_CALENDAR")
+	env.string(&cfg.History.Fill, "HISTORY_FILL")
+
+	env.float(&cfg.Limits.RequestsPerSecond, "RATE_LIMIT_RPS")
+	env.int(&cfg.Limits.Burst, "RATE_LIMIT_BURST")
+
+	env.bool(&cfg.Compression.Enabled, "COMPRESSION_ENABLED")
+	env.int(&cfg.Compression.MinSize, "COMPRESSION_MIN_SIZE")
+	env.int(&cfg.Compression.Level, "COMPRESSION_LEVEL")
+
+	env.bool(&cfg.Features.AdminAPI, "FEATURE_ADMIN_API")
+	env.bool(&cfg.Features.Metrics, "FEATURE_METRICS")
+	env.bool(&cfg.Features.HistoricalRates, "FEATURE_HISTORICAL_RATES")
+	env.bool(&cfg.Features.Alerts, "FEATURE_ALERTS")
+
+	env.duration(&cfg.Streaming.RefreshInterval, "STREAM_REFRESH_INTERVAL")
+	env.duration(&cfg.Streaming.HeartbeatInterval, "STREAM_HEARTBEAT_INTERVAL")
+	env.duration(&cfg.Streaming.PingInterval, "STREAM_PING_INTERVAL")
+	env.int(&cfg.Streaming.HistorySize, "STREAM_HISTORY_SIZE")
+	env.int(&cfg.Streaming.SubscriberBuffer, "STREAM_SUBSCRIBER_BUFFER")
+	env.int(&cfg.Streaming.MaxBases, "STREAM_MAX_BASES")
+	env.stringSlice(&cfg.Streaming.AllowedOrigins, "STREAM_ALLOWED_ORIGINS")
+
+	env.string(&cfg.Alerts.StoreFile, "ALERTS_STORE_FILE")
+	env.duration(&cfg.Alerts.EvaluationInterval, "ALERTS_EVALUATION_INTERVAL")
+	env.int(&cfg.Alerts.Workers, "ALERTS_WORKERS")
+	env.duration(&cfg.Alerts.Timeout, "ALERTS_WEBHOOK_TIMEOUT")
+	env.int(&cfg.Alerts.Retry.MaxAttempts, "ALERTS_RETRY_MAX_ATTEMPTS")
+	env.int(&cfg.Alerts.DeliveryLogSize, "ALERTS_DELIVERY_LOG_SIZE")
+	env.bool(&cfg.Alerts.AllowPrivateWebhooks, "ALERTS_ALLOW_PRIVATE_WEBHOOKS")
+
+	env.string(&cfg.Admin.Token, "ADMIN_API_TOKEN")
+	env.string(&cfg.Admin.OverridesFile, "OVERRIDES_FILE")
+
+	env.duration(&cfg.Health.ProviderCacheTTL, "HEALTH_PROVIDER_CACHE_TTL")
+
+	return errors.Join(env.errs...)
+}
+
+// envOverrides sets config fields from environment variables that are present,
+// collecting parse errors
+type envOverrides struct {
+	errs []error
+}
+
+func (e *envOverrides) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (e *envOverrides) fail(key, value, expected string) {
+	e.errs = append(e.errs, fmt.Errorf("%s=%q: expected %s", key, value, expected))
+}
+
+func (e *envOverrides) string(dst *string, key string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
 	}
-	return defaultValue
 }
 
-func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
+func (e *envOverrides) int(dst *int, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(key, value, "an integer")
+		return
+	}
+	*dst = intValue
+}
+
+func (e *envOverrides) duration(dst *time.Duration, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(key, value, "a duration such as 30s or 5m")
+		return
+	}
+	*dst = duration
+}
+
+func (e *envOverrides) float(dst *float64, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(key, value, "a number")
+		return
+	}
+	*dst = floatValue
+}
+
+func (e *envOverrides) bool(dst *bool, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, value, "true or false")
+		return
+	}
+	*dst = boolValue
+}
+
+func (e *envOverrides) stringSlice(dst *[]string, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	*dst = values
+}
+
+func (e *envOverrides) intSlice(dst *[]int, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	var values []int
+	for _, part := range strings.Split(value, ",") {
+		intValue, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			e.fail(key, value, "a comma-separated list of integers")
+			return
 		}
+		values = append(values, intValue)
 	}
-	return defaultValue
+	*dst = values
 }
@@ -0,0 +1,71 @@
+package configs
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values in printed configuration
+const redacted = "[REDACTED]"
+
+// WriteRedacted writes the configuration as YAML with secrets redacted and
+// durations in their readable form, e.g. for --print-config
+func (c *Config) WriteRedacted(w io.Writer) error {
+	node, err := redactedNode(reflect.ValueOf(*c))
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return enc.Close()
+}
+
+func redactedNode(v reflect.Value) (*yaml.Node, error) {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: time.Duration(v.Int()).String()}, nil
+	case v.Kind() == reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+			var value *yaml.Node
+			if field.Tag.Get("secret") == "true" && v.Field(i).String() != "" {
+				value = &yaml.Node{Kind: yaml.ScalarNode, Value: redacted}
+			} else {
+				var err error
+				if value, err = redactedNode(v.Field(i)); err != nil {
+					return nil, err
+				}
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+		}
+		return node, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := 0; i < v.Len(); i++ {
+			item, err := redactedNode(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, item)
+		}
+		return node, nil
+	default:
+		node := &yaml.Node{}
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+}
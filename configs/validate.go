@@ -0,0 +1,243 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Validate checks the configuration and reports every problem found, each
+// prefixed with the path of the offending setting
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port("server.port", c.Server.Port)
+	if c.Server.GRPCPort != "" {
+		v.port("server.grpc_port", c.Server.GRPCPort)
+		if c.Server.GRPCPort == c.Server.Port {
+			v.fail("server.grpc_port", "must differ from server.port")
+		}
+	}
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout.Seconds())
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		v.fail("log.level", "must be one of debug, info, warn, error, got %q", c.Log.Level)
+	}
+
+	v.positive("cache.latest_ttl", c.Cache.LatestTTL.Seconds())
+	v.positive("cache.stale_ttl", c.Cache.StaleTTL.Seconds())
+	v.positive("cache.historical_ttl", c.Cache.HistoricalTTL.Seconds())
+	v.positive("cache.currencies_ttl", c.Cache.CurrenciesTTL.Seconds())
+
+	if c.History.File == "" {
+		v.fail("history.file", "is required")
+	}
+	if c.History.FlushInterval < 0 {
+		v.fail("history.flush_interval", "must not be negative (0 writes every rate through)")
+	}
+	v.positive("history.max_range_days", float64(c.History.MaxRangeDays))
+	if c.History.MaxBucketRangeDays < c.History.MaxRangeDays {
+		v.fail("history.max_bucket_range_days", "must not be less than history.max_range_days")
+	}
+	v.positive("history.max_export_range_days", float64(c.History.MaxExportRangeDays))
+	v.positive("history.fetch_workers", float64(c.History.FetchWorkers))
+	if c.History.Calendar == "" {
+		v.fail("history.calendar", "is required")
+	}
+	switch c.History.Fill {
+	case "none", "previous", "next", "linear":
+	default:
+		v.fail("history.fill", "must be none, previous, next or linear, got %q", c.History.Fill)
+	}
+
+	if len(c.Providers) == 0 {
+		v.fail("providers", "at least one provider is required")
+	}
+	names := make(map[string]bool)
+	for i, p := range c.Providers {
+		path := fmt.Sprintf("providers[%d]", i)
+		if p.Name == "" {
+			v.fail(path+".name", "is required")
+		} else if names[p.Name] {
+			v.fail(path+".name", "duplicate provider name %q", p.Name)
+		}
+		names[p.Name] = true
+		v.validateProvider(path, p)
+	}
+
+	switch c.Aggregation.Mode {
+	case "failover":
+	case "consensus":
+		if c.Aggregation.MinProviders > len(c.Providers) {
+			v.fail("aggregation.min_providers", "needs %d providers but only %d are configured",
+				c.Aggregation.MinProviders, len(c.Providers))
+		}
+	default:
+		v.fail("aggregation.mode", "must be failover or consensus, got %q", c.Aggregation.Mode)
+	}
+	v.positive("aggregation.max_deviation", c.Aggregation.MaxDeviation)
+	if c.Aggregation.MinProviders < 1 {
+		v.fail("aggregation.min_providers", "must be at least 1")
+	}
+
+	if c.Validation.MinRate < 0 {
+		v.fail("validation.min_rate", "must not be negative")
+	}
+	if c.Validation.MaxRate <= c.Validation.MinRate {
+		v.fail("validation.max_rate", "must be greater than validation.min_rate")
+	}
+	if c.Validation.MaxChangePercent < 0 {
+		v.fail("validation.max_change_percent", "must not be negative (0 disables the check)")
+	}
+	v.positive("validation.baseline_ttl", c.Validation.BaselineTTL.Seconds())
+	v.positive("validation.quarantine_ttl", c.Validation.QuarantineTTL.Seconds())
+
+	if c.Limits.RequestsPerSecond < 0 {
+		v.fail("limits.requests_per_second", "must not be negative (0 disables rate limiting)")
+	}
+	if c.Limits.RequestsPerSecond > 0 && c.Limits.Burst < 1 {
+		v.fail("limits.burst", "must be at least 1 when rate limiting is enabled")
+	}
+
+	if c.Compression.MinSize < 0 {
+		v.fail("compression.min_size", "must not be negative")
+	}
+	if c.Compression.Level < 1 || c.Compression.Level > 9 {
+		v.fail("compression.level", "must be between 1 and 9")
+	}
+
+	v.positive("streaming.refresh_interval", c.Streaming.RefreshInterval.Seconds())
+	v.positive("streaming.heartbeat_interval", c.Streaming.HeartbeatInterval.Seconds())
+	v.positive("streaming.ping_interval", c.Streaming.PingInterval.Seconds())
+	if c.Streaming.HistorySize < 1 {
+		v.fail("streaming.history_size", "must be at least 1")
+	}
+	if c.Streaming.SubscriberBuffer < 1 {
+		v.fail("streaming.subscriber_buffer", "must be at least 1")
+	}
+	if c.Streaming.MaxBases < 1 {
+		v.fail("streaming.max_bases", "must be at least 1")
+	}
+	for i, origin := range c.Streaming.AllowedOrigins {
+		if origin != "*" {
+			v.origin(fmt.Sprintf("streaming.allowed_origins[%d]", i), origin)
+		}
+	}
+
+	if c.Alerts.StoreFile == "" {
+		v.fail("alerts.store_file", "is required")
+	}
+	v.positive("alerts.evaluation_interval", c.Alerts.EvaluationInterval.Seconds())
+	if c.Alerts.Workers < 1 {
+		v.fail("alerts.workers", "must be at least 1")
+	}
+	v.positive("alerts.timeout", c.Alerts.Timeout.Seconds())
+	v.validateRetry("alerts.retry", c.Alerts.Retry)
+	if c.Alerts.DeliveryLogSize < 1 {
+		v.fail("alerts.delivery_log_size", "must be at least 1")
+	}
+
+	if c.Admin.OverridesFile == "" {
+		v.fail("admin.overrides_file", "is required")
+	}
+	v.positive("health.provider_cache_ttl", c.Health.ProviderCacheTTL.Seconds())
+
+	return errors.Join(v.errs...)
+}
+
+func (v *validator) validateProvider(path string, p ProviderConfig) {
+	if p.Type != ProviderTypeOpenERAPI {
+		v.fail(path+".type", "unsupported provider type %q (supported: %s)", p.Type, ProviderTypeOpenERAPI)
+	}
+	v.url(path+".base_url", p.BaseURL, true)
+	v.url(path+".paid_base_url", p.PaidBaseURL, p.APIKey != "")
+	switch p.APIKeyLocation {
+	case "path":
+	case "header":
+		if p.APIKeyHeader == "" {
+			v.fail(path+".api_key_header", "is required when api_key_location is header")
+		}
+	default:
+		v.fail(path+".api_key_location", "must be path or header, got %q", p.APIKeyLocation)
+	}
+	v.positive(path+".timeout", p.Timeout.Seconds())
+	if p.Priority < 0 {
+		v.fail(path+".priority", "must not be negative")
+	}
+
+	if p.CircuitBreaker.FailureThreshold < 1 {
+		v.fail(path+".circuit_breaker.failure_threshold", "must be at least 1")
+	}
+	v.positive(path+".circuit_breaker.open_timeout", p.CircuitBreaker.OpenTimeout.Seconds())
+	if p.CircuitBreaker.HalfOpenRequests < 1 {
+		v.fail(path+".circuit_breaker.half_open_requests", "must be at least 1")
+	}
+
+	v.validateRetry(path+".retry", p.Retry)
+}
+
+func (v *validator) validateRetry(path string, r RetryConfig) {
+	if r.MaxAttempts < 1 {
+		v.fail(path+".max_attempts", "must be at least 1")
+	}
+	v.positive(path+".initial_backoff", r.InitialBackoff.Seconds())
+	if r.MaxBackoff < r.InitialBackoff {
+		v.fail(path+".max_backoff", "must not be less than retry.initial_backoff")
+	}
+	if r.Multiplier < 1 {
+		v.fail(path+".multiplier", "must be at least 1")
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		v.fail(path+".jitter", "must be between 0 and 1")
+	}
+	for _, code := range r.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			v.fail(path+".retryable_status_codes", "%d is not an HTTP status code", code)
+		}
+	}
+}
+
+// validator collects configuration errors
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) positive(path string, value float64) {
+	if value <= 0 {
+		v.fail(path, "must be greater than 0")
+	}
+}
+
+func (v *validator) port(path, value string) {
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		v.fail(path, "must be a port number between 1 and 65535, got %q", value)
+	}
+}
+
+func (v *validator) url(path, value string, required bool) {
+	if value == "" {
+		if required {
+			v.fail(path, "is required")
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.fail(path, "must be an absolute http(s) URL, got %q", value)
+	}
+}
+
+// origin checks a browser origin: an http(s) scheme and host, nothing more
+func (v *validator) origin(path, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		v.fail(path, "must be an origin such as https://app.example.com or *, got %q", value)
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+)
+
+// SetOverride handles requests to pin the rate of a currency pair
+func (h *Handlers) SetOverride(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	baseCurrency := vars["base"]
+	targetCurrency := vars["target"]
+
+	level.Debug(h.logger).Log("method", "SetOverride", "base", baseCurrency, "target", targetCurrency, "remote_addr", r.RemoteAddr)
+
+	var req models.OverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		level.Error(h.logger).Log("error", err, "method", "SetOverride")
+		models.WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	req.BaseCurrency = baseCurrency
+	req.TargetCurrency = targetCurrency
+	if req.Author == "" {
+		req.Author = r.Header.Get("X-Admin-User")
+	}
+
+	ctx := r.Context()
+	override, err := h.adminService.SetOverride(ctx, &req)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "SetOverride")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to set override")
+		return
+	}
+
+	models.WriteSuccess(w, override, "Override set successfully")
+}
+
+// ListOverrides handles requests to list all rate overrides
+func (h *Handlers) ListOverrides(w http.ResponseWriter, r *http.Request) {
+	level.Debug(h.logger).Log("method", "ListOverrides", "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	overrides, err := h.adminService.ListOverrides(ctx)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "ListOverrides")
+		models.WriteInternalError(w, "Failed to list overrides")
+		return
+	}
+
+	response := map[string]interface{}{
+		"overrides": overrides,
+		"count":     len(overrides),
+	}
+
+	models.WriteSuccess(w, response, "Overrides retrieved successfully")
+}
+
+// DeleteOverride handles requests to remove the override of a currency pair
+func (h *Handlers) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	baseCurrency := vars["base"]
+	targetCurrency := vars["target"]
+
+	level.Debug(h.logger).Log("method", "DeleteOverride", "base", baseCurrency, "target", targetCurrency, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	if err := h.adminService.DeleteOverride(ctx, baseCurrency, targetCurrency); err != nil {
+		level.Error(h.logger).Log("error", err, "method", "DeleteOverride")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+		if errors.IsNotFoundError(err) {
+			models.WriteNotFound(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to delete override")
+		return
+	}
+
+	models.WriteSuccess(w, nil, "Override removed successfully")
+}
+
+// ListCacheKeys handles requests to list cached keys matching ?pattern=
+func (h *Handlers) ListCacheKeys(w http.ResponseWriter, r *http.Request) {
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	level.Debug(h.logger).Log("method", "ListCacheKeys", "pattern", pattern, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	keys, err := h.adminService.ListCacheKeys(ctx, pattern)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "ListCacheKeys")
+		models.WriteError(w, errors.GetHTTPStatusCode(err), "Cache Error", "CACHE_ERROR", "Failed to list cache keys")
+		return
+	}
+
+	response := map[string]interface{}{
+		"pattern": pattern,
+		"keys":    keys,
+		"count":   len(keys),
+	}
+
+	models.WriteSuccess(w, response, "Cache keys retrieved successfully")
+}
+
+// GetCacheEntry handles requests to view a cached entry and its remaining TTL
+func (h *Handlers) GetCacheEntry(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	level.Debug(h.logger).Log("method", "GetCacheEntry", "key", key, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	entry, err := h.adminService.GetCacheEntry(ctx, key)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "GetCacheEntry")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+		if errors.IsNotFoundError(err) {
+			models.WriteNotFound(w, err.Error())
+			return
+		}
+
+		models.WriteError(w, errors.GetHTTPStatusCode(err), "Cache Error", "CACHE_ERROR", "Failed to read cache entry")
+		return
+	}
+
+	models.WriteSuccess(w, entry, "Cache entry retrieved successfully")
+}
+
+// InvalidateCache handles requests to drop cached data. ?base= and ?target=
+// select a pair or a base currency; ?all=true drops everything.
+func (h *Handlers) InvalidateCache(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	baseCurrency := q.Get("base")
+	targetCurrency := q.Get("target")
+
+	level.Debug(h.logger).Log("method", "InvalidateCache", "base", baseCurrency, "target", targetCurrency, "remote_addr", r.RemoteAddr)
+
+	// Dropping everything must be asked for explicitly
+	if baseCurrency == "" && targetCurrency == "" && q.Get("all") != "true" {
+		models.WriteBadRequest(w, "Specify base (and optionally target), or all=true")
+		return
+	}
+
+	ctx := r.Context()
+	result, err := h.adminService.InvalidateCache(ctx, baseCurrency, targetCurrency)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "InvalidateCache")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+
+		models.WriteError(w, errors.GetHTTPStatusCode(err), "Cache Error", "CACHE_ERROR", "Failed to invalidate cache")
+		return
+	}
+
+	models.WriteSuccess(w, result, "Cache invalidated successfully")
+}
+
+// RefreshRates handles requests to force a provider refresh for ?base=
+func (h *Handlers) RefreshRates(w http.ResponseWriter, r *http.Request) {
+	baseCurrency := r.URL.Query().Get("base")
+
+	level.Debug(h.logger).Log("method", "RefreshRates", "base", baseCurrency, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	table, err := h.adminService.RefreshRates(ctx, baseCurrency)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "RefreshRates")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+
+		models.WriteError(w, errors.GetHTTPStatusCode(err), "Provider Error", "PROVIDER_ERROR", "Failed to refresh rates")
+		return
+	}
+
+	models.WriteSuccess(w, table, "Rates refreshed successfully")
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+)
+
+// CreateAlert handles requests to register a rate alert
+func (h *Handlers) CreateAlert(w http.ResponseWriter, r *http.Request) {
+	level.Debug(h.logger).Log("method", "CreateAlert", "remote_addr", r.RemoteAddr)
+
+	var req models.AlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		level.Error(h.logger).Log("error", err, "method", "CreateAlert")
+		models.WriteBadRequest(w, "Invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	alert, err := h.alertService.CreateAlert(ctx, &req)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "CreateAlert")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to create alert")
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, models.SuccessResponse(alert, "Alert created successfully; store the secret, it is not shown again"))
+}
+
+// ListAlerts handles requests to list all rate alerts
+func (h *Handlers) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	level.Debug(h.logger).Log("method", "ListAlerts", "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	alerts, err := h.alertService.ListAlerts(ctx)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "ListAlerts")
+		models.WriteInternalError(w, "Failed to list alerts")
+		return
+	}
+
+	response := map[string]interface{}{
+		"alerts": alerts,
+		"count":  len(alerts),
+	}
+
+	models.WriteSuccess(w, response, "Alerts retrieved successfully")
+}
+
+// GetAlert handles requests to view a rate alert and its evaluation state
+func (h *Handlers) GetAlert(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	level.Debug(h.logger).Log("method", "GetAlert", "id", id, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	alert, err := h.alertService.GetAlert(ctx, id)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "GetAlert")
+
+		if errors.IsNotFoundError(err) {
+			models.WriteNotFound(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to get alert")
+		return
+	}
+
+	models.WriteSuccess(w, alert, "Alert retrieved successfully")
+}
+
+// DeleteAlert handles requests to remove a rate alert
+func (h *Handlers) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	level.Debug(h.logger).Log("method", "DeleteAlert", "id", id, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	if err := h.alertService.DeleteAlert(ctx, id); err != nil {
+		level.Error(h.logger).Log("error", err, "method", "DeleteAlert")
+
+		if errors.IsNotFoundError(err) {
+			models.WriteNotFound(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to delete alert")
+		return
+	}
+
+	models.WriteSuccess(w, nil, "Alert removed successfully")
+}
+
+// ListAlertDeliveries handles requests to view the webhook delivery log of an alert
+func (h *Handlers) ListAlertDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	level.Debug(h.logger).Log("method", "ListAlertDeliveries", "id", id, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	deliveries, err := h.alertService.ListDeliveries(ctx, id)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "ListAlertDeliveries")
+
+		if errors.IsNotFoundError(err) {
+			models.WriteNotFound(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to list deliveries")
+		return
+	}
+
+	response := map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	}
+
+	models.WriteSuccess(w, response, "Deliveries retrieved successfully")
+}
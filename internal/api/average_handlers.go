@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+)
+
+// GetPeriodAverage handles requests for the average and closing rates of a
+// pair over a month, quarter or year
+func (h *Handlers) GetPeriodAverage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	baseCurrency := vars["base"]
+	targetCurrency := vars["target"]
+	period := r.URL.Query().Get("period")
+
+	level.Debug(h.logger).Log("method", "GetPeriodAverage", "base", baseCurrency, "target", targetCurrency, "period", period, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	average, err := h.exchangeService.GetPeriodAverage(ctx, baseCurrency, targetCurrency, period, r.URL.Query().Get("calendar"))
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "GetPeriodAverage")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+		if errors.IsNotFoundError(err) {
+			models.WriteNotFound(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to compute period average")
+		return
+	}
+
+	models.WriteSuccess(w, average, "Period average computed successfully")
+}
+
+// GetPeriodReport handles requests for the period averages of all currencies
+// against a base
+func (h *Handlers) GetPeriodReport(w http.ResponseWriter, r *http.Request) {
+	baseCurrency := mux.Vars(r)["base"]
+	period := r.URL.Query().Get("period")
+
+	level.Debug(h.logger).Log("method", "GetPeriodReport", "base", baseCurrency, "period", period, "remote_addr", r.RemoteAddr)
+
+	ctx := r.Context()
+	report, err := h.exchangeService.GetPeriodReport(ctx, baseCurrency, period, r.URL.Query().Get("calendar"))
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "GetPeriodReport")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to compute period report")
+		return
+	}
+
+	models.WriteSuccess(w, report, "Period report computed successfully")
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+)
+
+// compressor is the writer of one content coding, as implemented by
+// gzip.Writer and flate.Writer
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// contentCoding is a supported Content-Encoding. Codings listed first win
+// when a client accepts several equally.
+type contentCoding struct {
+	name      string
+	newWriter func(w io.Writer, level int) (compressor, error)
+}
+
+var contentCodings = []contentCoding{
+	{"gzip", func(w io.Writer, level int) (compressor, error) { return gzip.NewWriterLevel(w, level) }},
+	{"deflate", func(w io.Writer, level int) (compressor, error) { return flate.NewWriter(w, level) }},
+}
+
+// Compressor compresses responses with the best content coding a client
+// accepts. Its settings can be changed while it is serving, e.g. on a
+// configuration reload.
+type Compressor struct {
+	mu  sync.RWMutex
+	cfg configs.CompressionConfig
+
+	// writers pools compressors by coding and level, as each one holds
+	// several hundred kilobytes of state
+	writers sync.Map
+}
+
+// NewCompressor creates the compression middleware from the configuration
+func NewCompressor(cfg configs.CompressionConfig) *Compressor {
+	return &Compressor{cfg: cfg}
+}
+
+// Update applies new compression settings
+func (c *Compressor) Update(cfg configs.CompressionConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg = cfg
+}
+
+func (c *Compressor) config() configs.CompressionConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// Middleware compresses responses of at least the configured minimum size.
+// Clients refusing every supported coding, identity included, get a 406.
+// Smaller responses are buffered and sent as they are. A handler that flushes
+// is streaming, so its response is compressed from the first flush on and
+// every flush sends what has been compressed so far. WebSocket handshakes are
+// passed through untouched, since they take over the connection.
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := c.config()
+		if !cfg.Enabled || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		coding, identity := negotiateCoding(r.Header.Get("Accept-Encoding"))
+		if coding == nil {
+			if !identity {
+				models.WriteError(w, http.StatusNotAcceptable, "Not Acceptable", "NOT_ACCEPTABLE",
+					"supported content codings are gzip, deflate and identity")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{ResponseWriter: w, compressor: c, coding: coding, level: cfg.Level, minSize: cfg.MinSize}
+		if !identity {
+			// The client refuses uncompressed bodies, whatever their size
+			cw.minSize, cw.always = 0, true
+		}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+func (c *Compressor) get(coding *contentCoding, level int, w io.Writer) (compressor, error) {
+	pool, _ := c.writers.LoadOrStore(coding.name+strconv.Itoa(level), &sync.Pool{})
+	if zw, ok := pool.(*sync.Pool).Get().(compressor); ok {
+		zw.Reset(w)
+		return zw, nil
+	}
+	return coding.newWriter(w, level)
+}
+
+func (c *Compressor) put(coding *contentCoding, level int, zw compressor) {
+	if pool, ok := c.writers.Load(coding.name + strconv.Itoa(level)); ok {
+		pool.(*sync.Pool).Put(zw)
+	}
+}
+
+// negotiateCoding picks the supported coding with the highest q-value in an
+// Accept-Encoding header, if any, and reports whether the client accepts
+// uncompressed bodies; "*" stands for any coding not listed
+func negotiateCoding(header string) (*contentCoding, bool) {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	var best *contentCoding
+	bestQ := 0.0
+	for i := range contentCodings {
+		q, ok := accepted[contentCodings[i].name]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = &contentCodings[i], q
+		}
+	}
+	identity, ok := accepted["identity"]
+	if !ok {
+		identity, ok = accepted["*"]
+	}
+	return best, !ok || identity > 0
+}
+
+// compressResponseWriter buffers a response until it is known whether it is
+// worth compressing, then writes it compressed or as is
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compressor *Compressor
+	coding     *contentCoding
+	level      int
+	minSize    int
+	// always compresses bodies of any content type
+	always bool
+
+	status   int
+	buf      []byte
+	zw       compressor
+	identity bool
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressResponseWriter) WriteHeader(status int) {
+	if cw.status != 0 || cw.zw != nil || cw.identity {
+		return
+	}
+	cw.status = status
+	// Responses without a body, or already encoded, are sent as they are
+	if status == http.StatusNoContent || status == http.StatusNotModified || cw.Header().Get("Content-Encoding") != "" {
+		cw.start(false)
+	}
+}
+
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	switch {
+	case cw.zw != nil:
+		return cw.zw.Write(p)
+	case cw.identity:
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.minSize {
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends the response so far, compressing it from here on
+func (cw *compressResponseWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.zw == nil && !cw.identity {
+		cw.start(true)
+	}
+	if cw.zw != nil {
+		cw.zw.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// start writes the header and the buffered body, compressed if asked for and
+// the content type is worth it
+func (cw *compressResponseWriter) start(compress bool) error {
+	header := cw.Header()
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if compress && (cw.always || compressible(header.Get("Content-Type"))) {
+		zw, err := cw.compressor.get(cw.coding, cw.level, cw.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		header.Set("Content-Encoding", cw.coding.name)
+		header.Del("Content-Length")
+		// The compressed body is another representation of the same data
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+		cw.zw = zw
+	} else {
+		cw.identity = true
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.zw != nil {
+		_, err := cw.zw.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// close sends a response that stayed below the minimum size and finishes the
+// compressed stream
+func (cw *compressResponseWriter) close() {
+	if cw.zw == nil {
+		if !cw.identity && cw.status != 0 {
+			cw.start(false)
+		}
+		return
+	}
+	cw.zw.Close()
+	cw.compressor.put(cw.coding, cw.level, cw.zw)
+	cw.zw = nil
+}
+
+// compressible reports whether a media type is text that compresses well
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/x-ndjson", "application/javascript":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"exchange-rate-service/configs"
+
+	"github.com/gorilla/websocket"
+)
+
+func newTestCompressor(minSize int) *Compressor {
+	return NewCompressor(configs.CompressionConfig{Enabled: true, MinSize: minSize, Level: 5})
+}
+
+// serveCompressed runs handler behind the middleware and returns the
+// recorded response
+func serveCompressed(c *Compressor, acceptEncoding string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	c.Middleware(handler).ServeHTTP(rec, r)
+	return rec
+}
+
+func jsonBody(size int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `"`+strings.Repeat("a", size-2)+`"`)
+	}
+}
+
+func gunzip(t *testing.T, body io.Reader) string {
+	t.Helper()
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to decompress body: %v", err)
+	}
+	return string(data)
+}
+
+func TestCompressorMinSize(t *testing.T) {
+	c := newTestCompressor(100)
+
+	small := serveCompressed(c, "gzip", jsonBody(99))
+	if enc := small.Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("response below min_size encoded as %q", enc)
+	}
+	if small.Body.Len() != 99 {
+		t.Errorf("small body is %d bytes, want 99", small.Body.Len())
+	}
+
+	large := serveCompressed(c, "gzip", jsonBody(1000))
+	if enc := large.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", enc)
+	}
+	if got := gunzip(t, large.Body); len(got) != 1000 {
+		t.Errorf("decompressed body is %d bytes, want 1000", len(got))
+	}
+	if vary := large.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", vary)
+	}
+}
+
+func TestCompressorNegotiatesCoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		size   int
+		want   string
+	}{
+		{"none", "", 1000, ""},
+		{"gzip", "gzip", 1000, "gzip"},
+		{"deflate", "deflate", 1000, "deflate"},
+		{"higher q wins", "gzip;q=0.5, deflate;q=0.8", 1000, "deflate"},
+		{"listed order breaks ties", "deflate, gzip", 1000, "gzip"},
+		{"q=0 refuses", "gzip;q=0", 1000, ""},
+		{"wildcard", "*", 1000, "gzip"},
+		{"wildcard except gzip", "gzip;q=0, *", 1000, "deflate"},
+		{"unsupported", "br", 1000, ""},
+		{"identity refused compresses small bodies", "gzip, identity;q=0", 10, "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveCompressed(newTestCompressor(100), tt.accept, jsonBody(tt.size))
+			if enc := rec.Header().Get("Content-Encoding"); enc != tt.want {
+				t.Errorf("Content-Encoding = %q, want %q", enc, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressorRejectsRefusedIdentity(t *testing.T) {
+	for _, accept := range []string{"identity;q=0", "*;q=0", "br, identity;q=0", "gzip;q=0, deflate;q=0, identity;q=0"} {
+		rec := serveCompressed(newTestCompressor(100), accept, jsonBody(1000))
+		if rec.Code != http.StatusNotAcceptable {
+			t.Errorf("Accept-Encoding %q: status = %d, want 406", accept, rec.Code)
+		}
+	}
+	if rec := serveCompressed(newTestCompressor(100), "br, *;q=0, identity", jsonBody(1000)); rec.Code != http.StatusOK {
+		t.Errorf("identity accepted explicitly: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestCompressorPassesNotModifiedThrough(t *testing.T) {
+	rec := serveCompressed(newTestCompressor(0), "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusNotModified)
+	})
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", rec.Code)
+	}
+	if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("304 encoded as %q", enc)
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"v1"` {
+		t.Errorf("ETag = %q, want it unchanged", etag)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("304 has a %d byte body", rec.Body.Len())
+	}
+}
+
+func TestCompressorFlushesEventStreams(t *testing.T) {
+	next := make(chan struct{})
+	server := httptest.NewServer(newTestCompressor(1024).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{"first", "second"} {
+			io.WriteString(w, "data: "+event+"\n\n")
+			w.(http.Flusher).Flush()
+			<-next
+		}
+	})))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := (&http.Client{Transport: &http.Transport{DisableCompression: true}}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if enc := resp.Header.Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", enc)
+	}
+
+	// Each event must arrive while the handler is still waiting to send the
+	// next, well below min_size
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bufio.NewReader(zr)
+	for _, want := range []string{"data: first\n", "\n", "data: second\n", "\n"} {
+		if want == "data: second\n" {
+			next <- struct{}{}
+		}
+		line, err := lines.ReadString('\n')
+		if err != nil || line != want {
+			t.Fatalf("read %q (%v), want %q", line, err, want)
+		}
+	}
+	close(next)
+	if rest, err := io.ReadAll(lines); err != nil || len(rest) != 0 {
+		t.Errorf("stream ended with %q (%v)", rest, err)
+	}
+}
+
+func TestCompressorPassesWebSocketUpgradesThrough(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(newTestCompressor(0).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msgType, data, err := conn.ReadMessage()
+		if err == nil {
+			conn.WriteMessage(msgType, data)
+		}
+	})))
+	defer server.Close()
+
+	header := http.Header{"Accept-Encoding": {"gzip"}}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	defer conn.Close()
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("handshake encoded as %q", enc)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "ping" {
+		t.Errorf("echo = %q (%v), want ping", data, err)
+	}
+}
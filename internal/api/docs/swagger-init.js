@@ -0,0 +1,11 @@
+// Starts the vendored Swagger UI on /openapi.json. It lives in its own file
+// as the page's Content-Security-Policy allows no inline scripts.
+"use strict";
+
+if (typeof SwaggerUIBundle === "function") {
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+} else {
+    document.getElementById("swagger-ui").textContent =
+        "Swagger UI is not bundled in this build; run go generate ./internal/api to vendor it. " +
+        "The API description is served at /openapi.json.";
+}
@@ -10,36 +10,78 @@ import (
 	"exchange-rate-service/internal/errors"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
 )
 
 // Handlers handles HTTP requests
 type Handlers struct {
 	exchangeService service.ExchangeService
+	adminService    service.AdminService
+	alertService    service.AlertService
+	rateHub         service.RateHub
 	logger          log.Logger
 }
 
 // NewHandlers creates new HTTP handlers
-func NewHandlers(exchangeService service.ExchangeService, logger log.Logger) *Handlers {
+func NewHandlers(exchangeService service.ExchangeService, adminService service.AdminService, alertService service.AlertService, rateHub service.RateHub, logger log.Logger) *Handlers {
 	return &Handlers{
 		exchangeService: exchangeService,
+		adminService:    adminService,
+		alertService:    alertService,
+		rateHub:         rateHub,
 		logger:          logger,
 	}
 }
 
 // HealthCheck handles health check requests
 func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	h.logger.Log("method", "HealthCheck", "remote_addr", r.RemoteAddr)
+	level.Debug(h.logger).Log("method", "HealthCheck", "remote_addr", r.RemoteAddr)
 
 	ctx := r.Context()
 	health, err := h.exchangeService.HealthCheck(ctx)
 	if err != nil {
-		h.logger.Log("error", err, "method", "HealthCheck")
+		level.Error(h.logger).Log("error", err, "method", "HealthCheck")
 		models.WriteInternalError(w, "Health check failed")
 		return
 	}
 
-	models.WriteSuccess(w, health, "Service is healthy")
+	writeHealth(w, health, "Service is "+health.Status)
+}
+
+// Livez handles liveness probes. It never touches dependencies: a live
+// process that can't reach Redis or a provider should not be restarted.
+func (h *Handlers) Livez(w http.ResponseWriter, r *http.Request) {
+	models.WriteSuccess(w, map[string]string{"status": "alive"}, "Service is alive")
+}
+
+// Readyz handles readiness probes. The service is ready unless the aggregated
+// health is unhealthy; degraded replicas keep receiving traffic.
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	health, err := h.exchangeService.HealthCheck(ctx)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "Readyz")
+		models.WriteError(w, http.StatusServiceUnavailable, "Service Unavailable", "NOT_READY", "Readiness check failed")
+		return
+	}
+
+	if health.Status == models.HealthStatusUnhealthy {
+		writeHealth(w, health, "Service is not ready")
+		return
+	}
+	writeHealth(w, health, "Service is ready")
+}
+
+// writeHealth writes a health report, answering 503 when the service is unhealthy
+func writeHealth(w http.ResponseWriter, health *models.HealthResponse, message string) {
+	response := models.SuccessResponse(health, message)
+	statusCode := http.StatusOK
+	if health.Status == models.HealthStatusUnhealthy {
+		response.Success = false
+		statusCode = http.StatusServiceUnavailable
+	}
+	models.WriteJSON(w, statusCode, response)
 }
 
 // GetLatestRate handles latest rate requests
@@ -48,14 +90,14 @@ func (h *Handlers) GetLatestRate(w http.ResponseWriter, r *http.Request) {
 	baseCurrency := vars["base"]
 	targetCurrency := vars["target"]
 
-	h.logger.Log("method", "GetLatestRate", "base", baseCurrency, "target", targetCurrency, "remote_addr", r.RemoteAddr)
+	level.Debug(h.logger).Log("method", "GetLatestRate", "base", baseCurrency, "target", targetCurrency, "remote_addr", r.RemoteAddr)
 
 	ctx := r.Context()
 	rate, err := h.exchangeService.GetLatestRate(ctx, baseCurrency, targetCurrency)
 	if err != nil {
-		h.logger.Log("error", err, "method", "GetLatestRate")
+		level.Error(h.logger).Log("error", err, "method", "GetLatestRate")
 
-		if utils.IsValidationError(err) {
+		if errors.IsValidationError(err) {
 			models.WriteBadRequest(w, err.Error())
 			return
 		}
@@ -69,11 +111,11 @@ func (h *Handlers) GetLatestRate(w http.ResponseWriter, r *http.Request) {
 
 // ConvertCurrency handles currency conversion requests
 func (h *Handlers) ConvertCurrency(w http.ResponseWriter, r *http.Request) {
-	h.logger.Log("method", "ConvertCurrency", "remote_addr", r.RemoteAddr)
+	level.Debug(h.logger).Log("method", "ConvertCurrency", "remote_addr", r.RemoteAddr)
 
 	var req models.ConversionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Log("error", err, "method", "ConvertCurrency")
+		level.Error(h.logger).Log("error", err, "method", "ConvertCurrency")
 		models.WriteBadRequest(w, "Invalid request body")
 		return
 	}
@@ -81,9 +123,9 @@ func (h *Handlers) ConvertCurrency(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	response, err := h.exchangeService.ConvertCurrency(ctx, &req)
 	if err != nil {
-		h.logger.Log("error", err, "method", "ConvertCurrency")
+		level.Error(h.logger).Log("error", err, "method", "ConvertCurrency")
 
-		if utils.IsValidationError(err) {
+		if errors.IsValidationError(err) {
 			models.WriteBadRequest(w, err.Error())
 			return
 		}
@@ -102,25 +144,29 @@ func (h *Handlers) GetHistoricalRate(w http.ResponseWriter, r *http.Request) {
 	targetCurrency := vars["target"]
 	dateStr := vars["date"]
 
-	h.logger.Log("method", "GetHistoricalRate", "base", baseCurrency, "target", targetCurrency, "date", dateStr, "remote_addr", r.RemoteAddr)
+	level.Debug(h.logger).Log("method", "GetHistoricalRate", "base", baseCurrency, "target", targetCurrency, "date", dateStr, "remote_addr", r.RemoteAddr)
 
 	// Parse date
 	date, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
-		h.logger.Log("error", err, "method", "GetHistoricalRate")
+		level.Error(h.logger).Log("error", err, "method", "GetHistoricalRate")
 		models.WriteBadRequest(w, "Invalid date format. Use YYYY-MM-DD")
 		return
 	}
 
 	ctx := r.Context()
-	rate, err := h.exchangeService.GetHistoricalRate(ctx, baseCurrency, targetCurrency, date)
+	rate, err := h.exchangeService.GetHistoricalRate(ctx, baseCurrency, targetCurrency, date, fillOptions(r))
 	if err != nil {
-		h.logger.Log("error", err, "method", "GetHistoricalRate")
+		level.Error(h.logger).Log("error", err, "method", "GetHistoricalRate")
 
-		if utils.IsValidationError(err) {
+		if errors.IsValidationError(err) {
 			models.WriteBadRequest(w, err.Error())
 			return
 		}
+		if errors.IsNotFoundError(err) {
+			models.WriteNotFound(w, err.Error())
+			return
+		}
 
 		models.WriteInternalError(w, "Failed to get historical rate")
 		return
@@ -129,14 +175,20 @@ func (h *Handlers) GetHistoricalRate(w http.ResponseWriter, r *http.Request) {
 	models.WriteSuccess(w, rate, "Historical rate retrieved successfully")
 }
 
+// fillOptions reads the fill policy and calendar query parameters
+func fillOptions(r *http.Request) models.FillOptions {
+	q := r.URL.Query()
+	return models.FillOptions{Policy: q.Get("fill"), Calendar: q.Get("calendar")}
+}
+
 // GetSupportedCurrencies handles supported currencies requests
 func (h *Handlers) GetSupportedCurrencies(w http.ResponseWriter, r *http.Request) {
-	h.logger.Log("method", "GetSupportedCurrencies", "remote_addr", r.RemoteAddr)
+	level.Debug(h.logger).Log("method", "GetSupportedCurrencies", "remote_addr", r.RemoteAddr)
 
 	ctx := r.Context()
 	currencies, err := h.exchangeService.GetSupportedCurrencies(ctx)
 	if err != nil {
-		h.logger.Log("error", err, "method", "GetSupportedCurrencies")
+		level.Error(h.logger).Log("error", err, "method", "GetSupportedCurrencies")
 		models.WriteInternalError(w, "Failed to get supported currencies")
 		return
 	}
@@ -146,7 +198,7 @@ func (h *Handlers) GetSupportedCurrencies(w http.ResponseWriter, r *http.Request
 
 // GetRates handles bulk rates requests
 func (h *Handlers) GetRates(w http.ResponseWriter, r *http.Request) {
-	h.logger.Log("method", "GetRates", "remote_addr", r.RemoteAddr)
+	level.Debug(h.logger).Log("method", "GetRates", "remote_addr", r.RemoteAddr)
 
 	// Parse query parameters
 	baseCurrency := r.URL.Query().Get("base")
@@ -158,7 +210,7 @@ func (h *Handlers) GetRates(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	currencies, err := h.exchangeService.GetSupportedCurrencies(ctx)
 	if err != nil {
-		h.logger.Log("error", err, "method", "GetRates")
+		level.Error(h.logger).Log("error", err, "method", "GetRates")
 		models.WriteInternalError(w, "Failed to get supported currencies")
 		return
 	}
@@ -172,80 +224,18 @@ func (h *Handlers) GetRates(w http.ResponseWriter, r *http.Request) {
 
 		rate, err := h.exchangeService.GetLatestRate(ctx, baseCurrency, currency.Code)
 		if err != nil {
-			h.logger.Log("error", err, "method", "GetRates", "base", baseCurrency, "target", currency.Code)
+			level.Error(h.logger).Log("error", err, "method", "GetRates", "base", baseCurrency, "target", currency.Code)
 			continue // Skip failed rates, continue with others
 		}
 
 		rates = append(rates, rate)
 	}
 
-	response := map[string]interface{}{
-		"base_currency": baseCurrency,
-		"rates":         rates,
-		"count":         len(rates),
+	response := &models.RateList{
+		BaseCurrency: baseCurrency,
+		Rates:        rates,
+		Count:        len(rates),
 	}
 
 	models.WriteSuccess(w, response, "Rates retrieved successfully")
 }
-
-// GetTimeSeries handles time series requests
-func (h *Handlers) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	baseCurrency := vars["base"]
-	targetCurrency := vars["target"]
-
-	// Parse query parameters
-	startDateStr := r.URL.Query().Get("start_date")
-	endDateStr := r.URL.Query().Get("end_date")
-
-	if startDateStr == "" || endDateStr == "" {
-		models.WriteBadRequest(w, "start_date and end_date are required")
-		return
-	}
-
-	startDate, err := time.Parse("2006-01-02", startDateStr)
-	if err != nil {
-		models.WriteBadRequest(w, "Invalid start_date format. Use YYYY-MM-DD")
-		return
-	}
-
-	endDate, err := time.Parse("2006-01-02", endDateStr)
-	if err != nil {
-		models.WriteBadRequest(w, "Invalid end_date format. Use YYYY-MM-DD")
-		return
-	}
-
-	if startDate.After(endDate) {
-		models.WriteBadRequest(w, "start_date must be before end_date")
-		return
-	}
-
-	h.logger.Log("method", "GetTimeSeries", "base", baseCurrency, "target", targetCurrency, "start", startDateStr, "end", endDateStr, "remote_addr", r.RemoteAddr)
-
-	// Get rates for each date in the range
-	ctx := r.Context()
-	var rates []*models.HistoricalRate
-	currentDate := startDate
-	for !currentDate.After(endDate) {
-		rate, err := h.exchangeService.GetHistoricalRate(ctx, baseCurrency, targetCurrency, currentDate)
-		if err != nil {
-			h.logger.Log("error", err, "method", "GetTimeSeries", "date", currentDate.Format("2006-01-02"))
-			// Continue with other dates
-		} else {
-			rates = append(rates, rate)
-		}
-
-		currentDate = currentDate.AddDate(0, 0, 1)
-	}
-
-	response := map[string]interface{}{
-		"base_currency":   baseCurrency,
-		"target_currency": targetCurrency,
-		"start_date":      startDateStr,
-		"end_date":        endDateStr,
-		"rates":           rates,
-		"count":           len(rates),
-	}
-
-	models.WriteSuccess(w, response, "Time series retrieved successfully")
-}
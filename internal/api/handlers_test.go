@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/service"
+
+	"github.com/go-kit/log"
+)
+
+// healthService reports a fixed health status
+type healthService struct {
+	service.ExchangeService
+	status string
+	err    error
+}
+
+func (s *healthService) HealthCheck(ctx context.Context) (*models.HealthResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.HealthResponse{Status: s.status}, nil
+}
+
+func TestProbes(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   *healthService
+		readyCode int
+	}{
+		{"healthy", &healthService{status: models.HealthStatusHealthy}, http.StatusOK},
+		{"degraded", &healthService{status: models.HealthStatusDegraded}, http.StatusOK},
+		{"unhealthy", &healthService{status: models.HealthStatusUnhealthy}, http.StatusServiceUnavailable},
+		{"health check failing", &healthService{err: errors.New("redis down")}, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers := NewHandlers(tt.service, nil, nil, nil, log.NewNopLogger())
+
+			rec := httptest.NewRecorder()
+			handlers.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.readyCode {
+				t.Errorf("GET /readyz = %d, want %d", rec.Code, tt.readyCode)
+			}
+			var response models.APIResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decoding /readyz: %v", err)
+			}
+			if response.Success != (tt.readyCode == http.StatusOK) {
+				t.Errorf("/readyz success = %v with status %d", response.Success, rec.Code)
+			}
+
+			// Liveness never depends on the health of dependencies
+			rec = httptest.NewRecorder()
+			handlers.Livez(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("GET /livez = %d, want 200", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/service"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+)
+
+// maxImportBytes caps the size of an uploaded history file
+const maxImportBytes = 64 << 20
+
+// exportFlushRows is how many exported rows are buffered before they are
+// flushed to the client
+const exportFlushRows = 500
+
+// ExportHistory handles requests to download the stored history of a base
+// currency as CSV or NDJSON. Rows are streamed as they are read from the
+// history store; if reading fails part way the response is cut short, since
+// the status has already been sent.
+func (h *Handlers) ExportHistory(w http.ResponseWriter, r *http.Request) {
+	baseCurrency := strings.ToUpper(mux.Vars(r)["base"])
+	q := r.URL.Query()
+
+	level.Debug(h.logger).Log("method", "ExportHistory", "base", baseCurrency, "targets", q.Get("targets"), "remote_addr", r.RemoteAddr)
+
+	format := strings.ToLower(q.Get("format"))
+	if format == "" {
+		format = service.FormatCSV
+	}
+	if format != service.FormatCSV && format != service.FormatNDJSON {
+		models.WriteBadRequest(w, "format must be csv or ndjson")
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", q.Get("start_date"))
+	if err != nil {
+		models.WriteBadRequest(w, "Invalid start_date format. Use YYYY-MM-DD")
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", q.Get("end_date"))
+	if err != nil {
+		models.WriteBadRequest(w, "Invalid end_date format. Use YYYY-MM-DD")
+		return
+	}
+
+	var targets []string
+	for _, target := range strings.Split(q.Get("targets"), ",") {
+		if target = strings.ToUpper(strings.TrimSpace(target)); target != "" {
+			targets = append(targets, target)
+		}
+	}
+
+	var writer service.HistoryWriter
+	rows := 0
+	start := func() (err error) {
+		w.Header().Set("Content-Type", service.HistoryContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="history-%s-%s-%s.%s"`,
+			baseCurrency, startDate.Format("20060102"), endDate.Format("20060102"), format))
+		w.WriteHeader(http.StatusOK)
+		writer, err = service.NewHistoryWriter(w, format)
+		return err
+	}
+	flusher, _ := w.(http.Flusher)
+
+	ctx := r.Context()
+	err = h.exchangeService.ExportHistory(ctx, baseCurrency, targets, startDate, endDate, func(rate *models.HistoricalRate) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(rate); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "ExportHistory", "rows", rows)
+		if writer != nil {
+			return
+		}
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to export history")
+		return
+	}
+
+	// An empty export still gets its header row
+	if writer == nil {
+		if err := start(); err != nil {
+			level.Error(h.logger).Log("error", err, "method", "ExportHistory")
+			return
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		level.Error(h.logger).Log("error", err, "method", "ExportHistory")
+	}
+}
+
+// ImportHistory handles uploads of historical rates into the history store.
+// The body is a CSV, JSON or NDJSON file, chosen by ?format= or else by the
+// Content-Type; ?on_conflict= sets the conflict policy.
+func (h *Handlers) ImportHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := strings.ToLower(q.Get("format"))
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+
+	level.Debug(h.logger).Log("method", "ImportHistory", "format", format, "on_conflict", q.Get("on_conflict"), "remote_addr", r.RemoteAddr)
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	ctx := r.Context()
+	result, err := h.adminService.ImportHistory(ctx, body, format, q.Get("on_conflict"))
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "method", "ImportHistory")
+
+		if errors.IsValidationError(err) {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+		if errors.IsConflictError(err) {
+			models.WriteConflict(w, err.Error())
+			return
+		}
+
+		models.WriteInternalError(w, "Failed to import history")
+		return
+	}
+
+	models.WriteSuccess(w, result, "History imported successfully")
+}
+
+// importFormat maps an upload's Content-Type to a history file format,
+// defaulting to CSV
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		return service.FormatJSON
+	case "application/x-ndjson", "application/jsonl":
+		return service.FormatNDJSON
+	}
+	return service.FormatCSV
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+)
+
+// HTTPCache adds caching headers and conditional request handling to
+// responses, with lifetimes taken from the cache TTLs. The TTLs can be changed
+// while it is serving, e.g. on a configuration reload.
+type HTTPCache struct {
+	mu  sync.RWMutex
+	cfg configs.CacheConfig
+}
+
+// NewHTTPCache creates the HTTP caching middleware from the cache config
+func NewHTTPCache(cfg configs.CacheConfig) *HTTPCache {
+	return &HTTPCache{cfg: cfg}
+}
+
+// Update applies new cache TTLs
+func (c *HTTPCache) Update(cfg configs.CacheConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg = cfg
+}
+
+func (c *HTTPCache) config() configs.CacheConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// Latest caches latest rates until their server-side cache entry expires
+func (c *HTTPCache) Latest(next http.Handler) http.Handler {
+	return c.middleware(next, func(cfg configs.CacheConfig) models.CachePolicy {
+		return models.CachePolicy{MaxAge: cfg.LatestTTL, FromFetch: true}
+	})
+}
+
+// Historical caches historical rates, which don't change once stored
+func (c *HTTPCache) Historical(next http.Handler) http.Handler {
+	return c.middleware(next, func(cfg configs.CacheConfig) models.CachePolicy {
+		return models.CachePolicy{MaxAge: cfg.HistoricalTTL}
+	})
+}
+
+// Currencies caches the list of supported currencies
+func (c *HTTPCache) Currencies(next http.Handler) http.Handler {
+	return c.middleware(next, func(cfg configs.CacheConfig) models.CachePolicy {
+		return models.CachePolicy{MaxAge: cfg.CurrenciesTTL}
+	})
+}
+
+func (c *HTTPCache) middleware(next http.Handler, policy func(configs.CacheConfig) models.CachePolicy) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(models.WithCaching(w, r, policy(c.config())), r)
+	})
+}
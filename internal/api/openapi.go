@@ -0,0 +1,455 @@
+package api
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// The OpenAPI document is generated from the routes registered on the router,
+// described by routeDocs, and from the request and response types, whose
+// schemas are derived from their JSON encoding. Routes without a description
+// are left out of the document, which the tests catch.
+
+type openAPIDocument struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       openAPIInfo                      `json:"info"`
+	Tags       []openAPITag                     `json:"tags,omitempty"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components openAPIComponents                `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*schema         `json:"schemas"`
+	Responses       map[string]*response       `json:"responses"`
+	SecuritySchemes map[string]*securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	Name   string `json:"name,omitempty"`
+	In     string `json:"in,omitempty"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*schema          `json:"allOf,omitempty"`
+}
+
+// openAPITypes are the models types that interface fields can name in their
+// openapi tag
+var openAPITypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		models.ExchangeRate{}, models.ConversionResponse{}, models.HistoricalRate{},
+		models.RateBucket{}, models.TimeSeriesStats{}, models.Currency{},
+	} {
+		t := reflect.TypeOf(v)
+		openAPITypes[t.Name()] = t
+	}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaGenerator derives schemas from Go types. Named structs become
+// components referenced by name; anonymous structs are inlined.
+type schemaGenerator struct {
+	schemas map[string]*schema
+}
+
+func (g *schemaGenerator) schemaOf(v interface{}) *schema {
+	return g.schemaOfType(reflect.TypeOf(v), "")
+}
+
+// schemaOfType returns the schema of t. hint is the openapi tag of the field
+// holding t, naming the type of an interface value.
+func (g *schemaGenerator) schemaOfType(t reflect.Type, hint string) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: g.schemaOfType(t.Elem(), hint)}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.schemaOfType(t.Elem(), hint)}
+	case reflect.Interface:
+		if name, isList := strings.CutPrefix(hint, "[]"); isList {
+			return &schema{Type: "array", Items: g.schemaOfType(openAPITypes[name], "")}
+		}
+		if named, ok := openAPITypes[hint]; ok {
+			return g.schemaOfType(named, "")
+		}
+		return &schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.objectOf(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// Registered before the fields so recursive types terminate
+			g.schemas[t.Name()] = &schema{}
+			*g.schemas[t.Name()] = *g.objectOf(t)
+		}
+		return &schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	return &schema{}
+}
+
+// objectOf describes the JSON object of a struct: its exported fields by JSON
+// name, with fields not marked omitempty required. Embedded structs without a
+// JSON name are flattened as encoding/json does.
+func (g *schemaGenerator) objectOf(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := g.objectOf(field.Type)
+			for prop, ps := range embedded.Properties {
+				s.Properties[prop] = ps
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = g.schemaOfType(field.Type, field.Tag.Get("openapi"))
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// errorResponses describes the error statuses of the API and the codes sent
+// with each in ErrorResponse.Code
+var errorResponses = []struct {
+	status      int
+	description string
+	codes       []string
+}{
+	{http.StatusBadRequest, "The request is invalid", []string{"BAD_REQUEST"}},
+	{http.StatusUnauthorized, "The admin token is missing or wrong", []string{"UNAUTHORIZED"}},
+	{http.StatusForbidden, "The admin API is disabled because no admin token is configured", []string{"FORBIDDEN"}},
+	{http.StatusNotFound, "The resource or rate does not exist", []string{"NOT_FOUND"}},
+	{http.StatusNotAcceptable, "None of the formats in Accept or ?format=, or of the codings in Accept-Encoding, is supported", []string{"NOT_ACCEPTABLE"}},
+	{http.StatusConflict, "Imported rates conflict with stored ones", []string{"CONFLICT"}},
+	{http.StatusTooManyRequests, "The request rate limit was exceeded; retry after the Retry-After seconds", []string{"RATE_LIMITED"}},
+	{http.StatusInternalServerError, "The request failed on the server or at a provider", []string{"INTERNAL_ERROR", "CACHE_ERROR", "PROVIDER_ERROR"}},
+	{http.StatusServiceUnavailable, "The service is not ready", []string{"NOT_READY"}},
+}
+
+func errorResponseName(status int) string {
+	return "Error" + strconv.Itoa(status)
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// newOpenAPI describes the routes registered on router
+func newOpenAPI(router *mux.Router) (*openAPIDocument, error) {
+	g := &schemaGenerator{schemas: map[string]*schema{}}
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title: "Exchange Rate Service API",
+			Description: "Latest and historical exchange rates aggregated from several providers. " +
+				"JSON responses are wrapped in an envelope with success, data, message and timestamp; " +
+				"errors carry an error, a machine-readable code and details.",
+			Version: "1.0.0",
+		},
+		Tags:  openAPITags,
+		Paths: map[string]map[string]*operation{},
+		Components: openAPIComponents{
+			Schemas:   g.schemas,
+			Responses: map[string]*response{},
+			SecuritySchemes: map[string]*securityScheme{
+				"adminBearer": {Type: "http", Scheme: "bearer"},
+				"adminToken":  {Type: "apiKey", Name: "X-Admin-Token", In: "header"},
+			},
+		},
+	}
+
+	errorSchema := g.schemaOf(models.ErrorResponse{})
+	var codes []string
+	for _, e := range errorResponses {
+		codes = append(codes, e.codes...)
+		doc.Components.Responses[errorResponseName(e.status)] = &response{
+			Description: e.description + " (" + strings.Join(e.codes, ", ") + ")",
+			Content:     map[string]*mediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+	g.schemas["ErrorResponse"].Properties["code"].Enum = codes
+	envelope := g.schemaOf(models.APIResponse{})
+	// Messages of the streaming routes, which have no JSON response body
+	for _, v := range []interface{}{models.RateUpdate{}, models.StreamRequest{}, models.StreamMessage{}} {
+		g.schemaOf(v)
+	}
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			rd, ok := routeDocs[method+" "+path]
+			if !ok {
+				continue
+			}
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = map[string]*operation{}
+			}
+			doc.Paths[path][strings.ToLower(method)] = rd.operation(g, envelope, path)
+		}
+		return nil
+	})
+	return doc, err
+}
+
+// operation describes the route at path
+func (rd routeDoc) operation(g *schemaGenerator, envelope *schema, path string) *operation {
+	op := &operation{
+		Summary:     rd.Summary,
+		Description: rd.Description,
+		OperationID: rd.ID,
+		Tags:        []string{rd.Tag},
+		Responses:   map[string]*response{},
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, &parameter{
+			Name: match[1], In: "path", Required: true,
+			Description: pathParamDocs[match[1]], Schema: &schema{Type: "string"},
+		})
+	}
+	for _, p := range rd.Query {
+		op.Parameters = append(op.Parameters, &parameter{
+			Name: p.Name, In: "query", Required: p.Required,
+			Description: p.Description, Schema: &schema{Type: "string", Enum: p.Enum},
+		})
+	}
+	for _, p := range rd.Headers {
+		op.Parameters = append(op.Parameters, &parameter{
+			Name: p.Name, In: "header", Required: p.Required,
+			Description: p.Description, Schema: &schema{Type: "string", Enum: p.Enum},
+		})
+	}
+
+	if rd.Body != nil {
+		op.RequestBody = &requestBody{Required: true, Content: map[string]*mediaType{
+			"application/json": {Schema: g.schemaOf(rd.Body)},
+		}}
+	}
+	if len(rd.BodyTypes) > 0 {
+		op.RequestBody = &requestBody{Required: true, Content: map[string]*mediaType{}}
+		for _, ct := range rd.BodyTypes {
+			op.RequestBody.Content[ct] = &mediaType{Schema: &schema{Type: "string"}}
+		}
+	}
+
+	status := rd.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &response{Description: rd.ResponseDescription, Content: map[string]*mediaType{}}
+	if success.Description == "" {
+		success.Description = http.StatusText(status)
+	}
+	switch {
+	case rd.Response != nil && rd.Unwrapped:
+		success.Content["application/json"] = &mediaType{Schema: g.schemaOf(rd.Response)}
+	case rd.Response != nil:
+		success.Content["application/json"] = &mediaType{Schema: &schema{AllOf: []*schema{
+			envelope,
+			{Type: "object", Properties: map[string]*schema{"data": g.schemaOf(rd.Response)}},
+		}}}
+	}
+	if rd.Negotiated {
+		success.Content["text/csv"] = &mediaType{Schema: &schema{Type: "string"}}
+		success.Content["application/xml"] = &mediaType{Schema: &schema{Type: "string"}}
+	}
+	for _, ct := range rd.Produces {
+		success.Content[ct] = &mediaType{Schema: &schema{Type: "string"}}
+	}
+	if len(success.Content) == 0 {
+		success.Content = nil
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	errs := rd.Errors
+	if strings.HasPrefix(path, "/api/v1/") {
+		errs = append(errs, http.StatusTooManyRequests)
+	}
+	if rd.Negotiated {
+		errs = append(errs, http.StatusNotAcceptable)
+	}
+	if rd.Admin {
+		errs = append(errs, http.StatusUnauthorized, http.StatusForbidden)
+		op.Security = []map[string][]string{{"adminBearer": {}}, {"adminToken": {}}}
+	}
+	for _, status := range errs {
+		op.Responses[strconv.Itoa(status)] = &response{Ref: "#/components/responses/" + errorResponseName(status)}
+	}
+	return op
+}
+
+// openAPIHandler serves the OpenAPI document of router, generated on first use
+// so it covers every route registered by then
+func openAPIHandler(router *mux.Router) http.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var doc *openAPIDocument
+			if doc, err = newOpenAPI(router); err == nil {
+				body, err = json.MarshalIndent(doc, "", "  ")
+			}
+		})
+		if err != nil {
+			models.WriteInternalError(w, "Failed to generate the OpenAPI document")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// Swagger UI is vendored into docs from a pinned swagger-ui-dist release, so
+// the docs page loads no third-party scripts. npm pack checks the package
+// against the registry's integrity hash.
+//
+//go:generate sh -c "cd docs && npm pack --silent swagger-ui-dist@5.17.14 && tar -xzf swagger-ui-dist-5.17.14.tgz --strip-components=1 package/swagger-ui-bundle.js package/swagger-ui.css package/LICENSE && rm swagger-ui-dist-5.17.14.tgz"
+
+// docsFiles is the Swagger UI page served at /docs
+//
+//go:embed docs
+var docsFiles embed.FS
+
+// docsPolicy keeps the docs page to its own scripts and API. Swagger UI sets
+// inline styles and uses data: images for its icons.
+const docsPolicy = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	serveDocsFile(w, r, "index.html")
+}
+
+// docsAssetHandler serves the scripts and styles of the docs page
+func docsAssetHandler(w http.ResponseWriter, r *http.Request) {
+	serveDocsFile(w, r, mux.Vars(r)["file"])
+}
+
+func serveDocsFile(w http.ResponseWriter, r *http.Request, name string) {
+	body, err := docsFiles.ReadFile("docs/" + name)
+	if err != nil {
+		models.WriteNotFound(w, "No such documentation file")
+		return
+	}
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		if contentType := mime.TypeByExtension(name[i:]); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+	}
+	w.Header().Set("Content-Security-Policy", docsPolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(body))
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"net/http"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/transport"
+)
+
+// routeDoc describes a route for the OpenAPI document. Body and Response are
+// values of the request and response types. Responses are wrapped in the
+// APIResponse envelope unless Unwrapped, as the go-kit endpoints answer with
+// their DTO; those report failures in its error field with a 200 status.
+type routeDoc struct {
+	ID          string
+	Summary     string
+	Description string
+	Tag         string
+
+	Query     []paramDoc
+	Headers   []paramDoc
+	Body      interface{}
+	BodyTypes []string
+
+	Status              int
+	Response            interface{}
+	ResponseDescription string
+	Unwrapped           bool
+	// Negotiated responses can also be requested as CSV or XML
+	Negotiated bool
+	Produces   []string
+	Errors     []int
+	Admin      bool
+}
+
+type paramDoc struct {
+	Name        string
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+var openAPITags = []openAPITag{
+	{Name: "rates", Description: "Latest and historical exchange rates"},
+	{Name: "history", Description: "Stored daily rates and the figures computed from them"},
+	{Name: "streaming", Description: "Live rate updates"},
+	{Name: "alerts", Description: "Webhooks fired when rates cross thresholds"},
+	{Name: "admin", Description: "Rate overrides, cache maintenance and history imports"},
+	{Name: "health", Description: "Health checks and metrics"},
+	{Name: "docs", Description: "API documentation"},
+}
+
+var pathParamDocs = map[string]string{
+	"base":   "Base currency code, e.g. USD",
+	"target": "Target currency code, e.g. EUR",
+	"date":   "Date as YYYY-MM-DD",
+	"id":     "Alert ID",
+	"key":    "Cache key",
+	"file":   "File name, e.g. docs.js",
+}
+
+// endpointResponse describes the responses of the go-kit endpoints
+const endpointResponse = "OK; failures are reported in the error field with this status too"
+
+var (
+	fillParams = []paramDoc{
+		{Name: "fill", Description: "How dates that are not business days are answered", Enum: []string{models.FillNone, models.FillPrevious, models.FillNext, models.FillLinear}},
+		{Name: "calendar", Description: "Business-day calendar: weekends, TARGET, US or UK"},
+	}
+	formatParam = paramDoc{Name: "format", Description: "Response format; takes precedence over the Accept header", Enum: []string{models.FormatJSON, models.FormatCSV, models.FormatXML}}
+	periodParam = paramDoc{Name: "period", Description: "Month (2024-03), quarter (2024-Q1) or year (2024)", Required: true}
+)
+
+// routeDocs describes every route, keyed by method and path template
+var routeDocs = map[string]routeDoc{
+	"GET /health": {
+		ID: "getHealth", Tag: "health", Summary: "Aggregated service health",
+		Description: "Reports the state of the cache and every provider. Answers 503 with the same body when the service is unhealthy.",
+		Response:    models.HealthResponse{},
+		Errors:      []int{http.StatusInternalServerError},
+	},
+	"GET /livez": {
+		ID: "getLiveness", Tag: "health", Summary: "Liveness probe",
+		Description: "Never checks dependencies.",
+		Response:    map[string]string{},
+	},
+	"GET /readyz": {
+		ID: "getReadiness", Tag: "health", Summary: "Readiness probe",
+		Description: "Ready unless the aggregated health is unhealthy; answers 503 with the health report otherwise.",
+		Response:    models.HealthResponse{},
+		Errors:      []int{http.StatusServiceUnavailable},
+	},
+	"GET /debug/vars": {
+		ID: "getMetrics", Tag: "health", Summary: "Metrics",
+		Description: "Runtime and service metrics published with expvar.",
+		Response:    map[string]interface{}{}, Unwrapped: true,
+	},
+
+	"GET /api/v1/currencies": {
+		ID: "listCurrencies", Tag: "rates", Summary: "List supported currencies",
+		Query:    []paramDoc{formatParam},
+		Response: transport.GetSupportedCurrenciesResponse{}, Unwrapped: true, Negotiated: true,
+		ResponseDescription: endpointResponse,
+	},
+	"GET /api/v1/rates": {
+		ID: "listRates", Tag: "rates", Summary: "Latest rates of all currencies against a base",
+		Query:    []paramDoc{{Name: "base", Description: "Base currency code (default USD)"}, formatParam},
+		Response: models.RateList{}, Negotiated: true,
+		Errors: []int{http.StatusInternalServerError},
+	},
+	"GET /api/v1/rates/{base}/{target}": {
+		ID: "getLatestRate", Tag: "rates", Summary: "Latest rate of a currency pair",
+		Query:    []paramDoc{formatParam},
+		Response: transport.GetLatestRateResponse{}, Unwrapped: true, Negotiated: true,
+		ResponseDescription: endpointResponse,
+	},
+	"GET /api/v1/rates/{base}/{target}/{date}": {
+		ID: "getHistoricalRate", Tag: "rates", Summary: "Rate of a currency pair on a date",
+		Query:    append(fillParams, formatParam),
+		Response: models.HistoricalRate{}, Negotiated: true,
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"POST /api/v1/convert": {
+		ID: "convertCurrency", Tag: "rates", Summary: "Convert an amount between currencies",
+		Description: "Uses the latest rate, or the rate of date when given.",
+		Body:        transport.ConvertCurrencyRequest{},
+		Response:    transport.ConvertCurrencyResponse{}, Unwrapped: true,
+		ResponseDescription: endpointResponse,
+	},
+	"GET /api/v1/timeseries/{base}/{target}": {
+		ID: "getTimeSeries", Tag: "rates", Summary: "Rates of a currency pair over a date range",
+		Description: "Daily rates, or OHLC buckets for coarser granularities. Dates whose rate is unavailable are listed in missing.",
+		Query: append([]paramDoc{
+			{Name: "start_date", Description: "First date as YYYY-MM-DD", Required: true},
+			{Name: "end_date", Description: "Last date as YYYY-MM-DD", Required: true},
+			{Name: "stats", Description: "true adds statistics, only returns just them", Enum: []string{models.StatsInclude, models.StatsOnly}},
+			{Name: "granularity", Enum: []string{models.GranularityDay, models.GranularityWeek, models.GranularityMonth, models.GranularityQuarter, models.GranularityYear}},
+		}, append(fillParams, formatParam)...),
+		Response: transport.GetHistoricalRatesResponse{}, Unwrapped: true, Negotiated: true,
+		ResponseDescription: endpointResponse,
+	},
+
+	"GET /api/v1/averages/{base}/{target}": {
+		ID: "getPeriodAverage", Tag: "history", Summary: "Average and closing rates of a pair over a period",
+		Query:    []paramDoc{periodParam, fillParams[1]},
+		Response: models.PeriodAverage{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"GET /api/v1/reports/averages/{base}": {
+		ID: "getPeriodReport", Tag: "history", Summary: "Period averages of every currency against a base",
+		Query:    []paramDoc{periodParam, fillParams[1]},
+		Response: models.PeriodReport{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"GET /api/v1/history/{base}/export": {
+		ID: "exportHistory", Tag: "history", Summary: "Download stored history",
+		Description: "Streams the stored daily rates as CSV, which can be imported again, or NDJSON.",
+		Query: []paramDoc{
+			{Name: "start_date", Description: "First date as YYYY-MM-DD", Required: true},
+			{Name: "end_date", Description: "Last date as YYYY-MM-DD", Required: true},
+			{Name: "targets", Description: "Comma-separated target currencies (default all stored)"},
+			{Name: "format", Enum: []string{"csv", "ndjson"}},
+		},
+		Produces: []string{"text/csv", "application/x-ndjson"},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+
+	"GET /api/v1/stream/rates": {
+		ID: "streamRates", Tag: "streaming", Summary: "Stream rate changes as Server-Sent Events",
+		Description: "Each rates event carries a RateUpdate; the first one per base is a snapshot.",
+		Query: []paramDoc{
+			{Name: "base", Description: "Comma-separated base currencies to receive every rate of"},
+			{Name: "pairs", Description: "Comma-separated pairs such as USD/EUR"},
+			{Name: "last_event_id", Description: "Resume after this event, like the Last-Event-ID header"},
+		},
+		Headers:  []paramDoc{{Name: "Last-Event-ID", Description: "Resume after this event"}},
+		Produces: []string{"text/event-stream"},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"GET /api/v1/ws/rates": {
+		ID: "ratesWebSocket", Tag: "streaming", Summary: "WebSocket for rate subscriptions and conversions",
+		Description: "Clients send StreamRequest messages and receive StreamMessage messages.",
+		Status:      http.StatusSwitchingProtocols,
+		Errors:      []int{http.StatusBadRequest},
+	},
+
+	"GET /api/v1/alerts": {
+		ID: "listAlerts", Tag: "alerts", Summary: "List rate alerts", Admin: true,
+		Response: struct {
+			Alerts []*models.RateAlert `json:"alerts"`
+			Count  int                 `json:"count"`
+		}{},
+		Errors: []int{http.StatusInternalServerError},
+	},
+	"POST /api/v1/alerts": {
+		ID: "createAlert", Tag: "alerts", Summary: "Register a rate alert", Admin: true,
+		Description: "The response is the only one carrying the webhook secret.",
+		Body:        models.AlertRequest{},
+		Status:      http.StatusCreated, Response: models.RateAlert{},
+		Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"GET /api/v1/alerts/{id}": {
+		ID: "getAlert", Tag: "alerts", Summary: "Get a rate alert", Admin: true,
+		Response: models.RateAlert{},
+		Errors:   []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"DELETE /api/v1/alerts/{id}": {
+		ID: "deleteAlert", Tag: "alerts", Summary: "Remove a rate alert", Admin: true,
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"GET /api/v1/alerts/{id}/deliveries": {
+		ID: "listAlertDeliveries", Tag: "alerts", Summary: "Recent webhook deliveries of an alert", Admin: true,
+		Response: struct {
+			Deliveries []*models.AlertDelivery `json:"deliveries"`
+			Count      int                     `json:"count"`
+		}{},
+		Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+
+	"GET /api/v1/admin/overrides": {
+		ID: "listOverrides", Tag: "admin", Summary: "List rate overrides", Admin: true,
+		Response: struct {
+			Overrides []*models.RateOverride `json:"overrides"`
+			Count     int                    `json:"count"`
+		}{},
+		Errors: []int{http.StatusInternalServerError},
+	},
+	"PUT /api/v1/admin/overrides/{base}/{target}": {
+		ID: "setOverride", Tag: "admin", Summary: "Pin the rate of a currency pair", Admin: true,
+		Headers:  []paramDoc{{Name: "X-Admin-User", Description: "Recorded as the author when the body has none"}},
+		Body:     models.OverrideRequest{},
+		Response: models.RateOverride{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"DELETE /api/v1/admin/overrides/{base}/{target}": {
+		ID: "deleteOverride", Tag: "admin", Summary: "Remove the override of a currency pair", Admin: true,
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"GET /api/v1/admin/cache/keys": {
+		ID: "listCacheKeys", Tag: "admin", Summary: "List cache keys", Admin: true,
+		Query: []paramDoc{{Name: "pattern", Description: "Glob pattern (default *)"}},
+		Response: struct {
+			Pattern string   `json:"pattern"`
+			Keys    []string `json:"keys"`
+			Count   int      `json:"count"`
+		}{},
+		Errors: []int{http.StatusInternalServerError},
+	},
+	"GET /api/v1/admin/cache/entries/{key}": {
+		ID: "getCacheEntry", Tag: "admin", Summary: "View a cache entry and its remaining TTL", Admin: true,
+		Response: models.CacheEntry{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"DELETE /api/v1/admin/cache": {
+		ID: "invalidateCache", Tag: "admin", Summary: "Drop cached rates", Admin: true,
+		Query: []paramDoc{
+			{Name: "base", Description: "Base currency whose rates are dropped"},
+			{Name: "target", Description: "Target currency, with base, to drop a single pair"},
+			{Name: "all", Description: "true drops everything", Enum: []string{"true"}},
+		},
+		Response: models.CacheInvalidation{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"POST /api/v1/admin/cache/refresh": {
+		ID: "refreshRates", Tag: "admin", Summary: "Fetch fresh rates of a base from the providers", Admin: true,
+		Query:    []paramDoc{{Name: "base", Description: "Base currency", Required: true}},
+		Response: models.RateTable{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"POST /api/v1/admin/history/import": {
+		ID: "importHistory", Tag: "admin", Summary: "Import historical rates", Admin: true,
+		Description: "Rows have date, base_currency, target_currency and rate, plus optional provider. The format is taken from ?format= or the Content-Type.",
+		Query: []paramDoc{
+			{Name: "format", Enum: []string{"csv", "json", "ndjson"}},
+			{Name: "on_conflict", Description: "What to do with rates stored with a different value", Enum: []string{models.ConflictSkip, models.ConflictOverwrite, models.ConflictFail}},
+		},
+		BodyTypes: []string{"text/csv", "application/json", "application/x-ndjson"},
+		Response:  models.HistoryImportResult{},
+		Errors:    []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+	},
+
+	"GET /openapi.json": {
+		ID: "getOpenAPI", Tag: "docs", Summary: "This OpenAPI document",
+		Produces: []string{"application/json"},
+	},
+	"GET /docs": {
+		ID: "getDocs", Tag: "docs", Summary: "Swagger UI for this document",
+		Produces: []string{"text/html"},
+	},
+	"GET /docs/{file}": {
+		ID: "getDocsAsset", Tag: "docs", Summary: "A script or stylesheet of the Swagger UI page",
+		Produces: []string{"text/javascript", "text/css"},
+		Errors:   []int{http.StatusNotFound},
+	},
+	"GET /": {
+		ID: "getIndex", Tag: "docs", Summary: "Redirects to the Swagger UI",
+		Status: http.StatusFound,
+	},
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"exchange-rate-service/configs"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	cfg := configs.Default()
+	handlers := NewHandlers(nil, nil, nil, nil, log.NewNopLogger())
+	router := NewRouter(handlers, cfg, NewRateLimiter(cfg.Limits), NewHTTPCache(cfg.Cache), NewCompressor(cfg.Compression))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d: %s", rec.Code, rec.Body.String())
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding the document: %v", err)
+	}
+
+	registered := map[string]bool{}
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			registered[method+" "+path] = true
+			if doc.Paths[path][strings.ToLower(method)] == nil {
+				t.Errorf("%s %s is registered but missing from the OpenAPI document; describe it in routeDocs", method, path)
+			}
+		}
+		return nil
+	})
+
+	// With every feature enabled, a description without a route is stale
+	for key := range routeDocs {
+		if !registered[key] {
+			t.Errorf("routeDocs describes %s, which is not registered", key)
+		}
+	}
+
+	// Every reference resolves
+	var refs func(v interface{})
+	refs = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+				var target interface{}
+				switch parts[0] {
+				case "schemas":
+					target = doc.Components.Schemas[parts[1]]
+				case "responses":
+					target = doc.Components.Responses[parts[1]]
+				}
+				if len(parts) != 2 || target == nil {
+					t.Errorf("unresolved reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				refs(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				refs(child)
+			}
+		}
+	}
+	var raw interface{}
+	json.Unmarshal(rec.Body.Bytes(), &raw)
+	refs(raw)
+}
+
+func TestOpenAPISchemasFollowJSONEncoding(t *testing.T) {
+	cfg := configs.Default()
+	router := NewRouter(NewHandlers(nil, nil, nil, nil, log.NewNopLogger()), cfg, NewRateLimiter(cfg.Limits), NewHTTPCache(cfg.Cache), NewCompressor(cfg.Compression))
+	doc, err := newOpenAPI(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rate := doc.Components.Schemas["ExchangeRate"]
+	if rate == nil || rate.Properties["fetched_at"].Format != "date-time" {
+		t.Fatalf("unexpected ExchangeRate schema %+v", rate)
+	}
+	if strings.Join(rate.Required, ",") != "base_currency,fetched_at,provider,rate,target_currency" {
+		t.Errorf("ExchangeRate requires %v", rate.Required)
+	}
+
+	// Interface fields take the type named in their openapi tag
+	latest := doc.Components.Schemas["GetLatestRateResponse"]
+	if latest == nil || latest.Properties["rate"].Ref != "#/components/schemas/ExchangeRate" {
+		t.Errorf("unexpected GetLatestRateResponse schema %+v", latest)
+	}
+	series := doc.Components.Schemas["GetHistoricalRatesResponse"]
+	if series == nil || series.Properties["rates"].Items.Ref != "#/components/schemas/HistoricalRate" ||
+		series.Properties["buckets"].Items.Ref != "#/components/schemas/RateBucket" {
+		t.Errorf("unexpected GetHistoricalRatesResponse schema %+v", series)
+	}
+
+	// Embedded structs are flattened
+	if req := doc.Components.Schemas["StreamRequest"]; req == nil || req.Properties["bases"] == nil {
+		t.Errorf("unexpected StreamRequest schema %+v", req)
+	}
+
+	codes := doc.Components.Schemas["ErrorResponse"].Properties["code"].Enum
+	if !strings.Contains(strings.Join(codes, ","), "RATE_LIMITED") {
+		t.Errorf("error codes %v", codes)
+	}
+}
+
+func TestDocsPageIsSelfContained(t *testing.T) {
+	cfg := configs.Default()
+	handlers := NewHandlers(nil, nil, nil, nil, log.NewNopLogger())
+	router := NewRouter(handlers, cfg, NewRateLimiter(cfg.Limits), NewHTTPCache(cfg.Cache), NewCompressor(cfg.Compression))
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{"/docs", http.StatusOK, "text/html"},
+		{"/docs/swagger-init.js", http.StatusOK, "text/javascript"},
+		{"/docs/missing.js", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+			t.Errorf("GET %s Content-Type = %q, want %s", tt.path, ct, tt.contentType)
+		}
+		if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self'") {
+			t.Errorf("GET %s Content-Security-Policy = %q", tt.path, csp)
+		}
+		if body := rec.Body.String(); strings.Contains(body, "https://") || strings.Contains(body, "http://") {
+			t.Errorf("GET %s loads something from another site", tt.path)
+		}
+	}
+}
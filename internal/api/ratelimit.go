@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter throttles the public API. Its limits can be changed while it is
+// serving, e.g. on a configuration reload.
+type RateLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter creates a rate limiter from the configured limits
+func NewRateLimiter(cfg configs.LimitsConfig) *RateLimiter {
+	l := &RateLimiter{limiter: rate.NewLimiter(rate.Inf, 0)}
+	l.Update(cfg)
+	return l
+}
+
+// Update applies new limits; a zero request rate disables limiting
+func (l *RateLimiter) Update(cfg configs.LimitsConfig) {
+	limit := rate.Inf
+	if cfg.RequestsPerSecond > 0 {
+		limit = rate.Limit(cfg.RequestsPerSecond)
+	}
+	l.limiter.SetLimit(limit)
+	l.limiter.SetBurst(cfg.Burst)
+}
+
+// Middleware rejects requests beyond the limiter's budget with 429
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.limiter.Allow() {
+			w.Header().Set("Retry-After", "1")
+			models.WriteError(w, http.StatusTooManyRequests, "Too Many Requests", "RATE_LIMITED", "Request rate limit exceeded, retry later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
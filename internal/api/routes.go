@@ -1,132 +1,107 @@
 package api
 
 import (
+	"crypto/subtle"
+	"expvar"
 	"net/http"
+	"strings"
 
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
 	"exchange-rate-service/internal/transport"
 
 	"github.com/gorilla/mux"
 )
 
-// NewRouter creates a new HTTP router with all routes
-func NewRouter(handlers *Handlers) *mux.Router {
+// NewRouter creates a new HTTP router with all routes. Requests under /api/v1
+// go through limiter, rate responses get caching headers from httpCache and
+// all responses are compressed by compressor.
+func NewRouter(handlers *Handlers, cfg *configs.Config, limiter *RateLimiter, httpCache *HTTPCache, compressor *Compressor) *mux.Router {
 	router := mux.NewRouter()
 
 	// Middleware
 	router.Use(loggingMiddleware)
 	router.Use(corsMiddleware)
+	router.Use(compressor.Middleware)
 
-	// Health check
+	// Health checks (aggregated report, liveness and readiness probes)
 	router.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+	router.HandleFunc("/livez", handlers.Livez).Methods("GET")
+	router.HandleFunc("/readyz", handlers.Readyz).Methods("GET")
+
+	// Metrics (expvar)
+	if cfg.Features.Metrics {
+		router.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+	}
 
 	// API v1 routes
 	v1 := router.PathPrefix("/api/v1").Subrouter()
+	v1.Use(limiter.Middleware)
 
 	// Build go-kit endpoints
 	eps := transport.MakeEndpoints(handlers.exchangeService, handlers.logger)
 
 	// Currency routes
-	v1.Handle("/currencies", transport.NewGetSupportedCurrenciesHTTPHandler(eps.GetSupportedCurrenciesEndpoint, handlers.logger)).Methods("GET")
-	v1.HandleFunc("/rates", handlers.GetRates).Methods("GET")
+	v1.Handle("/currencies", negotiate(httpCache.Currencies(transport.NewGetSupportedCurrenciesHTTPHandler(eps.GetSupportedCurrenciesEndpoint, handlers.logger)))).Methods("GET")
+	v1.Handle("/rates", negotiate(httpCache.Latest(http.HandlerFunc(handlers.GetRates)))).Methods("GET")
 
 	// Exchange rate routes
-	v1.Handle("/rates/{base}/{target}", transport.NewGetLatestRateHTTPHandler(eps.GetLatestRateEndpoint, handlers.logger)).Methods("GET")
+	v1.Handle("/rates/{base}/{target}", negotiate(httpCache.Latest(transport.NewGetLatestRateHTTPHandler(eps.GetLatestRateEndpoint, handlers.logger)))).Methods("GET")
 	// Historical single-date remains via handler (since free tier not supported)
-	v1.HandleFunc("/rates/{base}/{target}/{date}", handlers.GetHistoricalRate).Methods("GET")
+	if cfg.Features.HistoricalRates {
+		v1.Handle("/rates/{base}/{target}/{date}", negotiate(httpCache.Historical(http.HandlerFunc(handlers.GetHistoricalRate)))).Methods("GET")
+	}
+
+	// Live rate updates (Server-Sent Events and WebSocket)
+	v1.Handle("/stream/rates", handlers.StreamRates(cfg.Streaming.HeartbeatInterval)).Methods("GET")
+	v1.Handle("/ws/rates", handlers.RatesWebSocket(cfg.Streaming)).Methods("GET")
 
 	// Conversion routes
 	v1.Handle("/convert", transport.NewConvertCurrencyHTTPHandler(eps.ConvertCurrencyEndpoint, handlers.logger)).Methods("POST")
 
 	// Time series routes (range) via go-kit endpoint
-	v1.Handle("/timeseries/{base}/{target}", transport.NewGetHistoricalRatesHTTPHandler(eps.GetHistoricalRatesEndpoint, handlers.logger)).Methods("GET")
-
-	// Documentation
-	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		w.Write([]byte(`
-<!DOCTYPE html>
-<html>
-<head>
-    <title>Exchange Rate Service API</title>
-    <style>
-        body { font-family: Arial, sans-serif; margin: 40px; }
-        .endpoint { margin: 20px 0; padding: 15px; border-left: 4px solid #007cba; background: #f8f9fa; }
-        .method { font-weight: bold; color: #007cba; }
-        .url { font-family: monospace; background: #e9ecef; padding: 5px; }
-        .description { margin-top: 10px; color: #6c757d; }
-    </style>
-</head>
-<body>
-    <h1>Exchange Rate Service API</h1>
-    <p>Welcome to the Exchange Rate Service. This service provides real-time and historical exchange rates from multiple providers.</p>
-    
-    <h2>Available Endpoints</h2>
-    
-    <div class="endpoint">
-        <div class="method">GET</div>
-        <div class="url">/health</div>
-        <div class="description">Health check endpoint to verify service status</div>
-    </div>
-    
-    <div class="endpoint">
-        <div class="method">GET</div>
-        <div class="url">/api/v1/currencies</div>
-        <div class="description">Get list of supported currencies</div>
-    </div>
-    
-    <div class="endpoint">
-        <div class="method">GET</div>
-        <div class="url">/api/v1/rates?base=USD</div>
-        <div class="description">Get exchange rates for all currencies relative to base currency</div>
-    </div>
-    
-    <div class="endpoint">
-        <div class="method">GET</div>
-        <div class="url">/api/v1/rates/{base}/{target}</div>
-        <div class="description">Get latest exchange rate between two currencies</div>
-    </div>
-    
-    <div class="endpoint">
-        <div class="method">GET</div>
-        <div class="url">/api/v1/rates/{base}/{target}/{date}</div>
-        <div class="description">Get historical exchange rate for a specific date (YYYY-MM-DD)</div>
-    </div>
-    
-    <div class="endpoint">
-        <div class="method">POST</div>
-        <div class="url">/api/v1/convert</div>
-        <div class="description">Convert amount from one currency to another</div>
-    </div>
-    
-    <div class="endpoint">
-        <div class="method">GET</div>
-        <div class="url">/api/v1/timeseries/{base}/{target}?start_date=2024-01-01&end_date=2024-01-31</div>
-        <div class="description">Get exchange rates for a date range</div>
-    </div>
-    
-    <h2>Example Usage</h2>
-    <p><strong>Get USD to EUR rate:</strong> <code>GET /api/v1/rates/USD/EUR</code></p>
-    <p><strong>Convert 100 USD to EUR:</strong> <code>POST /api/v1/convert</code> with body: <code>{"from_currency": "USD", "to_currency": "EUR", "amount": 100}</code></p>
-    <p><strong>Get historical rate:</strong> <code>GET /api/v1/rates/USD/EUR/2024-01-15</code></p>
-    
-    <h2>Providers</h2>
-    <p>This service aggregates data from multiple exchange rate providers:</p>
-    <ul>
-        <li>open.er-api.com (primary)</li>
-    </ul>
-    
-    <h2>Response Format</h2>
-    <p>All responses follow this format:</p>
-    <pre>{
-  "success": true,
-  "data": {...},
-  "message": "Success message",
-  "timestamp": "2024-01-01T00:00:00Z"
-}</pre>
-</body>
-</html>
-        `))
-	}).Methods("GET")
+	if cfg.Features.HistoricalRates {
+		v1.Handle("/timeseries/{base}/{target}", negotiate(httpCache.Historical(transport.NewGetHistoricalRatesHTTPHandler(eps.GetHistoricalRatesEndpoint, handlers.logger)))).Methods("GET")
+
+		// Accounting rates computed from the stored history
+		v1.HandleFunc("/averages/{base}/{target}", handlers.GetPeriodAverage).Methods("GET")
+		v1.HandleFunc("/reports/averages/{base}", handlers.GetPeriodReport).Methods("GET")
+
+		// Streaming download of the stored history
+		v1.HandleFunc("/history/{base}/export", handlers.ExportHistory).Methods("GET")
+	}
+
+	// Rate alerts (require the admin token, since webhooks are sent from the
+	// service's network)
+	if cfg.Features.Alerts {
+		alerts := v1.PathPrefix("/alerts").Subrouter()
+		alerts.Use(adminAuthMiddleware(cfg.Admin.Token))
+		alerts.HandleFunc("", handlers.ListAlerts).Methods("GET")
+		alerts.HandleFunc("", handlers.CreateAlert).Methods("POST")
+		alerts.HandleFunc("/{id}", handlers.GetAlert).Methods("GET")
+		alerts.HandleFunc("/{id}", handlers.DeleteAlert).Methods("DELETE")
+		alerts.HandleFunc("/{id}/deliveries", handlers.ListAlertDeliveries).Methods("GET")
+	}
+
+	// Admin routes (require the admin token)
+	if cfg.Features.AdminAPI {
+		admin := v1.PathPrefix("/admin").Subrouter()
+		admin.Use(adminAuthMiddleware(cfg.Admin.Token))
+		admin.HandleFunc("/overrides", handlers.ListOverrides).Methods("GET")
+		admin.HandleFunc("/overrides/{base}/{target}", handlers.SetOverride).Methods("PUT")
+		admin.HandleFunc("/overrides/{base}/{target}", handlers.DeleteOverride).Methods("DELETE")
+		admin.HandleFunc("/cache/keys", handlers.ListCacheKeys).Methods("GET")
+		admin.HandleFunc("/cache/entries/{key}", handlers.GetCacheEntry).Methods("GET")
+		admin.HandleFunc("/cache", handlers.InvalidateCache).Methods("DELETE")
+		admin.HandleFunc("/cache/refresh", handlers.RefreshRates).Methods("POST")
+		admin.HandleFunc("/history/import", handlers.ImportHistory).Methods("POST")
+	}
+
+	// Documentation generated from the routes above
+	router.HandleFunc("/openapi.json", openAPIHandler(router)).Methods("GET")
+	router.HandleFunc("/docs", docsHandler).Methods("GET")
+	router.HandleFunc("/docs/{file}", docsAssetHandler).Methods("GET")
+	router.Handle("/", http.RedirectHandler("/docs", http.StatusFound)).Methods("GET")
 
 	return router
 }
@@ -139,13 +114,52 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// negotiate encodes the responses of next in the format asked for with
+// ?format= or the Accept header (JSON, CSV or XML), answering 406 when no
+// registered format is acceptable
+func negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format, ok := models.NegotiateFormat(r)
+		if !ok {
+			models.WriteError(w, http.StatusNotAcceptable, "Not Acceptable", "NOT_ACCEPTABLE",
+				"supported formats are "+strings.Join(models.Formats(), ", "))
+			return
+		}
+		next.ServeHTTP(models.WithFormat(w, format), r)
+	})
+}
+
+// adminAuthMiddleware only lets through requests presenting the admin token,
+// either as "Authorization: Bearer <token>" or in the X-Admin-Token header
+func adminAuthMiddleware(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				models.WriteForbidden(w, "Admin API is disabled; set ADMIN_API_TOKEN to enable it")
+				return
+			}
+
+			presented := r.Header.Get("X-Admin-Token")
+			if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && presented == "" {
+				presented = bearer
+			}
+			if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				models.WriteUnauthorized(w, "Missing or invalid admin token")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // corsMiddleware handles CORS headers
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Token, X-Admin-User")
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+)
+
+// StreamRates streams rate changes as Server-Sent Events. Clients subscribe
+// with ?base=USD,GBP and/or ?pairs=USD/EUR,GBP/JPY and resume after a
+// reconnect with the Last-Event-ID header (or ?last_event_id=). A comment line
+// is sent every heartbeat interval so proxies keep idle streams open.
+func (h *Handlers) StreamRates(heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		level.Debug(h.logger).Log("method", "StreamRates", "remote_addr", r.RemoteAddr)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			models.WriteInternalError(w, "Streaming is not supported by this connection")
+			return
+		}
+
+		filter, err := parseRateFilter(r)
+		if err != nil {
+			models.WriteBadRequest(w, err.Error())
+			return
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		var resumeFrom uint64
+		if lastEventID != "" {
+			resumeFrom, err = strconv.ParseUint(lastEventID, 10, 64)
+			if err != nil {
+				models.WriteBadRequest(w, "Last-Event-ID must be a non-negative integer")
+				return
+			}
+		}
+
+		ctx := r.Context()
+		sub, err := h.rateHub.Subscribe(ctx, filter, resumeFrom)
+		if err != nil {
+			level.Error(h.logger).Log("error", err, "method", "StreamRates")
+			if errors.IsValidationError(err) {
+				models.WriteBadRequest(w, err.Error())
+				return
+			}
+			models.WriteInternalError(w, "Failed to subscribe to rate updates")
+			return
+		}
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		// Ask EventSource clients to wait a few seconds before reconnecting
+		fmt.Fprint(w, "retry: 3000\n\n")
+		flusher.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-sub.Updates:
+				if !ok {
+					// Dropped for falling behind; the client reconnects and resumes
+					return
+				}
+				if err := writeRateEvent(w, update); err != nil {
+					level.Error(h.logger).Log("error", err, "method", "StreamRates")
+					return
+				}
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeRateEvent writes an update as a "rates" event
+func writeRateEvent(w http.ResponseWriter, update *models.RateUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: rates\ndata: %s\n\n", update.ID, data)
+	return err
+}
+
+// parseRateFilter reads the base and pairs query parameters; both take comma
+// separated lists, pairs written as BASE/TARGET
+func parseRateFilter(r *http.Request) (models.RateFilter, error) {
+	query := r.URL.Query()
+	filter := models.RateFilter{Bases: splitList(query.Get("base"))}
+	for _, pair := range splitList(query.Get("pairs")) {
+		base, target, ok := strings.Cut(pair, "/")
+		if !ok {
+			return filter, fmt.Errorf("invalid pair %q, expected BASE/TARGET", pair)
+		}
+		filter.Pairs = append(filter.Pairs, models.CurrencyPair{BaseCurrency: base, TargetCurrency: target})
+	}
+	return filter, nil
+}
+
+// splitList splits a comma separated parameter, skipping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/service"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait bounds every write so a stalled client can't pin a goroutine
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageSize caps client messages; requests are small JSON objects
+	wsMaxMessageSize = 4096
+	// wsMaxConversions caps conversions in flight per connection
+	wsMaxConversions = 4
+)
+
+// newUpgrader accepts upgrades from the service's own origin and from
+// allowedOrigins. Browsers send cookies with WebSocket handshakes whatever
+// the origin, so unlike CORS this check is what keeps other sites out.
+func newUpgrader(allowedOrigins []string) *websocket.Upgrader {
+	upgrader := &websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+	if len(allowedOrigins) == 0 {
+		// gorilla's default: no Origin header, or one matching the Host
+		return upgrader
+	}
+	upgrader.CheckOrigin = func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+				return true
+			}
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	return upgrader
+}
+
+// RatesWebSocket serves live rates over a WebSocket. Clients send subscribe
+// and unsubscribe messages for bases and pairs and may convert amounts over
+// the same socket; see models.StreamRequest and models.StreamMessage.
+func (h *Handlers) RatesWebSocket(config configs.StreamingConfig) http.HandlerFunc {
+	upgrader := newUpgrader(config.AllowedOrigins)
+	return func(w http.ResponseWriter, r *http.Request) {
+		level.Debug(h.logger).Log("method", "RatesWebSocket", "remote_addr", r.RemoteAddr)
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already replied with an HTTP error
+			level.Error(h.logger).Log("error", err, "method", "RatesWebSocket")
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		c := &wsConn{
+			handlers:    h,
+			conn:        conn,
+			send:        make(chan *models.StreamMessage, config.SubscriberBuffer),
+			done:        make(chan struct{}),
+			conversions: make(chan struct{}, wsMaxConversions),
+		}
+		go c.writeLoop(config.PingInterval)
+		c.readLoop(ctx, 2*config.PingInterval)
+		c.shutdown(websocket.CloseNormalClosure, "")
+	}
+}
+
+// wsConn is one WebSocket client. The read loop handles requests, the write
+// loop is the only writer of data frames, and each subscription gets a
+// goroutine forwarding its updates into send.
+type wsConn struct {
+	handlers    *Handlers
+	conn        *websocket.Conn
+	send        chan *models.StreamMessage
+	done        chan struct{}
+	closeOnce   sync.Once
+	conversions chan struct{}
+
+	// sub is only touched by the read loop
+	sub *service.RateSubscription
+}
+
+// readLoop handles client messages until the connection fails or goes
+// quiet for longer than pongWait
+func (c *wsConn) readLoop(ctx context.Context, pongWait time.Duration) {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var req models.StreamRequest
+		if err := c.conn.ReadJSON(&req); err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok && !isClosed(c.done) {
+				level.Error(c.handlers.logger).Log("error", err, "method", "RatesWebSocket")
+			}
+			return
+		}
+
+		switch req.Type {
+		case models.StreamSubscribe:
+			c.subscribe(ctx, &req)
+		case models.StreamUnsubscribe:
+			c.unsubscribe(&req)
+		case models.StreamConvert:
+			c.convert(ctx, &req)
+		default:
+			c.reply(&req, "", nil, "unknown message type "+req.Type)
+		}
+	}
+}
+
+func (c *wsConn) subscribe(ctx context.Context, req *models.StreamRequest) {
+	var err error
+	if c.sub == nil {
+		c.sub, err = c.handlers.rateHub.Subscribe(ctx, req.RateFilter, 0)
+		if err == nil {
+			go c.forward(c.sub)
+		}
+	} else {
+		err = c.sub.Add(ctx, req.RateFilter)
+	}
+	if err != nil {
+		c.reply(req, "", nil, err.Error())
+		return
+	}
+	c.reply(req, models.StreamSubscribed, req.RateFilter, "")
+}
+
+func (c *wsConn) unsubscribe(req *models.StreamRequest) {
+	if c.sub != nil {
+		if err := c.sub.Remove(req.RateFilter); err != nil {
+			c.reply(req, "", nil, err.Error())
+			return
+		}
+	}
+	c.reply(req, models.StreamUnsubscribed, req.RateFilter, "")
+}
+
+// convert answers from its own goroutine so a slow provider doesn't stall
+// the read loop and its pong handling
+func (c *wsConn) convert(ctx context.Context, req *models.StreamRequest) {
+	if req.Conversion == nil {
+		c.reply(req, "", nil, "conversion is required")
+		return
+	}
+	select {
+	case c.conversions <- struct{}{}:
+	default:
+		c.reply(req, "", nil, "too many conversions in flight")
+		return
+	}
+
+	go func() {
+		defer func() { <-c.conversions }()
+		response, err := c.handlers.exchangeService.ConvertCurrency(ctx, req.Conversion)
+		if err != nil {
+			level.Error(c.handlers.logger).Log("error", err, "method", "RatesWebSocket")
+			c.reply(req, "", nil, err.Error())
+			return
+		}
+		c.reply(req, models.StreamConversion, response, "")
+	}()
+}
+
+// forward queues the subscription's updates. The hub ends a subscription
+// whose client fell too far behind (or when the server stops), and the
+// client is told to reconnect.
+func (c *wsConn) forward(sub *service.RateSubscription) {
+	for update := range sub.Updates {
+		if !c.enqueue(&models.StreamMessage{Type: models.StreamRates, Data: update}) {
+			return
+		}
+	}
+	c.shutdown(websocket.CloseTryAgainLater, "rate subscription ended, reconnect")
+}
+
+// reply answers a request; an error reply has type "error"
+func (c *wsConn) reply(req *models.StreamRequest, msgType string, data interface{}, errMsg string) {
+	if errMsg != "" {
+		msgType = models.StreamError
+	}
+	c.enqueue(&models.StreamMessage{ID: req.ID, Type: msgType, Data: data, Error: errMsg})
+}
+
+// enqueue queues a message without blocking. A client that stops reading
+// until its queue fills up is disconnected rather than buffered for.
+func (c *wsConn) enqueue(msg *models.StreamMessage) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		level.Warn(c.handlers.logger).Log("msg", "dropping slow WebSocket client", "buffer", cap(c.send))
+		c.shutdown(websocket.ClosePolicyViolation, "client too slow")
+		return false
+	}
+}
+
+// writeLoop writes queued messages and pings the client every pingInterval
+func (c *wsConn) writeLoop(pingInterval time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.shutdown(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				c.shutdown(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+// shutdown sends a close frame and closes the connection, which ends the
+// read loop and with it the subscription; safe to call from any goroutine
+func (c *wsConn) shutdown(code int, reason string) {
+	c.closeOnce.Do(func() {
+		if code != websocket.CloseAbnormalClosure {
+			c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+		}
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func isClosed(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
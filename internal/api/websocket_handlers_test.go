@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+	"exchange-rate-service/internal/service"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/websocket"
+)
+
+// streamRepo supports a few currencies and hands its listener to the test,
+// which publishes tables through it
+type streamRepo struct {
+	repository.RateRepository
+	publish repository.RateListener
+}
+
+func (r *streamRepo) AddRateListener(listener repository.RateListener) { r.publish = listener }
+
+func (r *streamRepo) GetSupportedCurrencies(context.Context) ([]*models.Currency, error) {
+	return []*models.Currency{{Code: "USD"}, {Code: "EUR"}, {Code: "GBP"}}, nil
+}
+
+func newWebSocketServer(t *testing.T, allowedOrigins ...string) (*httptest.Server, *streamRepo) {
+	t.Helper()
+	config := configs.Default().Streaming
+	config.AllowedOrigins = allowedOrigins
+	repo := &streamRepo{}
+	hub := service.NewRateHub(repo, config, log.NewNopLogger())
+	handlers := NewHandlers(nil, nil, nil, hub, log.NewNopLogger())
+
+	server := httptest.NewServer(handlers.RatesWebSocket(config))
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+func dial(server *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+}
+
+func TestRatesWebSocketChecksOrigin(t *testing.T) {
+	defaults, _ := newWebSocketServer(t)
+	configured, _ := newWebSocketServer(t, "https://app.example.com")
+
+	tests := []struct {
+		name   string
+		server *httptest.Server
+		origin string
+		ok     bool
+	}{
+		{"no origin", defaults, "", true},
+		{"same origin", defaults, defaults.URL, true},
+		{"other origin", defaults, "https://evil.example.com", false},
+		{"allowed origin", configured, "https://APP.example.com", true},
+		{"same origin with allowed origins", configured, configured.URL, true},
+		{"origin not allowed", configured, "https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := dial(tt.server, tt.origin)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("dial: %v", err)
+				}
+				conn.Close()
+				return
+			}
+			if err == nil {
+				conn.Close()
+				t.Fatal("upgrade from a foreign origin succeeded")
+			}
+			if resp == nil || resp.StatusCode != http.StatusForbidden {
+				t.Errorf("rejected upgrade response = %v, want 403", resp)
+			}
+		})
+	}
+}
+
+// rawMessage is a StreamMessage with its data left undecoded
+type rawMessage struct {
+	ID    string                 `json:"id"`
+	Type  string                 `json:"type"`
+	Data  map[string]interface{} `json:"data"`
+	Error string                 `json:"error"`
+}
+
+func TestRatesWebSocketProtocol(t *testing.T) {
+	server, repo := newWebSocketServer(t)
+	conn, _, err := dial(server, "")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	send := func(req models.StreamRequest) {
+		t.Helper()
+		if err := conn.WriteJSON(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receive := func() rawMessage {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg rawMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	table := func(eur, gbp float64) *models.RateTable {
+		return &models.RateTable{BaseCurrency: "USD", Rates: map[string]float64{"EUR": eur, "GBP": gbp}, Provider: "p", FetchedAt: time.Now()}
+	}
+
+	send(models.StreamRequest{ID: "1", Type: models.StreamSubscribe, RateFilter: models.RateFilter{Bases: []string{"XYZ"}}})
+	if msg := receive(); msg.ID != "1" || msg.Type != models.StreamError || !strings.Contains(msg.Error, "unsupported currency") {
+		t.Errorf("subscribing to an unsupported base: %+v", msg)
+	}
+
+	send(models.StreamRequest{ID: "2", Type: models.StreamSubscribe, RateFilter: models.RateFilter{Pairs: []models.CurrencyPair{{BaseCurrency: "usd", TargetCurrency: "eur"}}}})
+	if msg := receive(); msg.ID != "2" || msg.Type != models.StreamSubscribed {
+		t.Fatalf("subscribe reply: %+v", msg)
+	}
+	repo.publish(table(0.9, 0.8))
+	msg := receive()
+	if msg.Type != models.StreamRates || msg.ID != "" {
+		t.Fatalf("expected a rates message, got %+v", msg)
+	}
+	if rates := msg.Data["rates"].(map[string]interface{}); len(rates) != 1 || rates["EUR"] != 0.9 {
+		t.Errorf("pair subscription got rates %v, want only EUR", rates)
+	}
+
+	// Adding the whole base sends a snapshot of it
+	send(models.StreamRequest{ID: "3", Type: models.StreamSubscribe, RateFilter: models.RateFilter{Bases: []string{"USD"}}})
+	got := map[string]rawMessage{}
+	for i := 0; i < 2; i++ {
+		msg := receive()
+		got[msg.Type] = msg
+	}
+	if got[models.StreamSubscribed].ID != "3" || got[models.StreamRates].Data["snapshot"] != true {
+		t.Errorf("adding a base: %+v", got)
+	}
+
+	send(models.StreamRequest{ID: "4", Type: models.StreamUnsubscribe, RateFilter: models.RateFilter{Bases: []string{"USD"}}})
+	if msg := receive(); msg.ID != "4" || msg.Type != models.StreamUnsubscribed {
+		t.Fatalf("unsubscribe reply: %+v", msg)
+	}
+
+	// Nothing is sent for the unsubscribed base, so the next message is the
+	// reply to an unknown request type
+	repo.publish(table(0.91, 0.81))
+	send(models.StreamRequest{ID: "5", Type: "resubscribe"})
+	if msg := receive(); msg.ID != "5" || msg.Type != models.StreamError || msg.Error != "unknown message type resubscribe" {
+		t.Errorf("after unsubscribing: %+v", msg)
+	}
+}
@@ -0,0 +1,205 @@
+// Package calendar tells business days from weekends and bank holidays, so
+// historical lookups know which dates have a published reference rate.
+package calendar
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Weekends is the name of the built-in calendar whose only non-business days
+// are Saturdays and Sundays
+const Weekends = "weekends"
+
+// maxSearchDays bounds the search for the previous or next business day
+const maxSearchDays = 31
+
+// Calendar decides which days are business days in a market
+type Calendar interface {
+	Name() string
+	IsBusinessDay(date time.Time) bool
+}
+
+// weekendCalendar treats every weekday as a business day
+type weekendCalendar struct{}
+
+func (weekendCalendar) Name() string { return Weekends }
+
+func (weekendCalendar) IsBusinessDay(date time.Time) bool {
+	day := date.Weekday()
+	return day != time.Saturday && day != time.Sunday
+}
+
+// Holiday is a bank holiday in a holiday calendar
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// ErrNotCovered is returned for dates in years a holiday calendar has no
+// holidays for, where it can't tell holidays from business days
+var ErrNotCovered = errors.New("date outside the years the calendar covers")
+
+// HolidayCalendar excludes weekends and a list of bank holidays. It covers
+// the years from its first holiday to its last.
+type HolidayCalendar struct {
+	name        string
+	description string
+	holidays    map[string]string
+	first, last int
+}
+
+// NewHolidayCalendar creates a calendar closed on weekends and on holidays
+func NewHolidayCalendar(name, description string, holidays []Holiday) (*HolidayCalendar, error) {
+	c := &HolidayCalendar{name: name, description: description, holidays: make(map[string]string, len(holidays))}
+	for _, h := range holidays {
+		date, err := time.Parse("2006-01-02", h.Date)
+		if err != nil {
+			return nil, fmt.Errorf("calendar %s: invalid holiday date %q", name, h.Date)
+		}
+		c.holidays[h.Date] = h.Name
+		if c.first == 0 || date.Year() < c.first {
+			c.first = date.Year()
+		}
+		if date.Year() > c.last {
+			c.last = date.Year()
+		}
+	}
+	return c, nil
+}
+
+func (c *HolidayCalendar) Name() string { return c.name }
+
+// Description says which market the calendar covers
+func (c *HolidayCalendar) Description() string { return c.description }
+
+func (c *HolidayCalendar) IsBusinessDay(date time.Time) bool {
+	if !(weekendCalendar{}).IsBusinessDay(date) {
+		return false
+	}
+	_, holiday := c.holidays[date.Format("2006-01-02")]
+	return !holiday
+}
+
+// Years returns the first and last year the calendar lists holidays for
+func (c *HolidayCalendar) Years() (first, last int) { return c.first, c.last }
+
+// Covers reports whether the calendar lists the holidays of date's year
+func (c *HolidayCalendar) Covers(date time.Time) bool {
+	return date.Year() >= c.first && date.Year() <= c.last
+}
+
+// Holiday returns the name of the holiday on date, if any
+func (c *HolidayCalendar) Holiday(date time.Time) (string, bool) {
+	name, ok := c.holidays[date.Format("2006-01-02")]
+	return name, ok
+}
+
+//go:embed holidays/*.json
+var holidayFiles embed.FS
+
+var (
+	mu        sync.RWMutex
+	calendars = map[string]Calendar{}
+)
+
+func init() {
+	Register(weekendCalendar{})
+
+	files, err := holidayFiles.ReadDir("holidays")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		data, err := holidayFiles.ReadFile(path.Join("holidays", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var file struct {
+			Name        string    `json:"name"`
+			Description string    `json:"description"`
+			Holidays    []Holiday `json:"holidays"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			panic(fmt.Sprintf("calendar %s: %v", f.Name(), err))
+		}
+		c, err := NewHolidayCalendar(file.Name, file.Description, file.Holidays)
+		if err != nil {
+			panic(err)
+		}
+		Register(c)
+	}
+}
+
+// Register makes a calendar available by name, replacing any calendar
+// registered under the same name. Names are case-insensitive.
+func Register(c Calendar) {
+	mu.Lock()
+	defer mu.Unlock()
+	calendars[strings.ToUpper(c.Name())] = c
+}
+
+// Get returns the calendar registered under name
+func Get(name string) (Calendar, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := calendars[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown calendar %q, available: %s", name, strings.Join(namesLocked(), ", "))
+	}
+	return c, nil
+}
+
+// Names lists the registered calendars
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(calendars))
+	for _, c := range calendars {
+		names = append(names, c.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckRange returns ErrNotCovered when c is a holiday calendar without
+// holidays for part of the dates from start to end. The weekends calendar
+// covers every date.
+func CheckRange(c Calendar, start, end time.Time) error {
+	h, ok := c.(*HolidayCalendar)
+	if !ok || (h.Covers(start) && h.Covers(end)) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s lists holidays for %d to %d only", ErrNotCovered, h.name, h.first, h.last)
+}
+
+// PreviousBusinessDay returns the last business day before date
+func PreviousBusinessDay(c Calendar, date time.Time) (time.Time, bool) {
+	for i := 1; i <= maxSearchDays; i++ {
+		if d := date.AddDate(0, 0, -i); c.IsBusinessDay(d) {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NextBusinessDay returns the first business day after date
+func NextBusinessDay(c Calendar, date time.Time) (time.Time, bool) {
+	for i := 1; i <= maxSearchDays; i++ {
+		if d := date.AddDate(0, 0, i); c.IsBusinessDay(d) {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,87 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestEmbeddedCalendars(t *testing.T) {
+	cases := []struct {
+		calendar string
+		date     string
+		business bool
+	}{
+		{Weekends, "2024-03-29", true},  // Good Friday is a weekday
+		{Weekends, "2024-03-30", false}, // Saturday
+		{"TARGET", "2024-03-29", false}, // Good Friday
+		{"TARGET", "2024-05-01", false}, // Labour Day
+		{"TARGET", "2024-07-04", true},
+		{"US", "2024-07-04", false}, // Independence Day
+		{"US", "2024-11-28", false}, // Thanksgiving
+		{"UK", "2024-08-26", false}, // Summer bank holiday
+		{"uk", "2024-08-27", true},
+	}
+	for _, c := range cases {
+		cal, err := Get(c.calendar)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", c.calendar, err)
+		}
+		if got := cal.IsBusinessDay(date(c.date)); got != c.business {
+			t.Errorf("%s %s: business day = %v, want %v", c.calendar, c.date, got, c.business)
+		}
+	}
+}
+
+func TestAdjacentBusinessDays(t *testing.T) {
+	target, err := Get("TARGET")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Easter 2024: Good Friday 29 March to Easter Monday 1 April
+	prev, ok := PreviousBusinessDay(target, date("2024-03-31"))
+	if !ok || !prev.Equal(date("2024-03-28")) {
+		t.Errorf("previous business day = %v, want 2024-03-28", prev)
+	}
+	next, ok := NextBusinessDay(target, date("2024-03-29"))
+	if !ok || !next.Equal(date("2024-04-02")) {
+		t.Errorf("next business day = %v, want 2024-04-02", next)
+	}
+}
+
+func TestCheckRange(t *testing.T) {
+	us, err := Get("US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last := us.(*HolidayCalendar).Years()
+	if first != 2020 || last != 2027 {
+		t.Fatalf("US covers %d to %d, want 2020 to 2027", first, last)
+	}
+
+	if err := CheckRange(us, date("2020-01-01"), date("2027-12-31")); err != nil {
+		t.Errorf("covered range: %v", err)
+	}
+	for _, r := range [][2]string{{"2019-12-31", "2020-01-10"}, {"2027-12-01", "2028-01-05"}, {"2030-01-01", "2030-01-01"}} {
+		if err := CheckRange(us, date(r[0]), date(r[1])); !errors.Is(err, ErrNotCovered) {
+			t.Errorf("%s to %s: got %v, want ErrNotCovered", r[0], r[1], err)
+		}
+	}
+
+	weekends, _ := Get(Weekends)
+	if err := CheckRange(weekends, date("1999-01-01"), date("2099-01-01")); err != nil {
+		t.Errorf("weekends calendar: %v", err)
+	}
+}
+
+func TestGetUnknownCalendar(t *testing.T) {
+	if _, err := Get("MARS"); err == nil {
+		t.Fatal("expected an error for an unknown calendar")
+	}
+}
@@ -13,6 +13,7 @@ const (
 	ErrorTypeNotFound     ErrorType = "NOT_FOUND"
 	ErrorTypeUnauthorized ErrorType = "UNAUTHORIZED"
 	ErrorTypeForbidden    ErrorType = "FORBIDDEN"
+	ErrorTypeConflict     ErrorType = "CONFLICT"
 	ErrorTypeInternal     ErrorType = "INTERNAL_ERROR"
 	ErrorTypeProvider     ErrorType = "PROVIDER_ERROR"
 	ErrorTypeCache        ErrorType = "CACHE_ERROR"
@@ -73,6 +74,15 @@ func NewForbiddenError(message string) *AppError {
 	}
 }
 
+// NewConflictError creates a new conflict error
+func NewConflictError(message string, details string) *AppError {
+	return &AppError{
+		Type:    ErrorTypeConflict,
+		Message: message,
+		Details: details,
+	}
+}
+
 // NewInternalError creates a new internal error
 func NewInternalError(message string, err error) *AppError {
 	return &AppError{
@@ -112,6 +122,8 @@ func GetHTTPStatusCode(err error) int {
 			return http.StatusUnauthorized
 		case ErrorTypeForbidden:
 			return http.StatusForbidden
+		case ErrorTypeConflict:
+			return http.StatusConflict
 		case ErrorTypeProvider:
 			return http.StatusServiceUnavailable
 		case ErrorTypeCache:
@@ -138,3 +150,11 @@ func IsValidationError(err error) bool {
 	}
 	return false
 }
+
+// IsConflictError checks if an error is a conflict error
+func IsConflictError(err error) bool {
+	if appErr, ok := err.(*AppError); ok {
+		return appErr.Type == ErrorTypeConflict
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RateOverride pins the rate of a currency pair, optionally within a validity window
+type RateOverride struct {
+	BaseCurrency   string     `json:"base_currency"`
+	TargetCurrency string     `json:"target_currency"`
+	Rate           float64    `json:"rate"`
+	ValidFrom      time.Time  `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	Author         string     `json:"author"`
+	Reason         string     `json:"reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ActiveAt reports whether the override applies at t
+func (o *RateOverride) ActiveAt(t time.Time) bool {
+	if t.Before(o.ValidFrom) {
+		return false
+	}
+	return o.ValidUntil == nil || t.Before(*o.ValidUntil)
+}
+
+// OverrideRequest represents a request to pin a rate. ValidFrom and ValidUntil
+// accept RFC 3339 timestamps or YYYY-MM-DD dates.
+type OverrideRequest struct {
+	BaseCurrency   string  `json:"base_currency"`
+	TargetCurrency string  `json:"target_currency"`
+	Rate           float64 `json:"rate"`
+	ValidFrom      string  `json:"valid_from,omitempty"`
+	ValidUntil     string  `json:"valid_until,omitempty"`
+	Author         string  `json:"author,omitempty"`
+	Reason         string  `json:"reason,omitempty"`
+}
+
+// CacheEntry represents a cached value as shown by the admin API. TTLSeconds
+// is -1 for entries that never expire.
+type CacheEntry struct {
+	Key        string          `json:"key"`
+	Value      json.RawMessage `json:"value"`
+	TTLSeconds int64           `json:"ttl_seconds"`
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"`
+}
+
+// CacheInvalidation reports the outcome of an admin cache invalidation
+type CacheInvalidation struct {
+	Scope       string   `json:"scope"`
+	DeletedKeys []string `json:"deleted_keys"`
+	Count       int      `json:"count"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Alert conditions. Above, below and crosses fire when the rate moves across
+// Threshold; change fires when the rate moves ChangePercent or more away from
+// the rate seen at the start of the alert's window.
+const (
+	AlertConditionAbove   = "above"
+	AlertConditionBelow   = "below"
+	AlertConditionCrosses = "crosses"
+	AlertConditionChange  = "change"
+)
+
+// Alert delivery statuses
+const (
+	AlertDeliveryDelivered = "delivered"
+	AlertDeliveryFailed    = "failed"
+)
+
+// RateAlert is a registered alert on a currency pair. LastRate, ReferenceRate
+// and ReferenceAt are evaluation state kept with the definition so restarts
+// don't fire alerts twice. Secret signs the webhook payloads; it is only
+// returned when the alert is created.
+type RateAlert struct {
+	ID              string     `json:"id"`
+	BaseCurrency    string     `json:"base_currency"`
+	TargetCurrency  string     `json:"target_currency"`
+	Condition       string     `json:"condition"`
+	Threshold       float64    `json:"threshold,omitempty"`
+	ChangePercent   float64    `json:"change_percent,omitempty"`
+	Window          string     `json:"window,omitempty"`
+	WebhookURL      string     `json:"webhook_url"`
+	Secret          string     `json:"secret,omitempty"`
+	Description     string     `json:"description,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastRate        float64    `json:"last_rate,omitempty"`
+	ReferenceRate   float64    `json:"reference_rate,omitempty"`
+	ReferenceAt     *time.Time `json:"reference_at,omitempty"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+}
+
+// AlertRequest represents a request to register an alert. Window is a
+// duration such as "24h" and only applies to change alerts; a secret is
+// generated when none is given.
+type AlertRequest struct {
+	BaseCurrency   string  `json:"base_currency"`
+	TargetCurrency string  `json:"target_currency"`
+	Condition      string  `json:"condition"`
+	Threshold      float64 `json:"threshold,omitempty"`
+	ChangePercent  float64 `json:"change_percent,omitempty"`
+	Window         string  `json:"window,omitempty"`
+	WebhookURL     string  `json:"webhook_url"`
+	Secret         string  `json:"secret,omitempty"`
+	Description    string  `json:"description,omitempty"`
+}
+
+// AlertEvent is the JSON payload POSTed to an alert's webhook
+type AlertEvent struct {
+	DeliveryID     string    `json:"delivery_id"`
+	AlertID        string    `json:"alert_id"`
+	BaseCurrency   string    `json:"base_currency"`
+	TargetCurrency string    `json:"target_currency"`
+	Condition      string    `json:"condition"`
+	Threshold      float64   `json:"threshold,omitempty"`
+	ChangePercent  float64   `json:"change_percent,omitempty"`
+	Rate           float64   `json:"rate"`
+	PreviousRate   float64   `json:"previous_rate"`
+	Provider       string    `json:"provider"`
+	TriggeredAt    time.Time `json:"triggered_at"`
+}
+
+// AlertDelivery records one webhook delivery and its outcome
+type AlertDelivery struct {
+	ID          string      `json:"id"`
+	AlertID     string      `json:"alert_id"`
+	Event       *AlertEvent `json:"event"`
+	Status      string      `json:"status"`
+	Attempts    int         `json:"attempts"`
+	StatusCode  int         `json:"status_code,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	CompletedAt time.Time   `json:"completed_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// PeriodAverage holds the accounting rates of a pair over a calendar period,
+// computed from the stored history. SimpleAverage is the mean of the rates
+// stored for dates in the period; BusinessDayAverage weights each business
+// day equally, carrying the last known rate over days without one. The
+// closing rate is the rate in force on the period's last business day.
+type PeriodAverage struct {
+	BaseCurrency       string  `json:"base_currency"`
+	TargetCurrency     string  `json:"target_currency"`
+	Period             string  `json:"period"`
+	StartDate          string  `json:"start_date"`
+	EndDate            string  `json:"end_date"`
+	Calendar           string  `json:"calendar"`
+	SimpleAverage      float64 `json:"simple_average"`
+	BusinessDayAverage float64 `json:"business_day_average"`
+	ClosingRate        float64 `json:"closing_rate"`
+	ClosingDate        string  `json:"closing_date"`
+	Rates              int     `json:"rates"`
+	BusinessDays       int     `json:"business_days"`
+}
+
+// PeriodReport lists the period averages of every currency against a base.
+// Currencies without stored rates for the period are listed in Missing.
+type PeriodReport struct {
+	BaseCurrency string            `json:"base_currency"`
+	Period       string            `json:"period"`
+	StartDate    string            `json:"start_date"`
+	EndDate      string            `json:"end_date"`
+	Calendar     string            `json:"calendar"`
+	Averages     []*PeriodAverage  `json:"averages"`
+	Missing      []MissingCurrency `json:"missing,omitempty"`
+}
+
+// MissingCurrency reports a currency left out of a report
+type MissingCurrency struct {
+	Currency string `json:"currency"`
+	Reason   string `json:"reason"`
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheInfo describes the data in a response for HTTP caching
+type CacheInfo struct {
+	// LastModified is when the newest data in the response was fetched
+	LastModified time.Time
+	// FetchedAt is when the oldest data was fetched, which expires first
+	FetchedAt time.Time
+	// Revalidate marks data that caches must not reuse without asking again,
+	// such as stale rates or an incomplete time series
+	Revalidate bool
+	// NoStore marks responses that must not be cached at all, such as errors
+	// reported with a 200 status
+	NoStore bool
+}
+
+// Cacheable is implemented by responses that HTTP caches may store.
+// Responses that don't implement it are sent with Cache-Control: no-store.
+type Cacheable interface {
+	CacheInfo() CacheInfo
+}
+
+// CachePolicy sets how long cacheable responses stay fresh
+type CachePolicy struct {
+	MaxAge time.Duration
+	// FromFetch counts MaxAge from when the data was fetched instead of from
+	// now, for data that expires from the server cache at that point
+	FromFetch bool
+}
+
+// cachingWriter carries the cache policy and conditional headers of a request
+// to the Write helpers
+type cachingWriter struct {
+	http.ResponseWriter
+	request *http.Request
+	policy  CachePolicy
+}
+
+// WithCaching makes the Write helpers add Cache-Control, ETag and
+// Last-Modified headers to successful responses written to w, and answer
+// 304 Not Modified when r's If-None-Match or If-Modified-Since still match
+func WithCaching(w http.ResponseWriter, r *http.Request, policy CachePolicy) http.ResponseWriter {
+	return &cachingWriter{ResponseWriter: w, request: r, policy: policy}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (c *cachingWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+func cachingOf(w http.ResponseWriter) *cachingWriter {
+	for {
+		switch cw := w.(type) {
+		case *cachingWriter:
+			return cw
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = cw.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// writeCacheHeaders sets the caching headers of a response and reports
+// whether the client's copy is still current, in which case a 304 has been
+// written instead
+func (c *cachingWriter) writeCacheHeaders(w http.ResponseWriter, statusCode int, data interface{}, contentType string) (notModified bool) {
+	header := w.Header()
+	if resp, ok := data.(*APIResponse); ok {
+		data = resp.Data
+	}
+	cacheable, ok := data.(Cacheable)
+	if statusCode != http.StatusOK || !ok {
+		header.Set("Cache-Control", "no-store")
+		return false
+	}
+	info := cacheable.CacheInfo()
+	if info.NoStore {
+		header.Set("Cache-Control", "no-store")
+		return false
+	}
+
+	// Hash the payload rather than the body, whose envelope carries the time
+	// it was written
+	payload, err := json.Marshal(data)
+	if err != nil {
+		header.Set("Cache-Control", "no-store")
+		return false
+	}
+	sum := sha256.Sum256(append([]byte(contentType+"\n"), payload...))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	header.Set("ETag", etag)
+
+	if info.Revalidate {
+		header.Set("Cache-Control", "no-cache")
+	} else {
+		maxAge := c.policy.MaxAge
+		if c.policy.FromFetch && !info.FetchedAt.IsZero() {
+			maxAge -= time.Since(info.FetchedAt)
+		}
+		if maxAge < 0 {
+			maxAge = 0
+		}
+		header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	}
+	if !info.LastModified.IsZero() {
+		header.Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if !c.notModified(etag, info.LastModified) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates the request's conditional headers as RFC 9110 does
+// for GET: If-None-Match takes precedence over If-Modified-Since
+func (c *cachingWriter) notModified(etag string, lastModified time.Time) bool {
+	if inm := c.request.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := c.request.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// cacheInfoOf merges the fetch times of several rates
+func cacheInfoOf(fetched []time.Time, revalidate bool) CacheInfo {
+	info := CacheInfo{Revalidate: revalidate}
+	for _, t := range fetched {
+		if t.IsZero() {
+			continue
+		}
+		if t.After(info.LastModified) {
+			info.LastModified = t
+		}
+		if info.FetchedAt.IsZero() || t.Before(info.FetchedAt) {
+			info.FetchedAt = t
+		}
+	}
+	return info
+}
+
+func (r *ExchangeRate) CacheInfo() CacheInfo {
+	return cacheInfoOf([]time.Time{r.FetchedAt}, r.IsStale)
+}
+
+func (l *RateList) CacheInfo() CacheInfo {
+	fetched := make([]time.Time, 0, len(l.Rates))
+	stale := false
+	for _, rate := range l.Rates {
+		fetched = append(fetched, rate.FetchedAt)
+		stale = stale || rate.IsStale
+	}
+	return cacheInfoOf(fetched, stale)
+}
+
+func (r *HistoricalRate) CacheInfo() CacheInfo {
+	return cacheInfoOf([]time.Time{r.FetchedAt}, false)
+}
+
+func (h HistoricalRates) CacheInfo() CacheInfo {
+	fetched := make([]time.Time, 0, len(h))
+	for _, rate := range h {
+		fetched = append(fetched, rate.FetchedAt)
+	}
+	return cacheInfoOf(fetched, false)
+}
+
+// CacheInfo marks a series with missing dates for revalidation, since the
+// dates may be filled in later
+func (s *TimeSeries) CacheInfo() CacheInfo {
+	info := HistoricalRates(s.Rates).CacheInfo()
+	info.Revalidate = len(s.Missing) > 0
+	return info
+}
+
+// CacheInfo marks buckets for revalidation while the period of the last one
+// is still open, since its close, high and low change with each new rate
+func (b RateBuckets) CacheInfo() CacheInfo {
+	fetched := make([]time.Time, 0, len(b))
+	for _, bucket := range b {
+		fetched = append(fetched, bucket.FetchedAt)
+	}
+	open := len(b) > 0 && !b[len(b)-1].End.Before(Today())
+	return cacheInfoOf(fetched, open)
+}
+
+// Today is the current date in UTC, the time zone rates are dated in
+func Today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (c Currencies) CacheInfo() CacheInfo { return CacheInfo{} }
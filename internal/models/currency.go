@@ -15,13 +15,32 @@ type Currency struct {
 
 // ExchangeRate represents an exchange rate between two currencies
 type ExchangeRate struct {
-	BaseCurrency   string    `json:"base_currency"`
-	TargetCurrency string    `json:"target_currency"`
-	Rate           float64   `json:"rate"`
-	Provider       string    `json:"provider"`
-	FetchedAt      time.Time `json:"fetched_at"`
-	IsStale        bool      `json:"is_stale,omitempty"`
-	TTL            int64     `json:"ttl,omitempty"`
+	BaseCurrency   string       `json:"base_currency"`
+	TargetCurrency string       `json:"target_currency"`
+	Rate           float64      `json:"rate"`
+	Provider       string       `json:"provider"`
+	Author         string       `json:"author,omitempty"`
+	Sources        []RateSource `json:"sources,omitempty"`
+	FetchedAt      time.Time    `json:"fetched_at"`
+	IsStale        bool         `json:"is_stale,omitempty"`
+	TTL            int64        `json:"ttl,omitempty"`
+}
+
+// RateSource is one provider's contribution to a consensus rate
+type RateSource struct {
+	Provider string  `json:"provider"`
+	Rate     float64 `json:"rate"`
+	Rejected bool    `json:"rejected,omitempty"`
+}
+
+// RateTable holds every rate published for a base currency at one point in time
+type RateTable struct {
+	BaseCurrency string                  `json:"base_currency"`
+	Rates        map[string]float64      `json:"rates"`
+	Provider     string                  `json:"provider"`
+	Sources      map[string][]RateSource `json:"sources,omitempty"`
+	FetchedAt    time.Time               `json:"fetched_at"`
+	IsStale      bool                    `json:"is_stale,omitempty"`
 }
 
 // ConversionRequest represents a currency conversion request
@@ -34,13 +53,15 @@ type ConversionRequest struct {
 
 // ConversionResponse represents a currency conversion response
 type ConversionResponse struct {
-	FromCurrency    string    `json:"from_currency"`
-	ToCurrency      string    `json:"to_currency"`
-	Amount          float64   `json:"amount"`
-	ConvertedAmount float64   `json:"converted_amount"`
-	Rate            float64   `json:"rate"`
-	Provider        string    `json:"provider"`
-	FetchedAt       time.Time `json:"fetched_at"`
+	FromCurrency    string       `json:"from_currency"`
+	ToCurrency      string       `json:"to_currency"`
+	Amount          float64      `json:"amount"`
+	ConvertedAmount float64      `json:"converted_amount"`
+	Rate            float64      `json:"rate"`
+	Provider        string       `json:"provider"`
+	Author          string       `json:"author,omitempty"`
+	Sources         []RateSource `json:"sources,omitempty"`
+	FetchedAt       time.Time    `json:"fetched_at"`
 }
 
 // HistoricalRate represents a historical exchange rate
@@ -50,7 +71,12 @@ type HistoricalRate struct {
 	Rate           float64   `json:"rate"`
 	Date           time.Time `json:"date"`
 	Provider       string    `json:"provider"`
+	Author         string    `json:"author,omitempty"`
 	FetchedAt      time.Time `json:"fetched_at"`
+	// Point is set when a fill policy was requested: "actual" for a published
+	// rate, "filled" for a rate derived from the business days in FilledFrom
+	Point      string   `json:"point,omitempty"`
+	FilledFrom []string `json:"filled_from,omitempty"`
 }
 
 // ProviderResponse represents a response from an exchange rate provider
@@ -63,9 +89,12 @@ type ProviderResponse struct {
 	Raw     map[string]interface{} `json:"raw,omitempty"`
 }
 
-// OpenERAPIResponse represents the response from open.er-api.com
+// OpenERAPIResponse represents the response from open.er-api.com.
+// Paid tier (exchangerate-api.com) responses use conversion_rates instead of rates
+// and report failures through error-type.
 type OpenERAPIResponse struct {
 	Result             string             `json:"result"`
+	ErrorType          string             `json:"error-type,omitempty"`
 	Provider           string             `json:"provider"`
 	Documentation      string             `json:"documentation"`
 	TermsOfUse         string             `json:"terms_of_use"`
@@ -76,8 +105,19 @@ type OpenERAPIResponse struct {
 	TimeEOLUnix        int64              `json:"time_eol_unix"`
 	BaseCode           string             `json:"base_code"`
 	Rates              map[string]float64 `json:"rates"`
+	ConversionRates    map[string]float64 `json:"conversion_rates,omitempty"`
+	Year               int                `json:"year,omitempty"`
+	Month              int                `json:"month,omitempty"`
+	Day                int                `json:"day,omitempty"`
 }
 
+// Aggregated service health states
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
 	Status    string            `json:"status"`
@@ -0,0 +1,321 @@
+package models
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Response formats that can be negotiated with the Accept header or ?format=
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXML  = "xml"
+)
+
+// ErrNotEncodable is returned by an Encoder that can't represent a value, in
+// which case the response falls back to JSON
+var ErrNotEncodable = errors.New("value cannot be encoded in this format")
+
+// Encoder writes response bodies in one format
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+}
+
+// Tabular is implemented by responses that can be written as CSV
+type Tabular interface {
+	Table() (header []string, rows [][]string)
+}
+
+type registeredEncoder struct {
+	format     string
+	encoder    Encoder
+	mediaTypes []string
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   []registeredEncoder
+)
+
+func init() {
+	RegisterEncoder(FormatJSON, jsonEncoder{}, "application/json")
+	RegisterEncoder(FormatCSV, csvEncoder{}, "text/csv")
+	RegisterEncoder(FormatXML, xmlEncoder{}, "application/xml", "text/xml")
+}
+
+// RegisterEncoder makes a format available for negotiation under ?format=
+// and the given media types, replacing any encoder already registered for it.
+// Formats registered earlier win when an Accept wildcard matches several.
+func RegisterEncoder(format string, encoder Encoder, mediaTypes ...string) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	for i, e := range encoders {
+		if e.format == format {
+			encoders[i] = registeredEncoder{format, encoder, mediaTypes}
+			return
+		}
+	}
+	encoders = append(encoders, registeredEncoder{format, encoder, mediaTypes})
+}
+
+// EncoderFor returns the encoder registered for format
+func EncoderFor(format string) (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	for _, e := range encoders {
+		if e.format == format {
+			return e.encoder, true
+		}
+	}
+	return nil, false
+}
+
+// Formats lists the registered formats in registration order
+func Formats() []string {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	formats := make([]string, 0, len(encoders))
+	for _, e := range encoders {
+		formats = append(formats, e.format)
+	}
+	return formats
+}
+
+// NegotiateFormat picks the response format of a request: ?format= if given,
+// otherwise the most preferred registered media type in the Accept header.
+// Requests without a preference get JSON; ok is false when nothing the client
+// accepts is registered.
+func NegotiateFormat(r *http.Request) (format string, ok bool) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		_, ok := EncoderFor(format)
+		return format, ok
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, true
+	}
+
+	type ranged struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []ranged
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, ranged{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	for _, rng := range ranges {
+		if rng.mediaType == "*/*" {
+			return FormatJSON, true
+		}
+		for _, e := range encoders {
+			for _, mt := range e.mediaTypes {
+				if mt == rng.mediaType || (strings.HasSuffix(rng.mediaType, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(rng.mediaType, "*"))) {
+					return e.format, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// encodingWriter carries the negotiated encoder of a response to the Write
+// helpers
+type encodingWriter struct {
+	http.ResponseWriter
+	encoder Encoder
+}
+
+// WithFormat makes the Write helpers encode responses written to w in format.
+// Unknown formats leave w unchanged.
+func WithFormat(w http.ResponseWriter, format string) http.ResponseWriter {
+	encoder, ok := EncoderFor(format)
+	if !ok {
+		return w
+	}
+	w.Header().Add("Vary", "Accept")
+	return &encodingWriter{ResponseWriter: w, encoder: encoder}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (e *encodingWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+// encoderOf returns the encoder negotiated for w, or nil for plain JSON
+func encoderOf(w http.ResponseWriter) Encoder {
+	for {
+		switch ew := w.(type) {
+		case *encodingWriter:
+			return ew.encoder
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = ew.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// Write writes a response in the format negotiated for w, falling back to
+// JSON when none was negotiated or the encoder can't represent data. Caching
+// headers are added when w was wrapped by WithCaching.
+func Write(w http.ResponseWriter, statusCode int, data interface{}) {
+	if encoder := encoderOf(w); encoder != nil && data != nil {
+		if _, isJSON := encoder.(jsonEncoder); !isJSON {
+			var buf bytes.Buffer
+			if err := encoder.Encode(&buf, data); err == nil {
+				if c := cachingOf(w); c != nil && c.writeCacheHeaders(w, statusCode, data, encoder.ContentType()) {
+					return
+				}
+				w.Header().Set("Content-Type", encoder.ContentType())
+				w.WriteHeader(statusCode)
+				w.Write(buf.Bytes())
+				return
+			}
+		}
+	}
+	if c := cachingOf(w); c != nil && c.writeCacheHeaders(w, statusCode, data, "application/json") {
+		return
+	}
+	WriteJSON(w, statusCode, data)
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string { return "application/json" }
+
+func (jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// csvEncoder writes Tabular values, and the data of successful responses
+// holding one, as CSV with a header row
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (csvEncoder) Encode(w io.Writer, v interface{}) error {
+	if resp, ok := v.(*APIResponse); ok {
+		v = resp.Data
+	}
+	table, ok := v.(Tabular)
+	if !ok {
+		return ErrNotEncodable
+	}
+
+	header, rows := table.Table()
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// xmlEncoder writes any JSON-encodable value as XML with the same element
+// names as the JSON keys, under a <response> root. Array items are <item>
+// elements, and keys that aren't valid element names become
+// <entry key="...">.
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string { return "application/xml; charset=utf-8" }
+
+func (xmlEncoder) Encode(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	if err := jsonToXML(dec, enc, xml.StartElement{Name: xml.Name{Local: "response"}}); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// jsonToXML converts the next JSON value of dec into an element
+func jsonToXML(dec *json.Decoder, enc *xml.Encoder, start xml.StartElement) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		for dec.More() {
+			child := xml.StartElement{Name: xml.Name{Local: "item"}}
+			if t == '{' {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child = xmlElement(keyTok.(string))
+			}
+			if err := jsonToXML(dec, enc, child); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(t))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+func xmlElement(key string) xml.StartElement {
+	valid := key != ""
+	for i, c := range key {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || !(c == '-' || c == '.' || (c >= '0' && c <= '9'))) {
+			valid = false
+			break
+		}
+	}
+	if valid && !strings.HasPrefix(strings.ToLower(key), "xml") {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+// Conflict policies for importing history over rates that are already stored
+// with a different value
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictFail      = "fail"
+)
+
+// HistoryImportResult summarises an import into the history store. Stored
+// includes overwritten rates; rows equal to the stored rate count as
+// unchanged, not as conflicts, and Duplicates counts rows replaced by a later
+// row for the same pair and date. Conflicts lists at most the first 100
+// conflicting rows.
+type HistoryImportResult struct {
+	Policy      string            `json:"policy"`
+	Received    int               `json:"received"`
+	Stored      int               `json:"stored"`
+	Overwritten int               `json:"overwritten"`
+	Unchanged   int               `json:"unchanged"`
+	Skipped     int               `json:"skipped"`
+	Duplicates  int               `json:"duplicates,omitempty"`
+	Conflicts   []HistoryConflict `json:"conflicts,omitempty"`
+}
+
+// HistoryConflict is an imported rate that differs from the stored one
+type HistoryConflict struct {
+	Date           string  `json:"date"`
+	BaseCurrency   string  `json:"base_currency"`
+	TargetCurrency string  `json:"target_currency"`
+	StoredRate     float64 `json:"stored_rate"`
+	ImportedRate   float64 `json:"imported_rate"`
+}
@@ -66,16 +66,16 @@ func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	}
 }
 
-// WriteSuccess writes a successful JSON response
+// WriteSuccess writes a successful response in the negotiated format
 func WriteSuccess(w http.ResponseWriter, data interface{}, message string) {
 	response := SuccessResponse(data, message)
-	WriteJSON(w, http.StatusOK, response)
+	Write(w, http.StatusOK, response)
 }
 
-// WriteError writes an error JSON response
+// WriteError writes an error response in the negotiated format
 func WriteError(w http.ResponseWriter, statusCode int, err string, code string, details string) {
 	response := NewErrorResponse(err, code, details)
-	WriteJSON(w, statusCode, response)
+	Write(w, statusCode, response)
 }
 
 // WriteBadRequest writes a bad request error response
@@ -92,3 +92,18 @@ func WriteNotFound(w http.ResponseWriter, message string) {
 func WriteInternalError(w http.ResponseWriter, message string) {
 	WriteError(w, http.StatusInternalServerError, "Internal Server Error", "INTERNAL_ERROR", message)
 }
+
+// WriteUnauthorized writes an unauthorized error response
+func WriteUnauthorized(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED", message)
+}
+
+// WriteForbidden writes a forbidden error response
+func WriteForbidden(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusForbidden, "Forbidden", "FORBIDDEN", message)
+}
+
+// WriteConflict writes a conflict error response
+func WriteConflict(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusConflict, "Conflict", "CONFLICT", message)
+}
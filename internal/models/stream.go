@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// CurrencyPair identifies a base/target currency pair
+type CurrencyPair struct {
+	BaseCurrency   string `json:"base_currency"`
+	TargetCurrency string `json:"target_currency"`
+}
+
+// RateFilter selects the rates a subscriber receives: every rate of each
+// currency in Bases plus the individual Pairs
+type RateFilter struct {
+	Bases []string       `json:"bases,omitempty"`
+	Pairs []CurrencyPair `json:"pairs,omitempty"`
+}
+
+// RateUpdate is a change to the latest rates of a base currency, streamed to
+// subscribers. A snapshot carries every subscribed rate rather than only the
+// changed ones and is sent when a subscription starts or can't be resumed.
+type RateUpdate struct {
+	ID           uint64             `json:"id"`
+	BaseCurrency string             `json:"base_currency"`
+	Rates        map[string]float64 `json:"rates"`
+	Provider     string             `json:"provider"`
+	FetchedAt    time.Time          `json:"fetched_at"`
+	IsStale      bool               `json:"is_stale,omitempty"`
+	Snapshot     bool               `json:"snapshot,omitempty"`
+}
+
+// Stream message types exchanged over the rates WebSocket
+const (
+	StreamSubscribe    = "subscribe"
+	StreamUnsubscribe  = "unsubscribe"
+	StreamConvert      = "convert"
+	StreamSubscribed   = "subscribed"
+	StreamUnsubscribed = "unsubscribed"
+	StreamRates        = "rates"
+	StreamConversion   = "conversion"
+	StreamError        = "error"
+)
+
+// StreamRequest is a message sent by a WebSocket client: a subscribe or
+// unsubscribe carrying bases and pairs, or a convert carrying Conversion.
+// ID is echoed in the reply.
+type StreamRequest struct {
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type"`
+	RateFilter
+	Conversion *ConversionRequest `json:"conversion,omitempty"`
+}
+
+// StreamMessage is a message sent to a WebSocket client. Replies carry the
+// ID of the request they answer; rate updates have no ID.
+type StreamMessage struct {
+	ID    string      `json:"id,omitempty"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV column layouts of the tabular responses
+var (
+	rateColumns       = []string{"base_currency", "target_currency", "rate", "provider", "fetched_at", "is_stale"}
+	historicalColumns = []string{"date", "base_currency", "target_currency", "rate", "provider", "point", "filled_from"}
+	bucketColumns     = []string{"start", "end", "open", "high", "low", "close", "average", "count"}
+	statsColumns      = []string{"count", "min", "min_date", "max", "max_date", "mean", "median", "std_dev", "start", "end", "change", "change_percent", "volatility"}
+	currencyColumns   = []string{"code", "name", "symbol", "is_supported"}
+)
+
+// RateList is the latest rates of several currencies against one base
+type RateList struct {
+	BaseCurrency string          `json:"base_currency"`
+	Rates        []*ExchangeRate `json:"rates"`
+	Count        int             `json:"count"`
+}
+
+// HistoricalRates is a list of daily rates
+type HistoricalRates []*HistoricalRate
+
+// RateBuckets is a list of OHLC buckets
+type RateBuckets []*RateBucket
+
+// Currencies is a list of currencies
+type Currencies []*Currency
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (r *ExchangeRate) row() []string {
+	return []string{r.BaseCurrency, r.TargetCurrency, formatFloat(r.Rate), r.Provider, formatTime(r.FetchedAt), strconv.FormatBool(r.IsStale)}
+}
+
+func (r *ExchangeRate) Table() ([]string, [][]string) {
+	return rateColumns, [][]string{r.row()}
+}
+
+func (l *RateList) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(l.Rates))
+	for _, rate := range l.Rates {
+		rows = append(rows, rate.row())
+	}
+	return rateColumns, rows
+}
+
+// Table lists the rates by target currency
+func (t *RateTable) Table() ([]string, [][]string) {
+	targets := make([]string, 0, len(t.Rates))
+	for target := range t.Rates {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	rows := make([][]string, 0, len(targets))
+	for _, target := range targets {
+		rows = append(rows, []string{t.BaseCurrency, target, formatFloat(t.Rates[target]), t.Provider, formatTime(t.FetchedAt), strconv.FormatBool(t.IsStale)})
+	}
+	return rateColumns, rows
+}
+
+func (r *HistoricalRate) row() []string {
+	return []string{r.Date.Format("2006-01-02"), r.BaseCurrency, r.TargetCurrency, formatFloat(r.Rate), r.Provider, r.Point, strings.Join(r.FilledFrom, " ")}
+}
+
+func (r *HistoricalRate) Table() ([]string, [][]string) {
+	return historicalColumns, [][]string{r.row()}
+}
+
+func (h HistoricalRates) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(h))
+	for _, rate := range h {
+		rows = append(rows, rate.row())
+	}
+	return historicalColumns, rows
+}
+
+func (s *TimeSeries) Table() ([]string, [][]string) {
+	return HistoricalRates(s.Rates).Table()
+}
+
+func (b RateBuckets) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(b))
+	for _, bucket := range b {
+		rows = append(rows, []string{
+			bucket.Start.Format("2006-01-02"), bucket.End.Format("2006-01-02"),
+			formatFloat(bucket.Open), formatFloat(bucket.High), formatFloat(bucket.Low), formatFloat(bucket.Close),
+			formatFloat(bucket.Average), strconv.Itoa(bucket.Count),
+		})
+	}
+	return bucketColumns, rows
+}
+
+func (s *TimeSeriesStats) Table() ([]string, [][]string) {
+	return statsColumns, [][]string{{
+		strconv.Itoa(s.Count),
+		formatFloat(s.Min), s.MinDate.Format("2006-01-02"),
+		formatFloat(s.Max), s.MaxDate.Format("2006-01-02"),
+		formatFloat(s.Mean), formatFloat(s.Median), formatFloat(s.StdDev),
+		formatFloat(s.Start), formatFloat(s.End), formatFloat(s.Change), formatFloat(s.ChangePercent),
+		formatFloat(s.Volatility),
+	}}
+}
+
+func (c Currencies) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(c))
+	for _, currency := range c {
+		rows = append(rows, []string{currency.Code, currency.Name, currency.Symbol, strconv.FormatBool(currency.IsSupported)})
+	}
+	return currencyColumns, rows
+}
+
+func (e *ErrorResponse) Table() ([]string, [][]string) {
+	return []string{"error", "code", "details"}, [][]string{{e.Error, e.Code, e.Details}}
+}
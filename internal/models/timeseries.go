@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// Time series statistics modes selected with the stats query parameter
+const (
+	StatsNone    = ""
+	StatsInclude = "true"
+	StatsOnly    = "only"
+)
+
+// TimeSeriesStats summarizes the rates of a time series. StdDev and
+// Volatility are sample standard deviations; Volatility is taken over the log
+// returns between consecutive points, i.e. daily for a daily series.
+type TimeSeriesStats struct {
+	Count         int       `json:"count"`
+	Min           float64   `json:"min"`
+	MinDate       time.Time `json:"min_date"`
+	Max           float64   `json:"max"`
+	MaxDate       time.Time `json:"max_date"`
+	Mean          float64   `json:"mean"`
+	Median        float64   `json:"median"`
+	StdDev        float64   `json:"std_dev"`
+	Start         float64   `json:"start"`
+	End           float64   `json:"end"`
+	Change        float64   `json:"change"`
+	ChangePercent float64   `json:"change_percent"`
+	Volatility    float64   `json:"volatility"`
+}
+
+// Time series granularities selected with the granularity query parameter.
+// Anything coarser than a day is returned as buckets rather than points.
+const (
+	GranularityDay     = "day"
+	GranularityWeek    = "week"
+	GranularityMonth   = "month"
+	GranularityQuarter = "quarter"
+	GranularityYear    = "year"
+)
+
+// RateBucket aggregates the daily rates of one calendar period. Start and End
+// bound the period (weeks start on Monday); Count is how many daily rates
+// fell inside it, and FetchedAt when the newest of them was fetched.
+type RateBucket struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Open    float64   `json:"open"`
+	High    float64   `json:"high"`
+	Low     float64   `json:"low"`
+	Close   float64   `json:"close"`
+	Average float64   `json:"average"`
+	Count   int       `json:"count"`
+
+	FetchedAt time.Time `json:"-"`
+}
+
+// TimeSeries holds the daily rates of a date range. Dates whose rate could not
+// be retrieved are listed in Missing with the reason instead of failing the
+// whole series.
+type TimeSeries struct {
+	BaseCurrency   string            `json:"base_currency"`
+	TargetCurrency string            `json:"target_currency"`
+	StartDate      string            `json:"start_date"`
+	EndDate        string            `json:"end_date"`
+	Rates          []*HistoricalRate `json:"rates"`
+	Missing        []MissingRate     `json:"missing,omitempty"`
+}
+
+// MissingRate reports a date left out of a time series
+type MissingRate struct {
+	Date   string `json:"date"`
+	Reason string `json:"reason"`
+}
+
+// Fill policies for dates without a published rate: take the previous or next
+// business day's rate, interpolate linearly between them, or leave the gap.
+const (
+	FillNone     = "none"
+	FillPrevious = "previous"
+	FillNext     = "next"
+	FillLinear   = "linear"
+)
+
+// Point kinds reported on historical rates returned with a fill policy
+const (
+	PointActual = "actual"
+	PointFilled = "filled"
+)
+
+// FillOptions selects how dates that are not business days in Calendar are
+// answered. Empty fields fall back to the configured defaults.
+type FillOptions struct {
+	Policy   string `json:"fill,omitempty"`
+	Calendar string `json:"calendar,omitempty"`
+}
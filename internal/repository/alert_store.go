@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrAlertNotFound is returned when no alert exists with the given ID
+var ErrAlertNotFound = errors.New("alert not found")
+
+// AlertStore persists rate alerts and the log of their webhook deliveries
+type AlertStore interface {
+	Get(ctx context.Context, id string) (*models.RateAlert, error)
+	List(ctx context.Context) ([]*models.RateAlert, error)
+	Put(ctx context.Context, alert *models.RateAlert) error
+	Delete(ctx context.Context, id string) error
+	// AddDelivery records a delivery, keeping the newest keep deliveries of the alert
+	AddDelivery(ctx context.Context, delivery *models.AlertDelivery, keep int) error
+	// ListDeliveries returns the deliveries of an alert, newest first
+	ListDeliveries(ctx context.Context, alertID string) ([]*models.AlertDelivery, error)
+}
+
+// NewAlertStore stores alerts in Redis, or in config.Alerts.StoreFile when
+// Redis is unavailable
+func NewAlertStore(config *configs.Config, logger log.Logger) AlertStore {
+	redisCache, err := NewRedisCache(config.Redis.Addr, config.Redis.Password, config.Redis.DB)
+	if err == nil {
+		return NewRedisAlertStore(redisCache.client)
+	}
+	level.Warn(logger).Log("error", err, "msg", "failed to initialize Redis alert store, using file")
+
+	fileStore, err := NewFileAlertStore(config.Alerts.StoreFile)
+	if err != nil {
+		level.Error(logger).Log("error", err, "msg", "failed to load alerts file, starting with no alerts")
+		fileStore = &FileAlertStore{
+			path:       config.Alerts.StoreFile,
+			alerts:     make(map[string]*models.RateAlert),
+			deliveries: make(map[string][]*models.AlertDelivery),
+		}
+	}
+	return fileStore
+}
+
+func sortAlerts(alerts []*models.RateAlert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.Before(alerts[j].CreatedAt)
+		}
+		return alerts[i].ID < alerts[j].ID
+	})
+}
+
+// RedisAlertStore keeps alerts in a Redis hash and each alert's deliveries in
+// a capped list, so every replica sees them
+type RedisAlertStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisAlertStore creates an alert store backed by the given Redis client
+func NewRedisAlertStore(client *redis.Client) *RedisAlertStore {
+	return &RedisAlertStore{client: client, key: "alerts"}
+}
+
+func (s *RedisAlertStore) deliveriesKey(alertID string) string {
+	return "alert_deliveries:" + alertID
+}
+
+func (s *RedisAlertStore) Get(ctx context.Context, id string) (*models.RateAlert, error) {
+	val, err := s.client.HGet(ctx, s.key, id).Result()
+	if err == redis.Nil {
+		return nil, ErrAlertNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var alert models.RateAlert
+	if err := json.Unmarshal([]byte(val), &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (s *RedisAlertStore) List(ctx context.Context) ([]*models.RateAlert, error) {
+	vals, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]*models.RateAlert, 0, len(vals))
+	for _, val := range vals {
+		var alert models.RateAlert
+		if err := json.Unmarshal([]byte(val), &alert); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &alert)
+	}
+	sortAlerts(alerts)
+	return alerts, nil
+}
+
+func (s *RedisAlertStore) Put(ctx context.Context, alert *models.RateAlert) error {
+	jsonData, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.key, alert.ID, jsonData).Err()
+}
+
+func (s *RedisAlertStore) Delete(ctx context.Context, id string) error {
+	removed, err := s.client.HDel(ctx, s.key, id).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrAlertNotFound
+	}
+	return s.client.Del(ctx, s.deliveriesKey(id)).Err()
+}
+
+func (s *RedisAlertStore) AddDelivery(ctx context.Context, delivery *models.AlertDelivery, keep int) error {
+	jsonData, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	key := s.deliveriesKey(delivery.AlertID)
+	pipe := s.client.TxPipeline()
+	pipe.LPush(ctx, key, jsonData)
+	pipe.LTrim(ctx, key, 0, int64(keep-1))
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisAlertStore) ListDeliveries(ctx context.Context, alertID string) ([]*models.AlertDelivery, error) {
+	vals, err := s.client.LRange(ctx, s.deliveriesKey(alertID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*models.AlertDelivery, 0, len(vals))
+	for _, val := range vals {
+		var delivery models.AlertDelivery
+		if err := json.Unmarshal([]byte(val), &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
+
+// FileAlertStore keeps alerts and their deliveries in a local JSON file. It is
+// used when Redis is unavailable so alerts still survive restarts.
+type FileAlertStore struct {
+	path string
+
+	mu         sync.RWMutex
+	alerts     map[string]*models.RateAlert
+	deliveries map[string][]*models.AlertDelivery
+}
+
+// alertsFile is the layout of the alerts file
+type alertsFile struct {
+	Alerts     []*models.RateAlert                `json:"alerts"`
+	Deliveries map[string][]*models.AlertDelivery `json:"deliveries,omitempty"`
+}
+
+// NewFileAlertStore loads the alerts stored at path, if any
+func NewFileAlertStore(path string) (*FileAlertStore, error) {
+	store := &FileAlertStore{
+		path:       path,
+		alerts:     make(map[string]*models.RateAlert),
+		deliveries: make(map[string][]*models.AlertDelivery),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alerts file: %w", err)
+	}
+
+	var file alertsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse alerts file: %w", err)
+	}
+	for _, alert := range file.Alerts {
+		store.alerts[alert.ID] = alert
+	}
+	for alertID, deliveries := range file.Deliveries {
+		store.deliveries[alertID] = deliveries
+	}
+	return store, nil
+}
+
+func (s *FileAlertStore) Get(ctx context.Context, id string) (*models.RateAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alert, exists := s.alerts[id]
+	if !exists {
+		return nil, ErrAlertNotFound
+	}
+	copied := *alert
+	return &copied, nil
+}
+
+func (s *FileAlertStore) List(ctx context.Context) ([]*models.RateAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot(), nil
+}
+
+func (s *FileAlertStore) Put(ctx context.Context, alert *models.RateAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.alerts[alert.ID]
+	copied := *alert
+	s.alerts[alert.ID] = &copied
+
+	if err := s.save(); err != nil {
+		if existed {
+			s.alerts[alert.ID] = previous
+		} else {
+			delete(s.alerts, alert.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileAlertStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.alerts[id]
+	if !exists {
+		return ErrAlertNotFound
+	}
+	deliveries := s.deliveries[id]
+	delete(s.alerts, id)
+	delete(s.deliveries, id)
+
+	if err := s.save(); err != nil {
+		s.alerts[id] = previous
+		s.deliveries[id] = deliveries
+		return err
+	}
+	return nil
+}
+
+func (s *FileAlertStore) AddDelivery(ctx context.Context, delivery *models.AlertDelivery, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.deliveries[delivery.AlertID]
+	copied := *delivery
+	deliveries := append([]*models.AlertDelivery{&copied}, previous...)
+	if len(deliveries) > keep {
+		deliveries = deliveries[:keep]
+	}
+	s.deliveries[delivery.AlertID] = deliveries
+
+	if err := s.save(); err != nil {
+		s.deliveries[delivery.AlertID] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *FileAlertStore) ListDeliveries(ctx context.Context, alertID string) ([]*models.AlertDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := make([]*models.AlertDelivery, 0, len(s.deliveries[alertID]))
+	for _, delivery := range s.deliveries[alertID] {
+		copied := *delivery
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+// snapshot returns copies of all alerts in creation order; callers hold mu
+func (s *FileAlertStore) snapshot() []*models.RateAlert {
+	alerts := make([]*models.RateAlert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		copied := *alert
+		alerts = append(alerts, &copied)
+	}
+	sortAlerts(alerts)
+	return alerts
+}
+
+// save writes the alerts file atomically; callers hold mu
+func (s *FileAlertStore) save() error {
+	data, err := json.MarshalIndent(alertsFile{Alerts: s.snapshot(), Deliveries: s.deliveries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write alerts file: %w", err)
+	}
+	return nil
+}
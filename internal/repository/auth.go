@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Supported places to send a provider API key
+const (
+	APIKeyInPath   = "path"
+	APIKeyInHeader = "header"
+)
+
+// redactedKey replaces API keys wherever they would otherwise be exposed
+const redactedKey = "[REDACTED]"
+
+// endpoint builds a provider URL from path segments, injecting the API key as
+// the first segment when the key is sent in the path
+func (c *OpenERAPIClient) endpoint(segments ...string) string {
+	parts := make([]string, 0, len(segments)+2)
+	parts = append(parts, c.baseURL)
+	if c.apiKey != "" && c.apiKeyLocation == APIKeyInPath {
+		parts = append(parts, url.PathEscape(c.apiKey))
+	}
+	parts = append(parts, segments...)
+	return strings.Join(parts, "/")
+}
+
+// authorize adds the API key header when the key is sent in a header
+func (c *OpenERAPIClient) authorize(req *http.Request) {
+	if c.apiKey == "" || c.apiKeyLocation != APIKeyInHeader {
+		return
+	}
+	if strings.EqualFold(c.apiKeyHeader, "Authorization") {
+		req.Header.Set(c.apiKeyHeader, "Bearer "+c.apiKey)
+		return
+	}
+	req.Header.Set(c.apiKeyHeader, c.apiKey)
+}
+
+// redact strips the API key from s
+func (c *OpenERAPIClient) redact(s string) string {
+	if c.apiKey == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, c.apiKey, redactedKey)
+	return strings.ReplaceAll(s, url.PathEscape(c.apiKey), redactedKey)
+}
+
+// redactError strips the API key from the URL carried by transport errors so
+// it never reaches logs or API responses
+func (c *OpenERAPIClient) redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = c.redact(urlErr.URL)
+	}
+	return err
+}
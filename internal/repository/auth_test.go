@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+
+	"github.com/go-kit/log"
+)
+
+func TestOpenERAPIClientSendsAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		location   string
+		header     string
+		wantPath   string
+		wantHeader string
+		wantValue  string
+	}{
+		{"path", APIKeyInPath, "", "/paid/k3y/latest/USD", "Authorization", ""},
+		{"bearer header", APIKeyInHeader, "", "/paid/latest/USD", "Authorization", "Bearer k3y"},
+		{"custom header", APIKeyInHeader, "X-Api-Key", "/paid/latest/USD", "X-Api-Key", "k3y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotValue string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotValue = r.URL.Path, r.Header.Get(tt.wantHeader)
+				w.Write([]byte(`{"result":"success","conversion_rates":{"EUR":0.9}}`))
+			}))
+			defer server.Close()
+
+			client := NewOpenERAPIClient(configs.ProviderConfig{
+				Name:           "test",
+				BaseURL:        server.URL + "/free",
+				PaidBaseURL:    server.URL + "/paid",
+				APIKey:         "k3y",
+				APIKeyLocation: tt.location,
+				APIKeyHeader:   tt.header,
+			}, log.NewNopLogger(), NopMetrics())
+
+			table, err := client.GetLatestRates(context.Background(), "USD")
+			if err != nil {
+				t.Fatalf("GetLatestRates: %v", err)
+			}
+			if table.Rates["EUR"] != 0.9 {
+				t.Errorf("rates = %v, want the paid tier conversion_rates", table.Rates)
+			}
+			if gotPath != tt.wantPath || gotValue != tt.wantValue {
+				t.Errorf("request to %q with %s %q, want %q with %q", gotPath, tt.wantHeader, gotValue, tt.wantPath, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestOpenERAPIClientRedactsAPIKey(t *testing.T) {
+	// A key that changes when escaped into the path
+	const key = "s3cret key"
+	server := httptest.NewServer(http.NotFoundHandler())
+	baseURL := server.URL
+	server.Close()
+
+	var logs bytes.Buffer
+	client := NewOpenERAPIClient(configs.ProviderConfig{
+		Name:        "test",
+		BaseURL:     baseURL,
+		PaidBaseURL: baseURL,
+		APIKey:      key,
+		Retry:       configs.RetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	}, log.NewLogfmtLogger(&logs), NopMetrics())
+
+	_, err := client.GetLatestRates(context.Background(), "USD")
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	for name, s := range map[string]string{"error": err.Error(), "logs": logs.String()} {
+		if strings.Contains(s, "s3cret") {
+			t.Errorf("%s expose the API key: %s", name, s)
+		}
+		if !strings.Contains(s, redactedKey) {
+			t.Errorf("%s lack the redaction marker: %s", name, s)
+		}
+	}
+
+	if err := client.HealthCheck(context.Background()); err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("health check error = %v, want a redacted failure", err)
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+)
+
+// managedKeyPatterns covers every cache key the repository writes. Overrides
+// are kept outside the cache and are never touched by invalidation.
+var managedKeyPatterns = []string{"rate:*", "rates:*", "quarantine:*", "currencies:*"}
+
+// managedKey reports whether key is one the repository caches. Redis also
+// holds overrides and history, which are not cache entries.
+func managedKey(key string) bool {
+	for _, pattern := range managedKeyPatterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ListCacheKeys returns the cached keys matching a glob pattern
+func (r *rateRepository) ListCacheKeys(ctx context.Context, pattern string) ([]string, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	keys, err := r.cache.Keys(ctx, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache keys: %w", err)
+	}
+
+	cached := keys[:0]
+	for _, key := range keys {
+		if managedKey(key) {
+			cached = append(cached, key)
+		}
+	}
+	return cached, nil
+}
+
+// GetCacheEntry returns a cached value together with its remaining TTL. Keys
+// outside the cache are reported as missing.
+func (r *rateRepository) GetCacheEntry(ctx context.Context, key string) (*models.CacheEntry, error) {
+	if !managedKey(key) {
+		return nil, ErrCacheMiss
+	}
+	ttl, err := r.cache.TTL(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var value json.RawMessage
+	if err := r.cache.Get(ctx, key, &value); err != nil {
+		return nil, err
+	}
+
+	entry := &models.CacheEntry{Key: key, Value: value, TTLSeconds: -1}
+	if ttl >= 0 {
+		expiresAt := time.Now().Add(ttl).UTC()
+		entry.TTLSeconds = int64(ttl.Round(time.Second) / time.Second)
+		entry.ExpiresAt = &expiresAt
+	}
+	return entry, nil
+}
+
+// InvalidateCache deletes cached data for a pair, a base currency or, when
+// both currencies are empty, everything the repository has cached. Dropping a
+// base also drops its validation baseline and quarantine, so the next table
+// fetched for it is accepted as is.
+func (r *rateRepository) InvalidateCache(ctx context.Context, baseCurrency, targetCurrency string) (*models.CacheInvalidation, error) {
+	var scope string
+	var patterns []string
+	switch {
+	case baseCurrency != "" && targetCurrency != "":
+		scope = "pair"
+		patterns = []string{fmt.Sprintf("rate:%s:%s:*", baseCurrency, targetCurrency), latestTableKey(baseCurrency)}
+	case baseCurrency != "":
+		scope = "base"
+		patterns = []string{fmt.Sprintf("rate:%s:*", baseCurrency), fmt.Sprintf("rates:%s:*", baseCurrency), quarantineTableKey(baseCurrency)}
+	default:
+		scope = "all"
+		patterns = managedKeyPatterns
+	}
+
+	seen := make(map[string]bool)
+	deleted := []string{}
+	for _, pattern := range patterns {
+		keys, err := r.cache.Keys(ctx, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cache keys: %w", err)
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				deleted = append(deleted, key)
+			}
+		}
+	}
+
+	if err := r.cache.Delete(ctx, deleted...); err != nil {
+		return nil, fmt.Errorf("failed to delete cache keys: %w", err)
+	}
+	if scope != "pair" {
+		r.mu.Lock()
+		for base := range r.quarantined {
+			if scope == "all" || base == baseCurrency {
+				delete(r.quarantined, base)
+			}
+		}
+		r.mu.Unlock()
+	}
+
+	level.Info(r.logger).Log("msg", "cache invalidated", "scope", scope, "base", baseCurrency, "target", targetCurrency,
+		"keys", strings.Join(deleted, ","))
+	return &models.CacheInvalidation{Scope: scope, DeletedKeys: deleted, Count: len(deleted)}, nil
+}
+
+// RefreshRates drops the cached table for a base currency and fetches a new one
+func (r *rateRepository) RefreshRates(ctx context.Context, baseCurrency string) (*models.RateTable, error) {
+	if err := r.cache.Delete(ctx, latestTableKey(baseCurrency)); err != nil {
+		return nil, fmt.Errorf("failed to drop cached rates: %w", err)
+	}
+	level.Info(r.logger).Log("msg", "forced rate refresh", "base", baseCurrency)
+	return r.GetLatestRates(ctx, baseCurrency)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+)
+
+func TestCacheAdminOnlyExposesCacheKeys(t *testing.T) {
+	r := newTestRepository(t, configs.Default())
+	ctx := context.Background()
+	for _, key := range []string{"rates:USD:latest", "rate:USD:EUR:2024-01-02", "currencies:supported", "overrides", "history:USD:EUR", "alerts"} {
+		r.cache.Set(ctx, key, map[string]string{"k": key}, time.Hour)
+	}
+
+	keys, err := r.ListCacheKeys(ctx, "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if got, want := strings.Join(keys, ","), "currencies:supported,rate:USD:EUR:2024-01-02,rates:USD:latest"; got != want {
+		t.Errorf("listed keys %s, want %s", got, want)
+	}
+
+	if _, err := r.GetCacheEntry(ctx, "overrides"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("GetCacheEntry(overrides) = %v, want ErrCacheMiss", err)
+	}
+	if entry, err := r.GetCacheEntry(ctx, "rates:USD:latest"); err != nil || entry.TTLSeconds <= 0 {
+		t.Errorf("GetCacheEntry(rates:USD:latest) = %+v, %v", entry, err)
+	}
+}
+
+func TestInvalidateCacheScopes(t *testing.T) {
+	r := newTestRepository(t, configs.Default())
+	ctx := context.Background()
+	keys := []string{"rates:USD:latest", "rates:USD:baseline", "rate:USD:EUR:2024-01-02", "rate:USD:GBP:2024-01-02", "rates:EUR:latest", "overrides"}
+	reset := func() {
+		for _, key := range keys {
+			r.cache.Set(ctx, key, &models.RateTable{}, time.Hour)
+		}
+	}
+
+	tests := []struct {
+		base, target string
+		scope        string
+		deleted      string
+	}{
+		{"USD", "EUR", "pair", "rate:USD:EUR:2024-01-02,rates:USD:latest"},
+		{"USD", "", "base", "rate:USD:EUR:2024-01-02,rate:USD:GBP:2024-01-02,rates:USD:baseline,rates:USD:latest"},
+		{"", "", "all", "rate:USD:EUR:2024-01-02,rate:USD:GBP:2024-01-02,rates:EUR:latest,rates:USD:baseline,rates:USD:latest"},
+	}
+	for _, tt := range tests {
+		reset()
+		result, err := r.InvalidateCache(ctx, tt.base, tt.target)
+		if err != nil {
+			t.Fatalf("InvalidateCache(%q, %q): %v", tt.base, tt.target, err)
+		}
+		sort.Strings(result.DeletedKeys)
+		if result.Scope != tt.scope || strings.Join(result.DeletedKeys, ",") != tt.deleted {
+			t.Errorf("InvalidateCache(%q, %q) = %s %v, want %s %s", tt.base, tt.target, result.Scope, result.DeletedKeys, tt.scope, tt.deleted)
+		}
+		if exists, _ := r.cache.Exists(ctx, "overrides"); !exists {
+			t.Errorf("InvalidateCache(%q, %q) removed a non-cache key", tt.base, tt.target)
+		}
+	}
+}
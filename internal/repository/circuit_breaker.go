@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"exchange-rate-service/configs"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// ErrCircuitOpen is returned when a provider call is rejected by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState represents the state of a circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// String returns the state name used in logs and health reports
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker fails provider calls fast once a provider keeps failing
+type CircuitBreaker struct {
+	name             string
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenRequests int
+	logger           log.Logger
+	now              func() time.Time
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	inFlight  int
+	successes int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker for the named provider
+func NewCircuitBreaker(name string, config configs.CircuitBreakerConfig, logger log.Logger) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: config.FailureThreshold,
+		openTimeout:      config.OpenTimeout,
+		halfOpenRequests: config.HalfOpenRequests,
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// State returns the current breaker state
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// Do runs fn if the breaker allows it and records the outcome
+func (b *CircuitBreaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case err == nil || !isProviderFailure(err):
+		b.onSuccess()
+	case errors.Is(ctx.Err(), context.Canceled):
+		// The caller went away; that says nothing about the provider
+		b.release()
+	default:
+		b.onFailure()
+	}
+
+	return err
+}
+
+// allow reports whether a call may proceed, reserving a trial slot when half-open
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.inFlight >= b.halfOpenRequests {
+			return ErrCircuitOpen
+		}
+		b.inFlight++
+	}
+	return nil
+}
+
+// advance moves an open breaker to half-open once the open timeout has elapsed
+func (b *CircuitBreaker) advance() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		b.setState(CircuitHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) onSuccess() {
+	switch b.state {
+	case CircuitClosed:
+		b.failures = 0
+	case CircuitHalfOpen:
+		b.inFlight--
+		b.successes++
+		if b.successes >= b.halfOpenRequests {
+			b.setState(CircuitClosed)
+		}
+	}
+}
+
+func (b *CircuitBreaker) onFailure() {
+	switch b.state {
+	case CircuitClosed:
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.setState(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		b.setState(CircuitOpen)
+	}
+}
+
+func (b *CircuitBreaker) release() {
+	if b.state == CircuitHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+}
+
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	level.Warn(b.logger).Log("msg", "circuit breaker state changed", "provider", b.name, "from", b.state, "to", state)
+
+	b.state = state
+	b.failures = 0
+	b.inFlight = 0
+	b.successes = 0
+	if state == CircuitOpen {
+		b.openedAt = b.now()
+	}
+}
+
+// isProviderFailure reports whether err means the provider itself misbehaved,
+// as opposed to answering that it has no data for the request or answering
+// with data that failed validation
+func isProviderFailure(err error) bool {
+	return !errors.Is(err, ErrRateNotFound) && !errors.Is(err, ErrNotSupported) && !errors.Is(err, ErrRateQuarantined)
+}
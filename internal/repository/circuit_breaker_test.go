@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log"
+)
+
+var errProviderDown = errors.New("provider down")
+
+func newTestBreaker(now *time.Time) *CircuitBreaker {
+	b := NewCircuitBreaker("test", configs.CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: 10 * time.Second, HalfOpenRequests: 2}, log.NewNopLogger())
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func fail(ctx context.Context) error    { return errProviderDown }
+func succeed(ctx context.Context) error { return nil }
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(&now)
+	ctx := context.Background()
+
+	// Answers that the provider has no data are not failures
+	b.Do(ctx, func(ctx context.Context) error { return ErrRateNotFound })
+	b.Do(ctx, fail)
+	if b.State() != CircuitClosed {
+		t.Fatalf("state after one failure = %s, want closed", b.State())
+	}
+	b.Do(ctx, succeed)
+	b.Do(ctx, fail)
+	if b.State() != CircuitClosed {
+		t.Fatalf("a success should reset the failure count, state = %s", b.State())
+	}
+
+	b.Do(ctx, fail)
+	if b.State() != CircuitOpen {
+		t.Fatalf("state after %d consecutive failures = %s, want open", 2, b.State())
+	}
+	called := false
+	if err := b.Do(ctx, func(ctx context.Context) error { called = true; return nil }); !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("open breaker returned %v and called = %v, want ErrCircuitOpen without a call", err, called)
+	}
+
+	// Once the timeout passes, a failed trial opens the circuit again
+	now = now.Add(10 * time.Second)
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("state after open timeout = %s, want half-open", b.State())
+	}
+	b.Do(ctx, fail)
+	if b.State() != CircuitOpen {
+		t.Fatalf("state after failed trial = %s, want open", b.State())
+	}
+
+	// Enough successful trials close it
+	now = now.Add(10 * time.Second)
+	b.Do(ctx, succeed)
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("state after one of two trials = %s, want half-open", b.State())
+	}
+	b.Do(ctx, succeed)
+	if b.State() != CircuitClosed {
+		t.Fatalf("state after successful trials = %s, want closed", b.State())
+	}
+}
+
+func TestCircuitBreakerLimitsHalfOpenTrials(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(&now)
+	ctx := context.Background()
+	b.Do(ctx, fail)
+	b.Do(ctx, fail)
+	now = now.Add(10 * time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- b.Do(ctx, func(ctx context.Context) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+	}
+	<-started
+	<-started
+
+	if err := b.Do(ctx, succeed); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("third concurrent trial returned %v, want ErrCircuitOpen", err)
+	}
+	close(release)
+	<-done
+	<-done
+	if b.State() != CircuitClosed {
+		t.Errorf("state after trials = %s, want closed", b.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledCalls(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(&now)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 3; i++ {
+		b.Do(ctx, func(ctx context.Context) error { return ctx.Err() })
+	}
+	if b.State() != CircuitClosed {
+		t.Errorf("state after canceled calls = %s, want closed", b.State())
+	}
+}
+
+func TestCallProvidersFailsOver(t *testing.T) {
+	config := configs.Default()
+	primary := &fakeProvider{name: "primary", err: errProviderDown}
+	secondary := &fakeProvider{name: "secondary", rates: map[string]float64{"EUR": 0.9}}
+	r := newTestRepository(t, config, primary, secondary)
+
+	getLatest := func(ctx context.Context, client ProviderClient) (*models.RateTable, error) {
+		return client.GetLatestRates(ctx, "USD")
+	}
+	for i := 0; i < 3; i++ {
+		table, err := callProviders(context.Background(), r, getLatest)
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if table.Provider != "secondary" {
+			t.Errorf("call %d served by %s, want secondary", i, table.Provider)
+		}
+	}
+
+	// The primary's breaker opened after two failures, so the third call skipped it
+	if got := primary.calls.Load(); got != 2 {
+		t.Errorf("primary called %d times, want 2", got)
+	}
+	if state := r.providerList()[0].breaker.State(); state != CircuitOpen {
+		t.Errorf("primary breaker = %s, want open", state)
+	}
+
+	secondary.err = errProviderDown
+	_, err := callProviders(context.Background(), r, getLatest)
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, errProviderDown) {
+		t.Errorf("error with every provider down = %v, want both failures joined", err)
+	}
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+)
+
+// Aggregation modes for combining provider rates
+const (
+	AggregationFailover  = "failover"
+	AggregationConsensus = "consensus"
+)
+
+// ConsensusProvider is reported as the provider of a consensus rate
+const ConsensusProvider = "consensus"
+
+// ErrNoConsensus is returned when too few providers agree on a rate
+var ErrNoConsensus = errors.New("no consensus between providers")
+
+// consensusLatestRates queries every provider whose circuit is not open
+// concurrently and builds a table in which each rate is the average of the
+// provider values within the configured deviation from their median. Rates
+// with fewer than MinProviders agreeing values are left out.
+func (r *rateRepository) consensusLatestRates(ctx context.Context, baseCurrency string) (*models.RateTable, error) {
+	minProviders := r.cfg().Aggregation.MinProviders
+	if minProviders <= 0 {
+		minProviders = 1
+	}
+
+	var healthy []*provider
+	for _, p := range r.providerList() {
+		if p.breaker.State() != CircuitOpen {
+			healthy = append(healthy, p)
+		}
+	}
+	if len(healthy) < minProviders {
+		return nil, fmt.Errorf("%w: %d healthy providers, %d required", ErrNoConsensus, len(healthy), minProviders)
+	}
+
+	tables := make([]*models.RateTable, len(healthy))
+	errs := make([]error, len(healthy))
+	var wg sync.WaitGroup
+	for i, p := range healthy {
+		wg.Add(1)
+		go func(i int, p *provider) {
+			defer wg.Done()
+			errs[i] = p.breaker.Do(ctx, func(ctx context.Context) error {
+				var err error
+				tables[i], err = p.client.GetLatestRates(ctx, baseCurrency)
+				return err
+			})
+		}(i, p)
+	}
+	wg.Wait()
+
+	var answered []*models.RateTable
+	for i, p := range healthy {
+		if errs[i] != nil {
+			level.Error(r.logger).Log("error", errs[i], "msg", "provider excluded from consensus", "provider", p.client.Name())
+			continue
+		}
+		r.sanitizeTable(tables[i])
+		answered = append(answered, tables[i])
+	}
+	if len(answered) < minProviders {
+		return nil, fmt.Errorf("%w: %d providers answered, %d required", ErrNoConsensus, len(answered), minProviders)
+	}
+
+	consensus := &models.RateTable{
+		BaseCurrency: baseCurrency,
+		Rates:        make(map[string]float64),
+		Provider:     ConsensusProvider,
+		Sources:      make(map[string][]models.RateSource),
+		FetchedAt:    time.Now(),
+	}
+	for currency := range unionOfRates(answered) {
+		var sources []models.RateSource
+		var values []float64
+		for _, table := range answered {
+			if rate, ok := table.Rates[currency]; ok {
+				sources = append(sources, models.RateSource{Provider: table.Provider, Rate: rate})
+				values = append(values, rate)
+			}
+		}
+		if len(values) < minProviders {
+			continue
+		}
+
+		mid := median(values)
+		var sum float64
+		var accepted int
+		for i := range sources {
+			if deviationPercent(sources[i].Rate, mid) > r.cfg().Aggregation.MaxDeviation {
+				level.Warn(r.logger).Log("msg", "outlier rejected from consensus", "provider", sources[i].Provider,
+					"base", baseCurrency, "target", currency, "rate", sources[i].Rate, "median", mid)
+				sources[i].Rejected = true
+				continue
+			}
+			sum += sources[i].Rate
+			accepted++
+		}
+		if accepted < minProviders {
+			level.Warn(r.logger).Log("msg", "no consensus for rate", "base", baseCurrency, "target", currency, "agreeing", accepted)
+			continue
+		}
+
+		consensus.Rates[currency] = sum / float64(accepted)
+		consensus.Sources[currency] = sources
+	}
+
+	if len(consensus.Rates) == 0 {
+		return nil, fmt.Errorf("%w for base %s", ErrNoConsensus, baseCurrency)
+	}
+	return consensus, nil
+}
+
+// unionOfRates returns every currency quoted by at least one table
+func unionOfRates(tables []*models.RateTable) map[string]struct{} {
+	currencies := make(map[string]struct{})
+	for _, table := range tables {
+		for currency := range table.Rates {
+			currencies[currency] = struct{}{}
+		}
+	}
+	return currencies
+}
+
+// median returns the median of values without reordering the input
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// deviationPercent returns how far value is from reference, in percent of reference
+func deviationPercent(value, reference float64) float64 {
+	if reference == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(value-reference) / math.Abs(reference) * 100
+}
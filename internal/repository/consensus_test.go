@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{5}, 5},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{1, 1, 100}, 1},
+	}
+	for _, tt := range tests {
+		input := append([]float64(nil), tt.values...)
+		if got := median(input); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+		for i := range input {
+			if input[i] != tt.values[i] {
+				t.Errorf("median reordered its input to %v", input)
+				break
+			}
+		}
+	}
+}
+
+func TestDeviationPercent(t *testing.T) {
+	tests := []struct {
+		value, reference, want float64
+	}{
+		{110, 100, 10},
+		{90, 100, 10},
+		{100, 100, 0},
+		{0.5, 0.25, 100},
+		{-1, -2, 50},
+	}
+	for _, tt := range tests {
+		if got := deviationPercent(tt.value, tt.reference); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("deviationPercent(%v, %v) = %v, want %v", tt.value, tt.reference, got, tt.want)
+		}
+	}
+	if got := deviationPercent(1, 0); !math.IsInf(got, 1) {
+		t.Errorf("deviationPercent from zero = %v, want +Inf", got)
+	}
+}
+
+func consensusConfig(minProviders int) *configs.Config {
+	config := configs.Default()
+	config.Aggregation = configs.AggregationConfig{Mode: AggregationConsensus, MaxDeviation: 2, MinProviders: minProviders}
+	return config
+}
+
+func TestConsensusRejectsOutliers(t *testing.T) {
+	r := newTestRepository(t, consensusConfig(2),
+		&fakeProvider{name: "a", rates: map[string]float64{"EUR": 0.90, "GBP": 0.80, "JPY": 150}},
+		&fakeProvider{name: "b", rates: map[string]float64{"EUR": 0.91, "GBP": 0.80}},
+		&fakeProvider{name: "c", rates: map[string]float64{"EUR": 1.20, "GBP": 0.80}},
+		&fakeProvider{name: "down", err: errProviderDown},
+	)
+
+	table, err := r.consensusLatestRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("consensusLatestRates: %v", err)
+	}
+	if table.Provider != ConsensusProvider {
+		t.Errorf("provider = %q, want %q", table.Provider, ConsensusProvider)
+	}
+
+	// The median of 0.90, 0.91 and 1.20 is 0.91; 1.20 is too far from it
+	if got := table.Rates["EUR"]; math.Abs(got-0.905) > 1e-9 {
+		t.Errorf("EUR = %v, want the average of the agreeing values 0.905", got)
+	}
+	want := []models.RateSource{{Provider: "a", Rate: 0.90}, {Provider: "b", Rate: 0.91}, {Provider: "c", Rate: 1.20, Rejected: true}}
+	if got := table.Sources["EUR"]; len(got) != len(want) {
+		t.Errorf("EUR sources = %+v, want %+v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("EUR source %d = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	}
+
+	// A rate quoted by fewer than MinProviders is left out
+	if _, ok := table.Rates["JPY"]; ok {
+		t.Error("JPY was quoted by a single provider and should be left out")
+	}
+	if math.Abs(table.Rates["GBP"]-0.80) > 1e-9 {
+		t.Errorf("GBP = %v, want 0.80", table.Rates["GBP"])
+	}
+}
+
+func TestConsensusNeedsEnoughProviders(t *testing.T) {
+	r := newTestRepository(t, consensusConfig(2),
+		&fakeProvider{name: "a", rates: map[string]float64{"EUR": 0.90}},
+		&fakeProvider{name: "down", err: errProviderDown},
+	)
+	if _, err := r.consensusLatestRates(context.Background(), "USD"); !errors.Is(err, ErrNoConsensus) {
+		t.Errorf("error with one answering provider = %v, want ErrNoConsensus", err)
+	}
+
+	// Two answers that disagree leave no rate with two agreeing values
+	r = newTestRepository(t, consensusConfig(2),
+		&fakeProvider{name: "a", rates: map[string]float64{"EUR": 0.90}},
+		&fakeProvider{name: "b", rates: map[string]float64{"EUR": 1.10}},
+	)
+	if _, err := r.consensusLatestRates(context.Background(), "USD"); !errors.Is(err, ErrNoConsensus) {
+		t.Errorf("error without agreement = %v, want ErrNoConsensus", err)
+	}
+}
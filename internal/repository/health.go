@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+// Component keys reported by HealthCheck besides the provider names
+const (
+	HealthComponentCache      = "cache"
+	HealthComponentValidation = "rate_validation"
+	// CircuitSuffix is appended to a provider name to report its breaker state
+	CircuitSuffix = "_circuit"
+)
+
+// providerHealth is the cached outcome of a provider health probe
+type providerHealth struct {
+	status    string
+	checkedAt time.Time
+}
+
+// probeProvider returns the provider's health, probing it upstream at most
+// once per configured TTL so frequent orchestrator probes stay cheap
+func (r *rateRepository) probeProvider(ctx context.Context, p *provider) string {
+	name := p.client.Name()
+	ttl := r.cfg().Health.ProviderCacheTTL
+
+	r.mu.Lock()
+	cached, ok := r.providerHealth[name]
+	r.mu.Unlock()
+	if ok && ttl > 0 && time.Since(cached.checkedAt) < ttl {
+		return cached.status
+	}
+
+	status := "healthy"
+	if err := p.client.HealthCheck(ctx); err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "provider health check failed", "provider", name)
+		status = "unhealthy"
+	}
+
+	r.mu.Lock()
+	r.providerHealth[name] = providerHealth{status: status, checkedAt: time.Now()}
+	r.mu.Unlock()
+
+	return status
+}
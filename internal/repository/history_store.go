@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrHistoryNotFound is returned when the history store has no rate for a date
+var ErrHistoryNotFound = errors.New("no stored rate")
+
+// HistoryStore persists daily historical rates, one per pair and date.
+// Storing a rate for a pair and date that already has one replaces it, so
+// repeated imports and backfills don't duplicate data.
+type HistoryStore interface {
+	Get(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error)
+	// Range returns the stored rates between start and end inclusive, in date
+	// order; dates without a stored rate are skipped
+	Range(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error)
+	// Targets lists the target currencies with stored rates against baseCurrency
+	Targets(ctx context.Context, baseCurrency string) ([]string, error)
+	Put(ctx context.Context, rates ...*models.HistoricalRate) error
+	// Flush persists rates that Put has buffered; stores that write through
+	// return nil
+	Flush(ctx context.Context) error
+}
+
+// NewHistoryStore stores historical rates in Redis, or in config.History.File
+// when Redis is unavailable
+func NewHistoryStore(config *configs.Config, logger log.Logger) HistoryStore {
+	redisCache, err := NewRedisCache(config.Redis.Addr, config.Redis.Password, config.Redis.DB)
+	if err == nil {
+		return NewRedisHistoryStore(redisCache.client)
+	}
+	level.Warn(logger).Log("error", err, "msg", "failed to initialize Redis history store, using file")
+	return loadFileHistoryStore(config.History, logger)
+}
+
+// loadFileHistoryStore opens the history file, starting empty if it can't be
+// read. Writes are delayed by config.FlushInterval.
+func loadFileHistoryStore(config configs.HistoryConfig, logger log.Logger) *FileHistoryStore {
+	store, err := NewFileHistoryStore(config.File)
+	if err != nil {
+		level.Error(logger).Log("error", err, "msg", "failed to load history file, starting with no stored history")
+		store = &FileHistoryStore{path: config.File, rates: make(map[string]map[string]*models.HistoricalRate)}
+	}
+	store.flushInterval = config.FlushInterval
+	store.logger = logger
+	return store
+}
+
+func historyPair(baseCurrency, targetCurrency string) string {
+	return strings.ToUpper(baseCurrency) + ":" + strings.ToUpper(targetCurrency)
+}
+
+func historyDate(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// historyDates lists the dates from start to end inclusive
+func historyDates(start, end time.Time) []string {
+	var dates []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, historyDate(d))
+	}
+	return dates
+}
+
+// RedisHistoryStore keeps each pair's rates in a Redis hash keyed by date
+type RedisHistoryStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisHistoryStore creates a history store backed by the given Redis client
+func NewRedisHistoryStore(client *redis.Client) *RedisHistoryStore {
+	return &RedisHistoryStore{client: client, prefix: "history:"}
+}
+
+func (s *RedisHistoryStore) key(baseCurrency, targetCurrency string) string {
+	return s.prefix + historyPair(baseCurrency, targetCurrency)
+}
+
+func (s *RedisHistoryStore) Get(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error) {
+	val, err := s.client.HGet(ctx, s.key(baseCurrency, targetCurrency), historyDate(date)).Result()
+	if err == redis.Nil {
+		return nil, ErrHistoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rate models.HistoricalRate
+	if err := json.Unmarshal([]byte(val), &rate); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (s *RedisHistoryStore) Range(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error) {
+	dates := historyDates(start, end)
+	if len(dates) == 0 {
+		return nil, nil
+	}
+
+	vals, err := s.client.HMGet(ctx, s.key(baseCurrency, targetCurrency), dates...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []*models.HistoricalRate
+	for _, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		var rate models.HistoricalRate
+		if err := json.Unmarshal([]byte(str), &rate); err != nil {
+			return nil, err
+		}
+		rates = append(rates, &rate)
+	}
+	return rates, nil
+}
+
+func (s *RedisHistoryStore) Targets(ctx context.Context, baseCurrency string) ([]string, error) {
+	prefix := s.prefix + strings.ToUpper(baseCurrency) + ":"
+	var targets []string
+	iter := s.client.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		targets = append(targets, strings.TrimPrefix(iter.Val(), prefix))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+func (s *RedisHistoryStore) Put(ctx context.Context, rates ...*models.HistoricalRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	pipe := s.client.Pipeline()
+	for _, rate := range rates {
+		jsonData, err := json.Marshal(rate)
+		if err != nil {
+			return err
+		}
+		pipe.HSet(ctx, s.key(rate.BaseCurrency, rate.TargetCurrency), historyDate(rate.Date), jsonData)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Flush does nothing: Put writes to Redis directly
+func (s *RedisHistoryStore) Flush(ctx context.Context) error {
+	return nil
+}
+
+// FileHistoryStore keeps historical rates in a local JSON file. It is used
+// when Redis is unavailable so fetched history survives restarts. Each write
+// rewrites the whole file, so with a flush interval the rates stored within
+// it are written together once it has passed.
+//
+// Several processes may share the file, such as the server and ratectl
+// backfill: every write takes a lock on the file, reloads it and merges the
+// rates other processes wrote before replacing it. Rates written by another
+// process are seen after the next write.
+type FileHistoryStore struct {
+	path string
+	// flushInterval delays writing the file after Put; zero writes through
+	flushInterval time.Duration
+	logger        log.Logger
+
+	// saveMu serializes writes of the file
+	saveMu sync.Mutex
+
+	mu    sync.RWMutex
+	rates map[string]map[string]*models.HistoricalRate
+	// unsaved holds the rates stored since the file was last written
+	unsaved map[string]map[string]*models.HistoricalRate
+	pending *time.Timer
+}
+
+// NewFileHistoryStore loads the rates stored at path, if any. Put writes the
+// file before it returns.
+func NewFileHistoryStore(path string) (*FileHistoryStore, error) {
+	rates, err := readHistoryFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileHistoryStore{path: path, rates: rates}, nil
+}
+
+// readHistoryFile reads the rates stored at path; a missing file holds none
+func readHistoryFile(path string) (map[string]map[string]*models.HistoricalRate, error) {
+	rates := make(map[string]map[string]*models.HistoricalRate)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rates, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("failed to parse history file: %w", err)
+	}
+	return rates, nil
+}
+
+func (s *FileHistoryStore) Get(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rate, exists := s.rates[historyPair(baseCurrency, targetCurrency)][historyDate(date)]
+	if !exists {
+		return nil, ErrHistoryNotFound
+	}
+	copied := *rate
+	return &copied, nil
+}
+
+func (s *FileHistoryStore) Range(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pair := s.rates[historyPair(baseCurrency, targetCurrency)]
+	var rates []*models.HistoricalRate
+	for _, date := range historyDates(start, end) {
+		if rate, exists := pair[date]; exists {
+			copied := *rate
+			rates = append(rates, &copied)
+		}
+	}
+	return rates, nil
+}
+
+func (s *FileHistoryStore) Targets(ctx context.Context, baseCurrency string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefix := strings.ToUpper(baseCurrency) + ":"
+	var targets []string
+	for pair, rates := range s.rates {
+		if target, ok := strings.CutPrefix(pair, prefix); ok && len(rates) > 0 {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// Put stores rates in memory and writes the file, or schedules a write when a
+// flush interval is set. Rates that could not be written are kept and written
+// with the next flush.
+func (s *FileHistoryStore) Put(ctx context.Context, rates ...*models.HistoricalRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.unsaved == nil {
+		s.unsaved = make(map[string]map[string]*models.HistoricalRate)
+	}
+	for _, rate := range rates {
+		pair, date := historyPair(rate.BaseCurrency, rate.TargetCurrency), historyDate(rate.Date)
+		copied := *rate
+		setHistoryRate(s.rates, pair, date, &copied)
+		setHistoryRate(s.unsaved, pair, date, &copied)
+	}
+	if s.flushInterval > 0 {
+		s.scheduleLocked()
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+	return s.Flush(ctx)
+}
+
+// Flush writes the rates stored since the last write. Under the file lock it
+// rereads the file, so rates written by other processes are kept and loaded.
+func (s *FileHistoryStore) Flush(ctx context.Context) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	if s.pending != nil {
+		s.pending.Stop()
+		s.pending = nil
+	}
+	empty := len(s.unsaved) == 0
+	s.mu.Unlock()
+	if empty {
+		return nil
+	}
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return s.flushFailed(fmt.Errorf("failed to lock history file: %w", err))
+	}
+	defer unlock()
+	onDisk, err := readHistoryFile(s.path)
+	if err != nil {
+		return s.flushFailed(err)
+	}
+
+	s.mu.Lock()
+	for pair, dates := range onDisk {
+		for date, rate := range dates {
+			if s.unsaved[pair][date] == nil {
+				setHistoryRate(s.rates, pair, date, rate)
+			}
+		}
+	}
+	data, err := json.Marshal(s.rates)
+	unsaved := s.unsaved
+	s.unsaved = nil
+	s.mu.Unlock()
+	if err == nil {
+		err = writeFileAtomic(s.path, data)
+	}
+	if err != nil {
+		// Put the rates back for the next attempt, behind any stored since
+		s.mu.Lock()
+		if s.unsaved == nil {
+			s.unsaved = make(map[string]map[string]*models.HistoricalRate)
+		}
+		for pair, dates := range unsaved {
+			for date, rate := range dates {
+				if s.unsaved[pair][date] == nil {
+					setHistoryRate(s.unsaved, pair, date, rate)
+				}
+			}
+		}
+		s.mu.Unlock()
+		return s.flushFailed(fmt.Errorf("failed to write history file: %w", err))
+	}
+	return nil
+}
+
+// flushFailed schedules another attempt when writes are delayed, and
+// returns err
+func (s *FileHistoryStore) flushFailed(err error) error {
+	if s.flushInterval > 0 {
+		s.mu.Lock()
+		s.scheduleLocked()
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// scheduleLocked arranges a flush once the flush interval has passed, unless
+// one is pending; callers hold mu
+func (s *FileHistoryStore) scheduleLocked() {
+	if s.pending != nil {
+		return
+	}
+	s.pending = time.AfterFunc(s.flushInterval, func() {
+		if err := s.Flush(context.Background()); err != nil {
+			level.Error(s.logger).Log("error", err, "msg", "failed to write history file, retrying")
+		}
+	})
+}
+
+func setHistoryRate(rates map[string]map[string]*models.HistoricalRate, pair, date string, rate *models.HistoricalRate) {
+	if rates[pair] == nil {
+		rates[pair] = make(map[string]*models.HistoricalRate)
+	}
+	rates[pair][date] = rate
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log"
+)
+
+func historyRates(days int) []*models.HistoricalRate {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rates := make([]*models.HistoricalRate, days)
+	for i := range rates {
+		rates[i] = &models.HistoricalRate{BaseCurrency: "USD", TargetCurrency: "EUR", Rate: 0.9, Date: start.AddDate(0, 0, i)}
+	}
+	return rates
+}
+
+func TestFileHistoryStoreWritesThrough(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	store, err := NewFileHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(context.Background(), historyRates(3)...); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reloaded, err := NewFileHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates, _ := reloaded.Range(context.Background(), "USD", "EUR", time.Time{}, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)); len(rates) != 3 {
+		t.Errorf("file holds %d rates after Put, want 3", len(rates))
+	}
+}
+
+func TestFileHistoryStoreBatchesWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "history.json")
+	store := loadFileHistoryStore(configs.HistoryConfig{File: path, FlushInterval: time.Hour}, log.NewNopLogger())
+
+	for _, rate := range historyRates(30) {
+		if err := store.Put(ctx, rate); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("file written before the flush interval passed (%v)", err)
+	}
+	if rate, err := store.Get(ctx, "USD", "EUR", time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)); err != nil || rate.Rate != 0.9 {
+		t.Errorf("buffered rate not served: %v", err)
+	}
+
+	if err := store.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	reloaded, err := NewFileHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates, _ := reloaded.Range(ctx, "USD", "EUR", time.Time{}, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)); len(rates) != 30 {
+		t.Errorf("file holds %d rates after Flush, want 30", len(rates))
+	}
+}
+
+func TestFileHistoryStoreFlushesAfterInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	store := loadFileHistoryStore(configs.HistoryConfig{File: path, FlushInterval: 10 * time.Millisecond}, log.NewNopLogger())
+	if err := store.Put(context.Background(), historyRates(2)...); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("history file not written after the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFileHistoryStoresShareFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "history.json")
+	server, err := NewFileHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backfill, err := NewFileHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates := historyRates(4)
+	if err := server.Put(ctx, rates[0], rates[1]); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	overwrite := *rates[1]
+	overwrite.Rate = 0.8
+	if err := backfill.Put(ctx, rates[2], rates[3], &overwrite); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reloaded, err := NewFileHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, _ := reloaded.Range(ctx, "USD", "EUR", time.Time{}, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	if len(all) != 4 {
+		t.Fatalf("file holds %d rates after both stores wrote, want 4", len(all))
+	}
+	if rate, _ := reloaded.Get(ctx, "USD", "EUR", rates[1].Date); rate.Rate != 0.8 {
+		t.Errorf("rate = %v, want the later write 0.8", rate.Rate)
+	}
+
+	// The server picks up the backfilled rates with its next write
+	if err := server.Put(ctx, rates[0]); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if rate, err := server.Get(ctx, "USD", "EUR", rates[3].Date); err != nil || rate.Rate != 0.9 {
+		t.Errorf("backfilled rate not loaded by the server: %v", err)
+	}
+}
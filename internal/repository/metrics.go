@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"expvar"
+	"strings"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+)
+
+// Metrics holds the instruments recorded by the repository
+type Metrics struct {
+	// ProviderRequests counts provider calls, labelled by provider and outcome
+	ProviderRequests metrics.Counter
+	// ProviderRetries counts retried provider calls, labelled by provider
+	ProviderRetries metrics.Counter
+	// RateAnomalies counts quarantined rate tables, labelled by base currency
+	RateAnomalies metrics.Counter
+}
+
+// NewMetrics creates metrics published through expvar (served at /debug/vars)
+func NewMetrics() *Metrics {
+	return &Metrics{
+		ProviderRequests: newExpvarCounter("provider_requests_total"),
+		ProviderRetries:  newExpvarCounter("provider_retries_total"),
+		RateAnomalies:    newExpvarCounter("rate_anomalies_total"),
+	}
+}
+
+// NopMetrics creates metrics that discard every observation
+func NopMetrics() *Metrics {
+	return &Metrics{
+		ProviderRequests: discard.NewCounter(),
+		ProviderRetries:  discard.NewCounter(),
+		RateAnomalies:    discard.NewCounter(),
+	}
+}
+
+// expvarCounter is a labelled metrics.Counter backed by an expvar.Map; each
+// distinct set of label values is kept under its own map key
+type expvarCounter struct {
+	m           *expvar.Map
+	labelValues []string
+}
+
+func newExpvarCounter(name string) *expvarCounter {
+	m, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		m = expvar.NewMap(name)
+	}
+	return &expvarCounter{m: m}
+}
+
+func (c *expvarCounter) With(labelValues ...string) metrics.Counter {
+	lvs := make([]string, 0, len(c.labelValues)+len(labelValues))
+	lvs = append(lvs, c.labelValues...)
+	lvs = append(lvs, labelValues...)
+	return &expvarCounter{m: c.m, labelValues: lvs}
+}
+
+func (c *expvarCounter) Add(delta float64) {
+	c.m.AddFloat(c.key(), delta)
+}
+
+func (c *expvarCounter) key() string {
+	if len(c.labelValues) == 0 {
+		return "total"
+	}
+	pairs := make([]string, 0, len(c.labelValues)/2)
+	for i := 0; i+1 < len(c.labelValues); i += 2 {
+		pairs = append(pairs, c.labelValues[i]+"="+c.labelValues[i+1])
+	}
+	return strings.Join(pairs, ",")
+}
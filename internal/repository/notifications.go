@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"maps"
+
+	"exchange-rate-service/internal/models"
+)
+
+// RateListener is called with every rate table freshly accepted from the
+// providers. Listeners run on the goroutine that fetched the table and must
+// not block.
+type RateListener func(table *models.RateTable)
+
+// AddRateListener registers a listener for refreshed rate tables
+func (r *rateRepository) AddRateListener(listener RateListener) {
+	r.mu.Lock()
+	r.listeners = append(r.listeners, listener)
+	r.mu.Unlock()
+}
+
+// notifyRateListeners hands each listener its own copy of the table
+func (r *rateRepository) notifyRateListeners(table *models.RateTable) {
+	r.mu.Lock()
+	listeners := r.listeners
+	r.mu.Unlock()
+
+	for _, listener := range listeners {
+		copied := *table
+		copied.Rates = maps.Clone(table.Rates)
+		listener(&copied)
+	}
+}
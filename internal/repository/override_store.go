@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrOverrideNotFound is returned when no override exists for a pair
+var ErrOverrideNotFound = errors.New("override not found")
+
+// OverrideProvider is reported as the provider of a pinned rate
+const OverrideProvider = "override"
+
+// OverrideStore persists manual rate overrides, one per currency pair
+type OverrideStore interface {
+	Get(ctx context.Context, baseCurrency, targetCurrency string) (*models.RateOverride, error)
+	List(ctx context.Context) ([]*models.RateOverride, error)
+	Put(ctx context.Context, override *models.RateOverride) error
+	Delete(ctx context.Context, baseCurrency, targetCurrency string) error
+}
+
+func overrideField(baseCurrency, targetCurrency string) string {
+	return strings.ToUpper(baseCurrency) + ":" + strings.ToUpper(targetCurrency)
+}
+
+func sortOverrides(overrides []*models.RateOverride) {
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrideField(overrides[i].BaseCurrency, overrides[i].TargetCurrency) <
+			overrideField(overrides[j].BaseCurrency, overrides[j].TargetCurrency)
+	})
+}
+
+// RedisOverrideStore keeps overrides in a Redis hash so every replica sees them
+type RedisOverrideStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisOverrideStore creates an override store backed by the given Redis client
+func NewRedisOverrideStore(client *redis.Client) *RedisOverrideStore {
+	return &RedisOverrideStore{client: client, key: "overrides"}
+}
+
+func (s *RedisOverrideStore) Get(ctx context.Context, baseCurrency, targetCurrency string) (*models.RateOverride, error) {
+	val, err := s.client.HGet(ctx, s.key, overrideField(baseCurrency, targetCurrency)).Result()
+	if err == redis.Nil {
+		return nil, ErrOverrideNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var override models.RateOverride
+	if err := json.Unmarshal([]byte(val), &override); err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (s *RedisOverrideStore) List(ctx context.Context) ([]*models.RateOverride, error) {
+	vals, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make([]*models.RateOverride, 0, len(vals))
+	for _, val := range vals {
+		var override models.RateOverride
+		if err := json.Unmarshal([]byte(val), &override); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, &override)
+	}
+	sortOverrides(overrides)
+	return overrides, nil
+}
+
+func (s *RedisOverrideStore) Put(ctx context.Context, override *models.RateOverride) error {
+	jsonData, err := json.Marshal(override)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.key, overrideField(override.BaseCurrency, override.TargetCurrency), jsonData).Err()
+}
+
+func (s *RedisOverrideStore) Delete(ctx context.Context, baseCurrency, targetCurrency string) error {
+	removed, err := s.client.HDel(ctx, s.key, overrideField(baseCurrency, targetCurrency)).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrOverrideNotFound
+	}
+	return nil
+}
+
+// FileOverrideStore keeps overrides in a local JSON file. It is used when
+// Redis is unavailable so pinned rates still survive restarts.
+type FileOverrideStore struct {
+	path string
+
+	mu        sync.RWMutex
+	overrides map[string]*models.RateOverride
+}
+
+// NewFileOverrideStore loads the overrides stored at path, if any
+func NewFileOverrideStore(path string) (*FileOverrideStore, error) {
+	store := &FileOverrideStore{
+		path:      path,
+		overrides: make(map[string]*models.RateOverride),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overrides file: %w", err)
+	}
+
+	var overrides []*models.RateOverride
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse overrides file: %w", err)
+	}
+	for _, override := range overrides {
+		store.overrides[overrideField(override.BaseCurrency, override.TargetCurrency)] = override
+	}
+	return store, nil
+}
+
+func (s *FileOverrideStore) Get(ctx context.Context, baseCurrency, targetCurrency string) (*models.RateOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	override, exists := s.overrides[overrideField(baseCurrency, targetCurrency)]
+	if !exists {
+		return nil, ErrOverrideNotFound
+	}
+	copied := *override
+	return &copied, nil
+}
+
+func (s *FileOverrideStore) List(ctx context.Context) ([]*models.RateOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot(), nil
+}
+
+func (s *FileOverrideStore) Put(ctx context.Context, override *models.RateOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	field := overrideField(override.BaseCurrency, override.TargetCurrency)
+	previous, existed := s.overrides[field]
+	copied := *override
+	s.overrides[field] = &copied
+
+	if err := s.save(); err != nil {
+		if existed {
+			s.overrides[field] = previous
+		} else {
+			delete(s.overrides, field)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileOverrideStore) Delete(ctx context.Context, baseCurrency, targetCurrency string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	field := overrideField(baseCurrency, targetCurrency)
+	previous, exists := s.overrides[field]
+	if !exists {
+		return ErrOverrideNotFound
+	}
+	delete(s.overrides, field)
+
+	if err := s.save(); err != nil {
+		s.overrides[field] = previous
+		return err
+	}
+	return nil
+}
+
+// snapshot returns copies of all overrides sorted by pair; callers hold mu
+func (s *FileOverrideStore) snapshot() []*models.RateOverride {
+	overrides := make([]*models.RateOverride, 0, len(s.overrides))
+	for _, override := range s.overrides {
+		copied := *override
+		overrides = append(overrides, &copied)
+	}
+	sortOverrides(overrides)
+	return overrides
+}
+
+// save writes the overrides atomically via a temporary file; callers hold mu
+func (s *FileOverrideStore) save() error {
+	data, err := json.MarshalIndent(s.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write overrides file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data via a temporary file in
+// the same directory, so readers never see a partial write
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
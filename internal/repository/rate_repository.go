@@ -3,196 +3,609 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"exchange-rate-service/configs"
 	"exchange-rate-service/internal/models"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/redis/go-redis/v9"
 )
 
 // RateRepository defines the interface for rate data operations
 type RateRepository interface {
 	GetLatestRate(ctx context.Context, baseCurrency, targetCurrency string) (*models.ExchangeRate, error)
+	GetLatestRates(ctx context.Context, baseCurrency string) (*models.RateTable, error)
 	GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error)
+	GetStoredRates(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error)
+	GetStoredTargets(ctx context.Context, baseCurrency string) ([]string, error)
+	StoreRates(ctx context.Context, rates ...*models.HistoricalRate) error
+	// FlushHistory writes historical rates the history store has buffered
+	FlushHistory(ctx context.Context) error
 	GetSupportedCurrencies(ctx context.Context) ([]*models.Currency, error)
 	HealthCheck(ctx context.Context) (map[string]string, error)
+	SetOverride(ctx context.Context, override *models.RateOverride) error
+	ListOverrides(ctx context.Context) ([]*models.RateOverride, error)
+	DeleteOverride(ctx context.Context, baseCurrency, targetCurrency string) error
+	ListCacheKeys(ctx context.Context, pattern string) ([]string, error)
+	GetCacheEntry(ctx context.Context, key string) (*models.CacheEntry, error)
+	InvalidateCache(ctx context.Context, baseCurrency, targetCurrency string) (*models.CacheInvalidation, error)
+	RefreshRates(ctx context.Context, baseCurrency string) (*models.RateTable, error)
+	Reconfigure(config *configs.Config)
+	AddRateListener(listener RateListener)
+}
+
+// ErrRateNotFound is returned when a provider has no rate for the requested currency
+var ErrRateNotFound = errors.New("rate not found")
+
+// ErrNotSupported is returned when a provider does not offer the requested operation
+var ErrNotSupported = errors.New("operation not supported")
+
+// ProviderClient defines the operations an exchange rate provider supports
+type ProviderClient interface {
+	Name() string
+	GetLatestRates(ctx context.Context, baseCurrency string) (*models.RateTable, error)
+	GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error)
+	// GetHistoricalRates returns every rate of a base currency on one date
+	GetHistoricalRates(ctx context.Context, baseCurrency string, date time.Time) (*models.RateTable, error)
+	GetSupportedCurrencies(ctx context.Context) ([]*models.Currency, error)
+	HealthCheck(ctx context.Context) error
+}
+
+// provider pairs a provider client with its circuit breaker
+type provider struct {
+	client   ProviderClient
+	breaker  *CircuitBreaker
+	priority int
+	config   configs.ProviderConfig
 }
 
 // rateRepository implements RateRepository
 type rateRepository struct {
-	config *configs.Config
-	logger log.Logger
-	cache  Cache
-	client *OpenERAPIClient
+	state     atomic.Pointer[repositoryState]
+	logger    log.Logger
+	metrics   *Metrics
+	cache     Cache
+	overrides OverrideStore
+	history   HistoryStore
+
+	mu             sync.Mutex
+	quarantined    map[string]time.Time
+	providerHealth map[string]providerHealth
+	listeners      []RateListener
 }
 
-// Cache defines the cache interface
+// ErrCacheMiss is returned when a key is not present in the cache
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache defines the cache interface. Keys matches glob-style patterns as Redis
+// does; TTL returns ErrCacheMiss for absent keys and -1 for keys without expiry.
 type Cache interface {
 	Get(ctx context.Context, key string, dest interface{}) error
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, keys ...string) error
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
 	Ping(ctx context.Context) error
 }
 
 // NewRateRepository creates a new rate repository
-func NewRateRepository(config *configs.Config, logger log.Logger) RateRepository {
+func NewRateRepository(config *configs.Config, logger log.Logger, metrics *Metrics) RateRepository {
 	// Initialize cache (Redis)
 	var cache Cache
+	var overrides OverrideStore
+	var history HistoryStore
 	redisCache, err := NewRedisCache(config.Redis.Addr, config.Redis.Password, config.Redis.DB)
 	if err != nil {
-		logger.Log("error", err, "msg", "failed to initialize Redis cache")
+		level.Warn(logger).Log("error", err, "msg", "failed to initialize Redis cache")
 		// Fallback to in-memory cache
 		cache = NewInMemoryCache()
 	} else {
 		cache = redisCache
+		overrides = NewRedisOverrideStore(redisCache.client)
+		history = NewRedisHistoryStore(redisCache.client)
 	}
 
-	// Initialize single provider client (open-er-api.com)
-	providerCfg := config.Providers
-	if strings.ToLower(providerCfg.Name) != "open.er-api.com" && strings.ToLower(providerCfg.Name) != "openerapi" {
-		if providerCfg.BaseURL == "" {
-			providerCfg.BaseURL = "https://open.er-api.com/v6"
+	// Without Redis, overrides are kept in a local file so they survive restarts
+	if overrides == nil {
+		fileStore, err := NewFileOverrideStore(config.Admin.OverridesFile)
+		if err != nil {
+			level.Error(logger).Log("error", err, "msg", "failed to load overrides file, starting with no overrides")
+			fileStore = &FileOverrideStore{path: config.Admin.OverridesFile, overrides: make(map[string]*models.RateOverride)}
 		}
-		if providerCfg.Timeout == 0 {
-			providerCfg.Timeout = 10 * time.Second
+		overrides = fileStore
+	}
+	if history == nil {
+		history = loadFileHistoryStore(config.History, logger)
+	}
+
+	r := &rateRepository{
+		logger:         logger,
+		metrics:        metrics,
+		cache:          cache,
+		overrides:      overrides,
+		history:        history,
+		quarantined:    make(map[string]time.Time),
+		providerHealth: make(map[string]providerHealth),
+	}
+	// Initialize provider clients in priority order
+	r.state.Store(&repositoryState{config: config, providers: r.buildProviders(config, nil)})
+	return r
+}
+
+// callProviders runs fn against each provider in priority order and returns the
+// first successful result. Providers whose circuit breaker is open are skipped
+// without waiting on them, so a failing provider fails fast or fails over.
+func callProviders[T any](ctx context.Context, r *rateRepository, fn func(ctx context.Context, client ProviderClient) (T, error)) (T, error) {
+	var result T
+	var errs []error
+
+	for _, p := range r.providerList() {
+		err := p.breaker.Do(ctx, func(ctx context.Context) error {
+			var err error
+			result, err = fn(ctx, p.client)
+			return err
+		})
+		if err == nil {
+			return result, nil
+		}
+
+		level.Error(r.logger).Log("error", err, "msg", "provider call failed", "provider", p.client.Name())
+		errs = append(errs, fmt.Errorf("%s: %w", p.client.Name(), err))
+
+		if ctx.Err() != nil {
+			break
 		}
-		providerCfg.Name = "open.er-api.com"
-		logger.Log("warn", "provider overridden to open.er-api.com")
 	}
-	client := NewOpenERAPIClient(providerCfg, logger)
 
-	return &rateRepository{
-		config: config,
-		logger: logger,
-		cache:  cache,
-		client: client,
+	var zero T
+	if len(errs) == 0 {
+		return zero, fmt.Errorf("no providers configured")
 	}
+	return zero, errors.Join(errs...)
 }
 
-// GetLatestRate retrieves the latest exchange rate
+// GetLatestRate retrieves the latest exchange rate from the base currency's rate table
 func (r *rateRepository) GetLatestRate(ctx context.Context, baseCurrency, targetCurrency string) (*models.ExchangeRate, error) {
-	// Try cache first
-	cacheKey := fmt.Sprintf("rate:%s:%s:latest", baseCurrency, targetCurrency)
-	var rate models.ExchangeRate
-	if err := r.cache.Get(ctx, cacheKey, &rate); err == nil {
-		r.logger.Log("msg", "rate found in cache", "base", baseCurrency, "target", targetCurrency)
-		return &rate, nil
+	// Pinned rates take precedence over cached and provider data
+	if override := r.activeOverride(ctx, baseCurrency, targetCurrency, time.Now()); override != nil {
+		return &models.ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           override.Rate,
+			Provider:       OverrideProvider,
+			Author:         override.Author,
+			FetchedAt:      override.CreatedAt,
+		}, nil
+	}
+
+	table, err := r.GetLatestRates(ctx, baseCurrency)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fetch from provider
-	ratePtr, err := r.client.GetLatestRate(ctx, baseCurrency, targetCurrency)
+	rate, exists := table.Rates[targetCurrency]
+	if !exists {
+		return nil, fmt.Errorf("%w for %s/%s", ErrRateNotFound, baseCurrency, targetCurrency)
+	}
+
+	return &models.ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           rate,
+		Provider:       table.Provider,
+		Sources:        table.Sources[targetCurrency],
+		FetchedAt:      table.FetchedAt,
+		IsStale:        table.IsStale,
+	}, nil
+}
+
+// GetLatestRates retrieves the latest table of rates for a base currency, with
+// active overrides applied. Fresh tables are validated before caching; when the
+// providers only return suspicious data the last accepted table is served,
+// flagged as stale.
+func (r *rateRepository) GetLatestRates(ctx context.Context, baseCurrency string) (*models.RateTable, error) {
+	table, fresh, err := r.latestRates(ctx, baseCurrency)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the result
-	if err := r.cache.Set(ctx, cacheKey, ratePtr, 5*time.Minute); err != nil {
-		r.logger.Log("error", err, "msg", "failed to cache rate")
+	// Overrides are applied on the way out, so the cache and the validation
+	// baseline only ever hold provider data
+	r.applyOverrides(ctx, table, time.Now())
+	if fresh {
+		r.notifyRateListeners(table)
 	}
+	return table, nil
+}
 
-	return ratePtr, nil
+// latestRates returns the cached table for a base currency, or fetches and
+// caches a new one; fresh reports whether it was fetched
+func (r *rateRepository) latestRates(ctx context.Context, baseCurrency string) (*models.RateTable, bool, error) {
+	// Try cache first
+	cacheKey := latestTableKey(baseCurrency)
+	var cached models.RateTable
+	if err := r.cache.Get(ctx, cacheKey, &cached); err == nil {
+		level.Debug(r.logger).Log("msg", "rates found in cache", "base", baseCurrency)
+		return &cached, false, nil
+	}
+
+	// Fetch from providers
+	var table *models.RateTable
+	var err error
+	if r.cfg().Aggregation.Mode == AggregationConsensus {
+		table, err = r.consensusLatestRates(ctx, baseCurrency)
+		if err == nil {
+			err = r.acceptTable(ctx, table)
+		}
+	} else {
+		table, err = callProviders(ctx, r, func(ctx context.Context, client ProviderClient) (*models.RateTable, error) {
+			table, err := client.GetLatestRates(ctx, baseCurrency)
+			if err != nil {
+				return nil, err
+			}
+			if err := r.acceptTable(ctx, table); err != nil {
+				return nil, err
+			}
+			return table, nil
+		})
+	}
+	if err != nil {
+		if errors.Is(err, ErrRateQuarantined) {
+			var baseline models.RateTable
+			if cerr := r.cache.Get(ctx, baselineTableKey(baseCurrency), &baseline); cerr == nil {
+				level.Warn(r.logger).Log("msg", "serving last accepted rates while new table is quarantined", "base", baseCurrency)
+				baseline.IsStale = true
+				// Hold the stale table briefly so every request doesn't hit the providers again
+				if err := r.cache.Set(ctx, cacheKey, &baseline, r.cfg().Cache.StaleTTL); err != nil {
+					level.Error(r.logger).Log("error", err, "msg", "failed to cache stale rates")
+				}
+				return &baseline, false, nil
+			}
+		}
+		return nil, false, err
+	}
+
+	r.releaseQuarantine(baseCurrency)
+
+	// Cache the result and keep it as the baseline for validating the next table
+	if err := r.cache.Set(ctx, cacheKey, table, r.cfg().Cache.LatestTTL); err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "failed to cache rates")
+	}
+	if err := r.cache.Set(ctx, baselineTableKey(baseCurrency), table, r.cfg().Validation.BaselineTTL); err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "failed to store rate baseline")
+	}
+
+	return table, true, nil
 }
 
 // GetHistoricalRate retrieves a historical exchange rate
 func (r *rateRepository) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error) {
+	// Pinned rates take precedence over cached and provider data
+	if override := r.activeOverride(ctx, baseCurrency, targetCurrency, date); override != nil {
+		return &models.HistoricalRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           override.Rate,
+			Date:           date,
+			Provider:       OverrideProvider,
+			Author:         override.Author,
+			FetchedAt:      override.CreatedAt,
+		}, nil
+	}
+
 	// Try cache first
 	cacheKey := fmt.Sprintf("rate:%s:%s:%s", baseCurrency, targetCurrency, date.Format("2006-01-02"))
 	var rate models.HistoricalRate
 	if err := r.cache.Get(ctx, cacheKey, &rate); err == nil {
-		r.logger.Log("msg", "historical rate found in cache", "base", baseCurrency, "target", targetCurrency, "date", date.Format("2006-01-02"))
+		level.Debug(r.logger).Log("msg", "historical rate found in cache", "base", baseCurrency, "target", targetCurrency, "date", date.Format("2006-01-02"))
 		return &rate, nil
 	}
 
-	ratePtr, err := r.client.GetHistoricalRate(ctx, baseCurrency, targetCurrency, date)
+	// Then the history store, which keeps every rate fetched so far
+	if stored, err := r.history.Get(ctx, baseCurrency, targetCurrency, date); err == nil {
+		if err := r.cache.Set(ctx, cacheKey, stored, r.cfg().Cache.HistoricalTTL); err != nil {
+			level.Error(r.logger).Log("error", err, "msg", "failed to cache historical rate")
+		}
+		return stored, nil
+	} else if !errors.Is(err, ErrHistoryNotFound) {
+		level.Error(r.logger).Log("error", err, "msg", "failed to read history store")
+	}
+
+	ratePtr, err := callProviders(ctx, r, func(ctx context.Context, client ProviderClient) (*models.HistoricalRate, error) {
+		rate, err := client.GetHistoricalRate(ctx, baseCurrency, targetCurrency, date)
+		if err != nil {
+			return nil, err
+		}
+		if !r.validRate(rate.Rate) {
+			level.Warn(r.logger).Log("alert", "invalid_rates", "msg", "rejected invalid historical rate from provider",
+				"provider", rate.Provider, "base", baseCurrency, "target", targetCurrency, "rate", rate.Rate)
+			return nil, fmt.Errorf("%w: invalid rate %g from %s", ErrRateQuarantined, rate.Rate, rate.Provider)
+		}
+		return rate, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache the result (historical rates can be cached longer)
-	if err := r.cache.Set(ctx, cacheKey, ratePtr, 24*time.Hour); err != nil {
-		r.logger.Log("error", err, "msg", "failed to cache historical rate")
+	if err := r.cache.Set(ctx, cacheKey, ratePtr, r.cfg().Cache.HistoricalTTL); err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "failed to cache historical rate")
+	}
+	if err := r.history.Put(ctx, ratePtr); err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "failed to store historical rate")
 	}
 
 	return ratePtr, nil
 }
 
+// GetStoredRates returns the rates kept in the history store between start and
+// end inclusive, without calling providers for missing dates
+func (r *rateRepository) GetStoredRates(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error) {
+	rates, err := r.history.Range(ctx, baseCurrency, targetCurrency, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history store: %w", err)
+	}
+	return rates, nil
+}
+
+// GetStoredTargets lists the target currencies with stored history against
+// baseCurrency
+func (r *rateRepository) GetStoredTargets(ctx context.Context, baseCurrency string) ([]string, error) {
+	targets, err := r.history.Targets(ctx, baseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history store: %w", err)
+	}
+	return targets, nil
+}
+
+// StoreRates writes rates to the history store, replacing any stored rate for
+// the same pair and date. Unlike rates fetched on demand, they are persisted
+// before it returns.
+func (r *rateRepository) StoreRates(ctx context.Context, rates ...*models.HistoricalRate) error {
+	if err := r.history.Put(ctx, rates...); err != nil {
+		return fmt.Errorf("failed to write history store: %w", err)
+	}
+	return r.FlushHistory(ctx)
+}
+
+// FlushHistory writes historical rates the history store has buffered
+func (r *rateRepository) FlushHistory(ctx context.Context) error {
+	if err := r.history.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to write history store: %w", err)
+	}
+	return nil
+}
+
 // GetSupportedCurrencies retrieves list of supported currencies
 func (r *rateRepository) GetSupportedCurrencies(ctx context.Context) ([]*models.Currency, error) {
 	// Try cache first
 	cacheKey := "currencies:supported"
 	var currencies []*models.Currency
 	if err := r.cache.Get(ctx, cacheKey, &currencies); err == nil {
-		r.logger.Log("msg", "supported currencies found in cache")
+		level.Debug(r.logger).Log("msg", "supported currencies found in cache")
 		return currencies, nil
 	}
 
-	currencies, err := r.client.GetSupportedCurrencies(ctx)
+	currencies, err := callProviders(ctx, r, func(ctx context.Context, client ProviderClient) ([]*models.Currency, error) {
+		return client.GetSupportedCurrencies(ctx)
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache the result (currencies list changes rarely)
-	if err := r.cache.Set(ctx, cacheKey, currencies, 24*time.Hour); err != nil {
-		r.logger.Log("error", err, "msg", "failed to cache currencies")
+	if err := r.cache.Set(ctx, cacheKey, currencies, r.cfg().Cache.CurrenciesTTL); err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "failed to cache currencies")
 	}
 
 	return currencies, nil
 }
 
+// SetOverride stores a manual rate override, replacing any existing one for the pair
+func (r *rateRepository) SetOverride(ctx context.Context, override *models.RateOverride) error {
+	if err := r.overrides.Put(ctx, override); err != nil {
+		return fmt.Errorf("failed to store override: %w", err)
+	}
+	level.Info(r.logger).Log("msg", "rate override set", "base", override.BaseCurrency, "target", override.TargetCurrency,
+		"rate", override.Rate, "author", override.Author)
+	return nil
+}
+
+// ListOverrides returns every stored override, including inactive ones
+func (r *rateRepository) ListOverrides(ctx context.Context) ([]*models.RateOverride, error) {
+	return r.overrides.List(ctx)
+}
+
+// DeleteOverride removes the override for a pair
+func (r *rateRepository) DeleteOverride(ctx context.Context, baseCurrency, targetCurrency string) error {
+	if err := r.overrides.Delete(ctx, baseCurrency, targetCurrency); err != nil {
+		return err
+	}
+	level.Info(r.logger).Log("msg", "rate override removed", "base", baseCurrency, "target", targetCurrency)
+	return nil
+}
+
+// activeOverride returns the override for a pair if one applies at t
+func (r *rateRepository) activeOverride(ctx context.Context, baseCurrency, targetCurrency string, t time.Time) *models.RateOverride {
+	override, err := r.overrides.Get(ctx, baseCurrency, targetCurrency)
+	if err != nil {
+		if !errors.Is(err, ErrOverrideNotFound) {
+			level.Error(r.logger).Log("error", err, "msg", "failed to look up override", "base", baseCurrency, "target", targetCurrency)
+		}
+		return nil
+	}
+	if !override.ActiveAt(t) {
+		return nil
+	}
+	return override
+}
+
+// applyOverrides replaces the rates of table pinned by an override active at t
+// and lists the override as their only source
+func (r *rateRepository) applyOverrides(ctx context.Context, table *models.RateTable, t time.Time) {
+	overrides, err := r.overrides.List(ctx)
+	if err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "failed to list overrides", "base", table.BaseCurrency)
+		return
+	}
+	for _, override := range overrides {
+		if override.BaseCurrency != table.BaseCurrency || !override.ActiveAt(t) {
+			continue
+		}
+		if table.Rates == nil {
+			table.Rates = make(map[string]float64)
+		}
+		if table.Sources == nil {
+			table.Sources = make(map[string][]models.RateSource)
+		}
+		table.Rates[override.TargetCurrency] = override.Rate
+		table.Sources[override.TargetCurrency] = []models.RateSource{{Provider: OverrideProvider, Rate: override.Rate}}
+	}
+}
+
 // HealthCheck performs a health check
 func (r *rateRepository) HealthCheck(ctx context.Context) (map[string]string, error) {
 	providers := make(map[string]string)
 
 	// Check cache health
 	if err := r.cache.Ping(ctx); err != nil {
-		providers["cache"] = "unhealthy"
+		providers[HealthComponentCache] = "unhealthy"
 	} else {
-		providers["cache"] = "healthy"
+		providers[HealthComponentCache] = "healthy"
 	}
 
-	// Check provider health
-	if r.client != nil {
-		if err := r.client.HealthCheck(ctx); err != nil {
-			providers[r.client.Name()] = "unhealthy"
-		} else {
-			providers[r.client.Name()] = "healthy"
-		}
-	} else {
+	// Check provider health, bypassing the breaker so an open circuit can be seen recovering
+	providerList := r.providerList()
+	for _, p := range providerList {
+		name := p.client.Name()
+		providers[name] = r.probeProvider(ctx, p)
+		providers[name+CircuitSuffix] = p.breaker.State().String()
+	}
+
+	// Quarantined provider data degrades the service until a valid table arrives
+	providers[HealthComponentValidation] = r.validationHealth()
+	if len(providerList) == 0 {
 		providers["open.er-api.com"] = "unconfigured"
 	}
 
 	return providers, nil
 }
 
-// InMemoryCache implements a simple in-memory cache
+// InMemoryCache implements a simple in-memory cache. Values are stored
+// JSON-encoded, like in Redis, so callers get copies rather than shared pointers.
 type InMemoryCache struct {
-	data map[string]interface{}
+	mu   sync.RWMutex
+	data map[string]inMemoryEntry
+}
+
+type inMemoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e inMemoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
 }
 
 func NewInMemoryCache() *InMemoryCache {
 	return &InMemoryCache{
-		data: make(map[string]interface{}),
+		data: make(map[string]inMemoryEntry),
 	}
 }
 
 func (c *InMemoryCache) Get(ctx context.Context, key string, dest interface{}) error {
-	// Simple implementation - in real world, you'd want proper serialization
-	return fmt.Errorf("in-memory cache not implemented")
+	c.mu.RLock()
+	entry, exists := c.data[key]
+	c.mu.RUnlock()
+
+	if !exists || entry.expired(time.Now()) {
+		return fmt.Errorf("%w: %s", ErrCacheMiss, key)
+	}
+	return json.Unmarshal(entry.value, dest)
 }
 
 func (c *InMemoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	// Simple implementation - in real world, you'd want proper serialization
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	entry := inMemoryEntry{value: jsonData}
+	if expiration > 0 {
+		entry.expiresAt = time.Now().Add(expiration)
+	}
+
+	c.mu.Lock()
+	c.data[key] = entry
+	c.mu.Unlock()
 	return nil
 }
 
 func (c *InMemoryCache) Exists(ctx context.Context, key string) (bool, error) {
-	_, exists := c.data[key]
-	return exists, nil
+	c.mu.RLock()
+	entry, exists := c.data[key]
+	c.mu.RUnlock()
+	return exists && !entry.expired(time.Now()), nil
+}
+
+func (c *InMemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	for _, key := range keys {
+		delete(c.data, key)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// Keys returns the live keys matching pattern and evicts expired entries on the way
+func (c *InMemoryCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var keys []string
+	for key, entry := range c.data {
+		if entry.expired(now) {
+			delete(c.data, key)
+			continue
+		}
+		if matched, _ := path.Match(pattern, key); matched {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *InMemoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	c.mu.RLock()
+	entry, exists := c.data[key]
+	c.mu.RUnlock()
+
+	now := time.Now()
+	if !exists || entry.expired(now) {
+		return 0, fmt.Errorf("%w: %s", ErrCacheMiss, key)
+	}
+	if entry.expiresAt.IsZero() {
+		return -1, nil
+	}
+	return entry.expiresAt.Sub(now), nil
 }
 
 func (c *InMemoryCache) Ping(ctx context.Context) error {
@@ -201,14 +614,19 @@ func (c *InMemoryCache) Ping(ctx context.Context) error {
 
 // OpenERAPIClient implements ProviderClient for open.er-api.com API
 type OpenERAPIClient struct {
-	name    string
-	baseURL string
-	client  *http.Client
-	logger  log.Logger
+	name           string
+	baseURL        string
+	apiKey         string
+	apiKeyLocation string
+	apiKeyHeader   string
+	client         *http.Client
+	retry          retryPolicy
+	logger         log.Logger
+	metrics        *Metrics
 }
 
 // NewOpenERAPIClient creates a new client for open.er-api.com API
-func NewOpenERAPIClient(config configs.ProviderConfig, logger log.Logger) *OpenERAPIClient {
+func NewOpenERAPIClient(config configs.ProviderConfig, logger log.Logger, metrics *Metrics) *OpenERAPIClient {
 	timeout := config.Timeout
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -218,11 +636,30 @@ func NewOpenERAPIClient(config configs.ProviderConfig, logger log.Logger) *OpenE
 		Timeout: timeout,
 	}
 
+	// A configured key switches the client to the paid tier
+	baseURL := config.BaseURL
+	if config.APIKey != "" && config.PaidBaseURL != "" {
+		baseURL = config.PaidBaseURL
+	}
+	keyLocation := strings.ToLower(config.APIKeyLocation)
+	if keyLocation != APIKeyInHeader {
+		keyLocation = APIKeyInPath
+	}
+	keyHeader := config.APIKeyHeader
+	if keyHeader == "" {
+		keyHeader = "Authorization"
+	}
+
 	return &OpenERAPIClient{
-		name:    config.Name,
-		baseURL: config.BaseURL,
-		client:  httpClient,
-		logger:  logger,
+		name:           config.Name,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		apiKey:         config.APIKey,
+		apiKeyLocation: keyLocation,
+		apiKeyHeader:   keyHeader,
+		client:         httpClient,
+		retry:          newRetryPolicy(config.Retry),
+		logger:         logger,
+		metrics:        metrics,
 	}
 }
 
@@ -233,74 +670,170 @@ func (c *OpenERAPIClient) Name() string {
 
 // GetLatestRate retrieves the latest exchange rate from open.er-api.com
 func (c *OpenERAPIClient) GetLatestRate(ctx context.Context, baseCurrency, targetCurrency string) (*models.ExchangeRate, error) {
-	url := fmt.Sprintf("%s/latest/%s", c.baseURL, baseCurrency)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	table, err := c.GetLatestRates(ctx, baseCurrency)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	rate, exists := table.Rates[targetCurrency]
+	if !exists {
+		return nil, fmt.Errorf("%w for %s", ErrRateNotFound, targetCurrency)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
+	return &models.ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           rate,
+		Provider:       c.name,
+		FetchedAt:      table.FetchedAt,
+	}, nil
+}
 
-	var apiResp models.OpenERAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// GetLatestRates retrieves the full table of latest rates for a base currency from open.er-api.com
+func (c *OpenERAPIClient) GetLatestRates(ctx context.Context, baseCurrency string) (*models.RateTable, error) {
+	apiResp, err := c.get(ctx, c.endpoint("latest", baseCurrency))
+	if err != nil {
+		return nil, err
 	}
 
-	if apiResp.Result != "success" {
-		return nil, fmt.Errorf("API returned error result: %s", apiResp.Result)
+	return &models.RateTable{
+		BaseCurrency: baseCurrency,
+		Rates:        apiResp.Rates,
+		Provider:     c.name,
+		FetchedAt:    time.Now(),
+	}, nil
+}
+
+// GetHistoricalRate retrieves a historical exchange rate from open.er-api.com.
+// The history endpoint is only available on the paid tier, so a key is required.
+func (c *OpenERAPIClient) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error) {
+	table, err := c.GetHistoricalRates(ctx, baseCurrency, date)
+	if err != nil {
+		return nil, err
 	}
 
-	rate, exists := apiResp.Rates[targetCurrency]
+	rate, exists := table.Rates[targetCurrency]
 	if !exists {
-		return nil, fmt.Errorf("rate not found for %s", targetCurrency)
+		return nil, fmt.Errorf("%w for %s on %s", ErrRateNotFound, targetCurrency, date.Format("2006-01-02"))
 	}
 
-	return &models.ExchangeRate{
+	return &models.HistoricalRate{
 		BaseCurrency:   baseCurrency,
 		TargetCurrency: targetCurrency,
 		Rate:           rate,
+		Date:           date,
 		Provider:       c.name,
-		FetchedAt:      time.Now(),
+		FetchedAt:      table.FetchedAt,
 	}, nil
 }
 
-// GetHistoricalRate retrieves a historical exchange rate from open.er-api.com
-// Note: Historical rates endpoint may not be available in free tier
-func (c *OpenERAPIClient) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error) {
-	// For now, return an error indicating historical rates are not supported
-	// In a production environment, you might want to implement a fallback strategy
-	// or use a different provider that supports historical rates
-	return nil, fmt.Errorf("historical rates not supported by %s in free tier", c.name)
+// GetHistoricalRates retrieves the table of rates of a base currency on a
+// date from open.er-api.com. Like GetHistoricalRate it needs the paid tier.
+func (c *OpenERAPIClient) GetHistoricalRates(ctx context.Context, baseCurrency string, date time.Time) (*models.RateTable, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("historical rates in %s free tier: %w", c.name, ErrNotSupported)
+	}
+
+	url := c.endpoint("history", baseCurrency,
+		strconv.Itoa(date.Year()), strconv.Itoa(int(date.Month())), strconv.Itoa(date.Day()))
+
+	apiResp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RateTable{
+		BaseCurrency: baseCurrency,
+		Rates:        apiResp.Rates,
+		Provider:     c.name,
+		FetchedAt:    time.Now(),
+	}, nil
 }
 
 // GetSupportedCurrencies retrieves list of supported currencies from open.er-api.com
 func (c *OpenERAPIClient) GetSupportedCurrencies(ctx context.Context) ([]*models.Currency, error) {
 	// For open.er-api.com, we can get currencies by making a request to get rates for USD
 	// and then extract the currency codes from the response
-	url := fmt.Sprintf("%s/latest/USD", c.baseURL)
+	apiResp, err := c.get(ctx, c.endpoint("latest", "USD"))
+	if err != nil {
+		return nil, err
+	}
 
+	var currencies []*models.Currency
+	for code := range apiResp.Rates {
+		currencies = append(currencies, &models.Currency{
+			Code:        code,
+			Name:        code, // We don't have names, so use code as name
+			IsSupported: true,
+		})
+	}
+
+	return currencies, nil
+}
+
+// get fetches url, retrying transient failures according to the retry policy.
+// Retries stop early when the next backoff would outlive the context deadline.
+func (c *OpenERAPIClient) get(ctx context.Context, url string) (*models.OpenERAPIResponse, error) {
+	for attempt := 1; ; attempt++ {
+		apiResp, err := c.getOnce(ctx, url)
+		if err == nil {
+			c.metrics.ProviderRequests.With("provider", c.name, "outcome", "success").Add(1)
+			if attempt > 1 {
+				level.Info(c.logger).Log("msg", "provider request succeeded after retry", "provider", c.name, "attempts", attempt)
+			}
+			return apiResp, nil
+		}
+		c.metrics.ProviderRequests.With("provider", c.name, "outcome", "error").Add(1)
+
+		if ctx.Err() != nil || !c.retry.retryable(err) || attempt >= c.retry.maxAttempts {
+			if attempt > 1 {
+				level.Error(c.logger).Log("error", err, "msg", "provider request failed after retries", "provider", c.name, "attempts", attempt)
+			}
+			return nil, err
+		}
+
+		wait, ok := c.retry.backoff(attempt, err)
+		if !ok {
+			level.Warn(c.logger).Log("error", err, "msg", "not retrying, Retry-After exceeds maximum backoff", "provider", c.name, "attempts", attempt, "retry_after", wait)
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			level.Warn(c.logger).Log("error", err, "msg", "not retrying, backoff exceeds deadline", "provider", c.name, "attempts", attempt, "backoff", wait)
+			return nil, err
+		}
+
+		c.metrics.ProviderRetries.With("provider", c.name).Add(1)
+		level.Warn(c.logger).Log("error", err, "msg", "retrying provider request", "provider", c.name, "attempt", attempt, "backoff", wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// getOnce performs a single request against the provider
+func (c *OpenERAPIClient) getOnce(ctx context.Context, url string) (*models.OpenERAPIResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %s", c.redact(err.Error()))
 	}
+	c.authorize(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", c.redactError(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	var apiResp models.OpenERAPIResponse
@@ -309,29 +842,27 @@ func (c *OpenERAPIClient) GetSupportedCurrencies(ctx context.Context) ([]*models
 	}
 
 	if apiResp.Result != "success" {
+		if apiResp.ErrorType != "" {
+			return nil, fmt.Errorf("API returned error result: %s (%s)", apiResp.Result, apiResp.ErrorType)
+		}
 		return nil, fmt.Errorf("API returned error result: %s", apiResp.Result)
 	}
 
-	var currencies []*models.Currency
-	for code := range apiResp.Rates {
-		currencies = append(currencies, &models.Currency{
-			Code:        code,
-			Name:        code, // We don't have names, so use code as name
-			IsSupported: true,
-		})
+	// Paid tier responses carry the table under conversion_rates
+	if len(apiResp.Rates) == 0 {
+		apiResp.Rates = apiResp.ConversionRates
 	}
 
-	return currencies, nil
+	return &apiResp, nil
 }
 
 // HealthCheck performs a health check against the open.er-api.com API
 func (c *OpenERAPIClient) HealthCheck(ctx context.Context) error {
-	url := fmt.Sprintf("%s/latest/USD", c.baseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint("latest", "USD"), nil)
 	if err != nil {
-		return fmt.Errorf("failed to create health check request: %w", err)
+		return fmt.Errorf("failed to create health check request: %s", c.redact(err.Error()))
 	}
+	c.authorize(req)
 
 	// Use a shorter timeout for health checks
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -340,7 +871,7 @@ func (c *OpenERAPIClient) HealthCheck(ctx context.Context) error {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("health check failed: %w", err)
+		return fmt.Errorf("health check failed: %w", c.redactError(err))
 	}
 	defer resp.Body.Close()
 
@@ -376,6 +907,9 @@ func NewRedisCache(addr, password string, db int) (*RedisCache, error) {
 
 func (r *RedisCache) Get(ctx context.Context, key string, dest interface{}) error {
 	val, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return fmt.Errorf("%w: %s", ErrCacheMiss, key)
+	}
 	if err != nil {
 		return err
 	}
@@ -400,6 +934,42 @@ func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	return result > 0, nil
 }
 
+func (r *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// Keys returns the keys matching pattern using SCAN so Redis is never blocked
+func (r *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, pattern, 500).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (r *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// go-redis reports a missing key as -2 and a key without expiry as -1
+	switch ttl {
+	case -2:
+		return 0, fmt.Errorf("%w: %s", ErrCacheMiss, key)
+	case -1:
+		return -1, nil
+	}
+	return ttl, nil
+}
+
 func (r *RedisCache) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
 }
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log"
+)
+
+// fakeProvider serves fixed latest rate tables, or fails with err
+type fakeProvider struct {
+	name  string
+	rates map[string]float64
+	err   error
+	calls atomic.Int32
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) GetLatestRates(ctx context.Context, baseCurrency string) (*models.RateTable, error) {
+	p.calls.Add(1)
+	if p.err != nil {
+		return nil, p.err
+	}
+	rates := make(map[string]float64, len(p.rates))
+	for currency, rate := range p.rates {
+		rates[currency] = rate
+	}
+	return &models.RateTable{BaseCurrency: baseCurrency, Rates: rates, Provider: p.name, FetchedAt: time.Now()}, nil
+}
+
+func (p *fakeProvider) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *fakeProvider) GetHistoricalRates(ctx context.Context, baseCurrency string, date time.Time) (*models.RateTable, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *fakeProvider) GetSupportedCurrencies(ctx context.Context) ([]*models.Currency, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *fakeProvider) HealthCheck(ctx context.Context) error { return p.err }
+
+// newTestRepository creates a repository backed by memory and temporary files,
+// calling the given providers in order
+func newTestRepository(t *testing.T, config *configs.Config, clients ...ProviderClient) *rateRepository {
+	t.Helper()
+	dir := t.TempDir()
+	r := &rateRepository{
+		logger:         log.NewNopLogger(),
+		metrics:        NopMetrics(),
+		cache:          NewInMemoryCache(),
+		overrides:      &FileOverrideStore{path: filepath.Join(dir, "overrides.json"), overrides: make(map[string]*models.RateOverride)},
+		history:        &FileHistoryStore{path: filepath.Join(dir, "history.json"), rates: make(map[string]map[string]*models.HistoricalRate)},
+		quarantined:    make(map[string]time.Time),
+		providerHealth: make(map[string]providerHealth),
+	}
+
+	providers := make([]*provider, len(clients))
+	for i, client := range clients {
+		cfg := configs.ProviderConfig{Name: client.Name(), Priority: i, CircuitBreaker: configs.CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}}
+		providers[i] = &provider{client: client, breaker: NewCircuitBreaker(cfg.Name, cfg.CircuitBreaker, r.logger), priority: i, config: cfg}
+	}
+	r.state.Store(&repositoryState{config: config, providers: providers})
+	return r
+}
+
+func TestGetLatestRatesAppliesActiveOverrides(t *testing.T) {
+	p := &fakeProvider{name: "p", rates: map[string]float64{"EUR": 0.9, "GBP": 0.8}}
+	r := newTestRepository(t, configs.Default(), p)
+	ctx := context.Background()
+
+	var notified []*models.RateTable
+	r.AddRateListener(func(table *models.RateTable) { notified = append(notified, table) })
+
+	now := time.Now()
+	until := now.Add(-time.Minute)
+	for _, override := range []*models.RateOverride{
+		{BaseCurrency: "USD", TargetCurrency: "EUR", Rate: 1.5, ValidFrom: now.Add(-time.Hour), Author: "ops"},
+		{BaseCurrency: "USD", TargetCurrency: "GBP", Rate: 2, ValidFrom: now.Add(-time.Hour), ValidUntil: &until},
+		{BaseCurrency: "USD", TargetCurrency: "JPY", Rate: 3, ValidFrom: now.Add(time.Hour)},
+		{BaseCurrency: "EUR", TargetCurrency: "GBP", Rate: 4, ValidFrom: now.Add(-time.Hour)},
+	} {
+		if err := r.SetOverride(ctx, override); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, source := range []string{"provider", "cache"} {
+		table, err := r.GetLatestRates(ctx, "USD")
+		if err != nil {
+			t.Fatalf("%s: GetLatestRates: %v", source, err)
+		}
+		if table.Rates["EUR"] != 1.5 || table.Rates["GBP"] != 0.8 {
+			t.Errorf("%s: rates = %v, want EUR pinned to 1.5 and GBP from the provider", source, table.Rates)
+		}
+		if _, ok := table.Rates["JPY"]; ok {
+			t.Errorf("%s: an override that has not started was applied", source)
+		}
+		if sources := table.Sources["EUR"]; len(sources) != 1 || sources[0].Provider != OverrideProvider {
+			t.Errorf("%s: EUR sources = %+v, want the override", source, sources)
+		}
+	}
+	if p.calls.Load() != 1 {
+		t.Errorf("provider called %d times, want 1", p.calls.Load())
+	}
+	if len(notified) != 1 || notified[0].Rates["EUR"] != 1.5 {
+		t.Errorf("listeners saw %d tables, want one with the override applied", len(notified))
+	}
+
+	// The cache and the validation baseline keep the provider's value
+	for _, key := range []string{latestTableKey("USD"), baselineTableKey("USD")} {
+		var stored models.RateTable
+		if err := r.cache.Get(ctx, key, &stored); err != nil || stored.Rates["EUR"] != 0.9 {
+			t.Errorf("%s holds %v (%v), want the provider rate", key, stored.Rates, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"reflect"
+	"sort"
+
+	"exchange-rate-service/configs"
+
+	"github.com/go-kit/log/level"
+)
+
+// repositoryState is the configuration and the provider clients built from
+// it. It is replaced as a whole on reload, so a request never mixes the
+// settings of two configurations.
+type repositoryState struct {
+	config    *configs.Config
+	providers []*provider
+}
+
+// cfg returns the current configuration
+func (r *rateRepository) cfg() *configs.Config {
+	return r.state.Load().config
+}
+
+// providerList returns the current providers in priority order
+func (r *rateRepository) providerList() []*provider {
+	return r.state.Load().providers
+}
+
+// Reconfigure swaps in a new configuration and provider clients. Requests
+// already in flight finish with the clients they started with. The cache and
+// override store are left as they are; changing them needs a restart.
+func (r *rateRepository) Reconfigure(config *configs.Config) {
+	providers := r.buildProviders(config, r.providerList())
+	r.state.Store(&repositoryState{config: config, providers: providers})
+
+	r.mu.Lock()
+	r.providerHealth = make(map[string]providerHealth)
+	r.mu.Unlock()
+
+	level.Info(r.logger).Log("msg", "repository reconfigured", "providers", len(providers))
+}
+
+// buildProviders creates a client for each configured provider, sorted by
+// priority. A provider whose circuit breaker settings are unchanged from
+// previous keeps its breaker, so a reload doesn't reset an open circuit.
+func (r *rateRepository) buildProviders(config *configs.Config, previous []*provider) []*provider {
+	breakers := make(map[string]*provider, len(previous))
+	for _, p := range previous {
+		breakers[p.config.Name] = p
+	}
+
+	providers := make([]*provider, 0, len(config.Providers))
+	for _, providerCfg := range config.Providers {
+		breaker := NewCircuitBreaker(providerCfg.Name, providerCfg.CircuitBreaker, r.logger)
+		if old, ok := breakers[providerCfg.Name]; ok && reflect.DeepEqual(old.config.CircuitBreaker, providerCfg.CircuitBreaker) {
+			breaker = old.breaker
+		}
+		providers = append(providers, &provider{
+			client:   NewOpenERAPIClient(providerCfg, r.logger, r.metrics),
+			breaker:  breaker,
+			priority: providerCfg.Priority,
+			config:   providerCfg,
+		})
+	}
+	sort.SliceStable(providers, func(i, j int) bool {
+		return providers[i].priority < providers[j].priority
+	})
+	return providers
+}
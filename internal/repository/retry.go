@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"exchange-rate-service/configs"
+)
+
+// StatusError is returned when a provider answers with an unexpected HTTP status
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned status %d", e.StatusCode)
+}
+
+// retryPolicy decides whether and when a failed provider call is retried
+type retryPolicy struct {
+	maxAttempts     int
+	initialBackoff  time.Duration
+	maxBackoff      time.Duration
+	multiplier      float64
+	jitter          float64
+	retryableStatus map[int]bool
+}
+
+func newRetryPolicy(config configs.RetryConfig) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts:     config.MaxAttempts,
+		initialBackoff:  config.InitialBackoff,
+		maxBackoff:      config.MaxBackoff,
+		multiplier:      config.Multiplier,
+		jitter:          config.Jitter,
+		retryableStatus: make(map[int]bool),
+	}
+	if policy.maxAttempts <= 0 {
+		policy.maxAttempts = 1
+	}
+	if policy.initialBackoff <= 0 {
+		policy.initialBackoff = 200 * time.Millisecond
+	}
+	if policy.maxBackoff < policy.initialBackoff {
+		policy.maxBackoff = policy.initialBackoff
+	}
+	if policy.multiplier < 1 {
+		policy.multiplier = 1
+	}
+	if policy.jitter < 0 || policy.jitter > 1 {
+		policy.jitter = 0
+	}
+	for _, code := range config.RetryableStatusCodes {
+		policy.retryableStatus[code] = true
+	}
+	return policy
+}
+
+// retryable reports whether err is worth another attempt: transport failures
+// and the configured status codes are, malformed or negative answers are not
+func (p retryPolicy) retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return p.retryableStatus[statusErr.StatusCode]
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// backoff returns how long to wait before the attempt following the given one.
+// A Retry-After longer than the maximum backoff is not waited out: ok is false
+// and the caller should give up rather than hold the request that long.
+func (p retryPolicy) backoff(attempt int, err error) (wait time.Duration, ok bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter, statusErr.RetryAfter <= p.maxBackoff
+	}
+
+	delay := float64(p.initialBackoff) * math.Pow(p.multiplier, float64(attempt-1))
+	if delay > float64(p.maxBackoff) {
+		delay = float64(p.maxBackoff)
+	}
+	if p.jitter > 0 {
+		delay += delay * p.jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay), true
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := newRetryPolicy(configs.RetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     3,
+	})
+	transportErr := &url.Error{Op: "Get", URL: "http://provider", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		want    time.Duration
+		wantOK  bool
+	}{
+		{"first retry", 1, transportErr, 100 * time.Millisecond, true},
+		{"grows by multiplier", 2, transportErr, 300 * time.Millisecond, true},
+		{"capped at max backoff", 4, transportErr, time.Second, true},
+		{"status without Retry-After", 1, &StatusError{StatusCode: 503}, 100 * time.Millisecond, true},
+		{"Retry-After replaces backoff", 1, &StatusError{StatusCode: 429, RetryAfter: 700 * time.Millisecond}, 700 * time.Millisecond, true},
+		{"Retry-After at max backoff", 1, &StatusError{StatusCode: 429, RetryAfter: time.Second}, time.Second, true},
+		{"Retry-After beyond max backoff", 1, &StatusError{StatusCode: 429, RetryAfter: time.Hour}, time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := policy.backoff(tt.attempt, tt.err)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("backoff(%d, %v) = %v, %v; want %v, %v", tt.attempt, tt.err, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	policy := newRetryPolicy(configs.RetryConfig{InitialBackoff: time.Second, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5})
+	for i := 0; i < 100; i++ {
+		got, _ := policy.backoff(1, errors.New("timeout"))
+		if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("backoff with 50%% jitter = %v, want within 0.5s..1.5s", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"soon", 0},
+		{"Fri, 01 Mar 2024 12:00:30 GMT", 30 * time.Second},
+		{"Fri, 01 Mar 2024 11:59:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
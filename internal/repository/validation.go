@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+)
+
+// ErrRateQuarantined is returned when provider data failed validation and no
+// previously accepted table is available to serve instead
+var ErrRateQuarantined = errors.New("rate table quarantined")
+
+// rateMove describes a rate that changed more than allowed since the last accepted table
+type rateMove struct {
+	Currency      string  `json:"currency"`
+	Previous      float64 `json:"previous"`
+	Current       float64 `json:"current"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// quarantinedTable is what gets stored for a table that failed validation
+type quarantinedTable struct {
+	Table         *models.RateTable `json:"table"`
+	Moves         []rateMove        `json:"moves"`
+	QuarantinedAt time.Time         `json:"quarantined_at"`
+}
+
+func latestTableKey(baseCurrency string) string {
+	return fmt.Sprintf("rates:%s:latest", baseCurrency)
+}
+
+func baselineTableKey(baseCurrency string) string {
+	return fmt.Sprintf("rates:%s:baseline", baseCurrency)
+}
+
+func quarantineTableKey(baseCurrency string) string {
+	return fmt.Sprintf("quarantine:rates:%s", baseCurrency)
+}
+
+// validRate reports whether rate is a usable positive, finite, plausible value
+func (r *rateRepository) validRate(rate float64) bool {
+	return ValidRate(r.cfg().Validation, rate)
+}
+
+// ValidRate reports whether rate is positive, finite and within the bounds
+// of cfg. Rates from providers are checked with it before they are served or
+// stored.
+func ValidRate(cfg configs.ValidationConfig, rate float64) bool {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return false
+	}
+	if cfg.MinRate > 0 && rate < cfg.MinRate {
+		return false
+	}
+	if cfg.MaxRate > 0 && rate > cfg.MaxRate {
+		return false
+	}
+	return true
+}
+
+// sanitizeTable drops rates that are zero, negative, NaN, infinite or outside
+// the configured bounds
+func (r *rateRepository) sanitizeTable(table *models.RateTable) {
+	var rejected []string
+	for currency, rate := range table.Rates {
+		if !r.validRate(rate) {
+			rejected = append(rejected, fmt.Sprintf("%s=%g", currency, rate))
+			delete(table.Rates, currency)
+		}
+	}
+	if len(rejected) > 0 {
+		sort.Strings(rejected)
+		level.Warn(r.logger).Log("alert", "invalid_rates", "msg", "rejected invalid rates from provider",
+			"provider", table.Provider, "base", table.BaseCurrency, "rates", strings.Join(rejected, ","))
+	}
+}
+
+// acceptTable validates a freshly fetched table before it is cached. Invalid
+// rates are dropped; if any remaining rate moved more than the configured
+// percentage since the last accepted table, the whole table is quarantined.
+func (r *rateRepository) acceptTable(ctx context.Context, table *models.RateTable) error {
+	r.sanitizeTable(table)
+	if len(table.Rates) == 0 {
+		return fmt.Errorf("%w: no valid rates for %s from %s", ErrRateQuarantined, table.BaseCurrency, table.Provider)
+	}
+
+	var baseline models.RateTable
+	if err := r.cache.Get(ctx, baselineTableKey(table.BaseCurrency), &baseline); err != nil {
+		return nil
+	}
+
+	maxChange := r.cfg().Validation.MaxChangePercent
+	if maxChange <= 0 {
+		return nil
+	}
+
+	var moves []rateMove
+	for currency, rate := range table.Rates {
+		previous, ok := baseline.Rates[currency]
+		if !ok {
+			continue
+		}
+		if change := deviationPercent(rate, previous); change > maxChange {
+			moves = append(moves, rateMove{Currency: currency, Previous: previous, Current: rate, ChangePercent: change})
+		}
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+
+	sort.Slice(moves, func(i, j int) bool { return moves[i].Currency < moves[j].Currency })
+	r.quarantine(ctx, table, moves)
+	return fmt.Errorf("%w: %d rates for %s moved more than %.2f%%", ErrRateQuarantined, len(moves), table.BaseCurrency, maxChange)
+}
+
+// quarantine stores a suspicious table for inspection, raises an alert and
+// marks validation as degraded in the health report
+func (r *rateRepository) quarantine(ctx context.Context, table *models.RateTable, moves []rateMove) {
+	for _, move := range moves {
+		level.Warn(r.logger).Log("alert", "rate_anomaly", "msg", "rate moved beyond threshold, table quarantined",
+			"provider", table.Provider, "base", table.BaseCurrency, "target", move.Currency,
+			"previous", move.Previous, "current", move.Current, "change_percent", move.ChangePercent)
+	}
+	r.metrics.RateAnomalies.With("base", table.BaseCurrency).Add(1)
+
+	entry := quarantinedTable{Table: table, Moves: moves, QuarantinedAt: time.Now()}
+	if err := r.cache.Set(ctx, quarantineTableKey(table.BaseCurrency), entry, r.cfg().Validation.QuarantineTTL); err != nil {
+		level.Error(r.logger).Log("error", err, "msg", "failed to store quarantined table")
+	}
+
+	r.mu.Lock()
+	r.quarantined[table.BaseCurrency] = entry.QuarantinedAt
+	r.mu.Unlock()
+}
+
+// releaseQuarantine clears the degraded state once a valid table is accepted
+func (r *rateRepository) releaseQuarantine(baseCurrency string) {
+	r.mu.Lock()
+	delete(r.quarantined, baseCurrency)
+	r.mu.Unlock()
+}
+
+// validationHealth reports "degraded" while any base currency has a recently
+// quarantined table, "healthy" otherwise
+func (r *rateRepository) validationHealth() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ttl := r.cfg().Validation.QuarantineTTL
+	for base, at := range r.quarantined {
+		if ttl > 0 && time.Since(at) > ttl {
+			delete(r.quarantined, base)
+		}
+	}
+	if len(r.quarantined) > 0 {
+		return "degraded"
+	}
+	return "healthy"
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+)
+
+func validationConfig() *configs.Config {
+	config := configs.Default()
+	config.Aggregation.Mode = AggregationFailover
+	config.Validation = configs.ValidationConfig{
+		MinRate:          1e-6,
+		MaxRate:          1e6,
+		MaxChangePercent: 10,
+		BaselineTTL:      time.Hour,
+		QuarantineTTL:    time.Hour,
+	}
+	return config
+}
+
+func TestSanitizeTableDropsInvalidRates(t *testing.T) {
+	r := newTestRepository(t, validationConfig())
+	table := &models.RateTable{BaseCurrency: "USD", Rates: map[string]float64{
+		"EUR": 0.9,
+		"ZER": 0,
+		"NEG": -1,
+		"NAN": math.NaN(),
+		"INF": math.Inf(1),
+		"LOW": 1e-9,
+		"BIG": 1e7,
+	}}
+
+	r.sanitizeTable(table)
+	if len(table.Rates) != 1 || table.Rates["EUR"] != 0.9 {
+		t.Errorf("rates after sanitizing = %v, want only EUR", table.Rates)
+	}
+}
+
+func TestAcceptTableQuarantinesLargeMoves(t *testing.T) {
+	r := newTestRepository(t, validationConfig())
+	ctx := context.Background()
+
+	// Without a baseline there is nothing to compare against
+	if err := r.acceptTable(ctx, &models.RateTable{BaseCurrency: "USD", Rates: map[string]float64{"EUR": 0.9}}); err != nil {
+		t.Fatalf("first table rejected: %v", err)
+	}
+	r.cache.Set(ctx, baselineTableKey("USD"), &models.RateTable{BaseCurrency: "USD", Rates: map[string]float64{"EUR": 0.9, "GBP": 0.8}}, time.Hour)
+
+	if err := r.acceptTable(ctx, &models.RateTable{BaseCurrency: "USD", Rates: map[string]float64{"EUR": 0.95, "GBP": 0.8}}); err != nil {
+		t.Errorf("move within the threshold rejected: %v", err)
+	}
+	if r.validationHealth() != "healthy" {
+		t.Errorf("validation health = %s, want healthy", r.validationHealth())
+	}
+
+	err := r.acceptTable(ctx, &models.RateTable{BaseCurrency: "USD", Provider: "p", Rates: map[string]float64{"EUR": 1.8, "GBP": 0.8}})
+	if !errors.Is(err, ErrRateQuarantined) {
+		t.Fatalf("error for a doubled rate = %v, want ErrRateQuarantined", err)
+	}
+	var stored quarantinedTable
+	if err := r.cache.Get(ctx, quarantineTableKey("USD"), &stored); err != nil {
+		t.Fatalf("quarantined table not stored: %v", err)
+	}
+	if len(stored.Moves) != 1 || stored.Moves[0].Currency != "EUR" || stored.Moves[0].ChangePercent != 100 {
+		t.Errorf("stored moves = %+v, want EUR moving 100%%", stored.Moves)
+	}
+	if r.validationHealth() != "degraded" {
+		t.Errorf("validation health = %s, want degraded", r.validationHealth())
+	}
+
+	// A table with nothing valid left is rejected as well
+	err = r.acceptTable(ctx, &models.RateTable{BaseCurrency: "USD", Rates: map[string]float64{"EUR": -1}})
+	if !errors.Is(err, ErrRateQuarantined) {
+		t.Errorf("error for an all-invalid table = %v, want ErrRateQuarantined", err)
+	}
+}
+
+func TestGetLatestRatesServesBaselineWhileQuarantined(t *testing.T) {
+	config := validationConfig()
+	p := &fakeProvider{name: "p", rates: map[string]float64{"EUR": 0.9}}
+	r := newTestRepository(t, config, p)
+	ctx := context.Background()
+
+	if _, err := r.GetLatestRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetLatestRates: %v", err)
+	}
+
+	p.rates = map[string]float64{"EUR": 9}
+	for i := 0; i < 3; i++ {
+		r.cache.Delete(ctx, latestTableKey("USD"))
+		table, err := r.GetLatestRates(ctx, "USD")
+		if err != nil {
+			t.Fatalf("GetLatestRates while quarantined: %v", err)
+		}
+		if !table.IsStale || table.Rates["EUR"] != 0.9 {
+			t.Errorf("served %+v, want the stale baseline", table)
+		}
+	}
+
+	// Rejected data says nothing about the provider's availability
+	if state := r.providerList()[0].breaker.State(); state != CircuitClosed {
+		t.Errorf("breaker after quarantined tables = %s, want closed", state)
+	}
+
+	p.rates = map[string]float64{"EUR": 0.91}
+	r.cache.Delete(ctx, latestTableKey("USD"))
+	if table, err := r.GetLatestRates(ctx, "USD"); err != nil || table.IsStale {
+		t.Errorf("GetLatestRates after recovery = %+v, %v; want a fresh table", table, err)
+	}
+	if r.validationHealth() != "healthy" {
+		t.Errorf("validation health after recovery = %s, want healthy", r.validationHealth())
+	}
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"exchange-rate-service/configs"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Headers sent with every webhook. The signature covers the timestamp so a
+// captured request can't be replayed later with a valid signature.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// SignWebhook returns the signature of a webhook body sent at timestamp (Unix
+// seconds): "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the alert's secret
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookResult reports how a webhook delivery went
+type WebhookResult struct {
+	Attempts   int
+	StatusCode int
+}
+
+// ErrWebhookBlocked is returned for a webhook whose host resolves to an
+// address that is not public
+var ErrWebhookBlocked = errors.New("webhook destination is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598)
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// PublicAddress reports whether ip may receive webhooks: it is not loopback,
+// private, link-local (which includes cloud metadata endpoints), unspecified,
+// multicast or carrier-grade NAT
+func PublicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// WebhookClient POSTs signed JSON payloads to webhook receivers
+type WebhookClient struct {
+	client *http.Client
+	retry  retryPolicy
+	logger log.Logger
+}
+
+// NewWebhookClient creates a webhook client using the alert delivery settings.
+// Redirects are not followed, and unless config.AllowPrivateWebhooks is set
+// connections are only made to public addresses. The address is checked when
+// dialing, after DNS resolution, so a host can't be pointed at the internal
+// network once its alert is registered.
+func NewWebhookClient(config configs.AlertsConfig, logger log.Logger) *WebhookClient {
+	client := &http.Client{
+		Timeout: config.Timeout,
+		// A redirect is answered like any other non-2xx status
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	if !config.AllowPrivateWebhooks {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublicOnly}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dialer.DialContext
+		// A proxy would make the connection on our behalf, unchecked
+		transport.Proxy = nil
+		client.Transport = transport
+	}
+	return &WebhookClient{
+		client: client,
+		retry:  newRetryPolicy(config.Retry),
+		logger: logger,
+	}
+}
+
+// dialPublicOnly refuses connections to addresses that are not public
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !PublicAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookBlocked, host)
+	}
+	return nil
+}
+
+// Send POSTs body to url, retrying transport failures and retryable statuses
+// with backoff. Any 2xx answer counts as delivered.
+func (c *WebhookClient) Send(ctx context.Context, url, secret, deliveryID string, body []byte) (WebhookResult, error) {
+	var result WebhookResult
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+		statusCode, err := c.post(ctx, url, secret, deliveryID, body)
+		result.StatusCode = statusCode
+		if err == nil {
+			return result, nil
+		}
+
+		if ctx.Err() != nil || errors.Is(err, ErrWebhookBlocked) || !c.retry.retryable(err) || attempt >= c.retry.maxAttempts {
+			return result, err
+		}
+
+		wait, ok := c.retry.backoff(attempt, err)
+		if !ok {
+			level.Warn(c.logger).Log("error", err, "msg", "not retrying webhook delivery, Retry-After exceeds maximum backoff", "delivery", deliveryID, "attempt", attempt, "retry_after", wait)
+			return result, err
+		}
+		level.Warn(c.logger).Log("error", err, "msg", "retrying webhook delivery", "delivery", deliveryID, "attempt", attempt, "backoff", wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
+
+// post performs a single delivery attempt, signed at the time it is sent
+func (c *WebhookClient) post(ctx context.Context, url, secret, deliveryID string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "exchange-rate-service-webhooks")
+	req.Header.Set(WebhookDeliveryHeader, deliveryID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, timestamp, body))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+
+	"github.com/go-kit/log"
+)
+
+func testAlertsConfig() configs.AlertsConfig {
+	return configs.AlertsConfig{
+		Timeout:              time.Second,
+		AllowPrivateWebhooks: true, // the receivers listen on loopback
+		Retry: configs.RetryConfig{
+			MaxAttempts:          3,
+			InitialBackoff:       time.Millisecond,
+			MaxBackoff:           5 * time.Millisecond,
+			Multiplier:           2,
+			RetryableStatusCodes: []int{500, 503},
+		},
+	}
+}
+
+func TestWebhookClientSignsAndRetries(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"alert_id":"a1"}`)
+
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		if err != nil {
+			t.Errorf("bad timestamp header: %v", err)
+		}
+		if got, want := r.Header.Get(WebhookSignatureHeader), SignWebhook(secret, timestamp, received); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if r.Header.Get(WebhookDeliveryHeader) != "d1" {
+			t.Errorf("delivery header = %q, want d1", r.Header.Get(WebhookDeliveryHeader))
+		}
+
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	client := NewWebhookClient(testAlertsConfig(), log.NewNopLogger())
+	result, err := client.Send(context.Background(), receiver.URL, secret, "d1", body)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if result.Attempts != 3 || result.StatusCode != http.StatusNoContent {
+		t.Errorf("result = %+v, want 3 attempts ending in 204", result)
+	}
+}
+
+func TestWebhookClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	client := NewWebhookClient(testAlertsConfig(), log.NewNopLogger())
+	result, err := client.Send(context.Background(), receiver.URL, "secret", "d1", []byte(`{}`))
+	if err == nil {
+		t.Fatal("expected an error for a 400 answer")
+	}
+	if result.Attempts != 1 || result.StatusCode != http.StatusBadRequest || calls.Load() != 1 {
+		t.Errorf("result = %+v after %d calls, want a single attempt", result, calls.Load())
+	}
+}
+
+func TestWebhookClientGivesUpOnLongRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	client := NewWebhookClient(testAlertsConfig(), log.NewNopLogger())
+	start := time.Now()
+	result, err := client.Send(context.Background(), receiver.URL, "secret", "d1", []byte(`{}`))
+	if err == nil {
+		t.Fatal("expected an error for a 503 answer")
+	}
+	if result.Attempts != 1 || calls.Load() != 1 || time.Since(start) > time.Second {
+		t.Errorf("result = %+v after %d calls in %v, want a single attempt without waiting", result, calls.Load(), time.Since(start))
+	}
+}
+
+func TestWebhookClientBlocksPrivateAddresses(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer receiver.Close()
+
+	config := testAlertsConfig()
+	config.AllowPrivateWebhooks = false
+	client := NewWebhookClient(config, log.NewNopLogger())
+
+	// By name as well as by address: the check happens after resolution
+	for _, url := range []string{receiver.URL, strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1)} {
+		result, err := client.Send(context.Background(), url, "secret", "d1", []byte(`{}`))
+		if !errors.Is(err, ErrWebhookBlocked) {
+			t.Errorf("Send(%s) = %v, want ErrWebhookBlocked", url, err)
+		}
+		if result.Attempts != 1 {
+			t.Errorf("Send(%s) made %d attempts, want 1", url, result.Attempts)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Errorf("the loopback receiver was called %d times", calls.Load())
+	}
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	var redirected atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected.Add(1)
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	client := NewWebhookClient(testAlertsConfig(), log.NewNopLogger())
+	result, err := client.Send(context.Background(), receiver.URL, "secret", "d1", []byte(`{}`))
+	if err == nil || result.StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("Send = %+v, %v; want the redirect reported as a failure", result, err)
+	}
+	if redirected.Load() != 0 {
+		t.Error("the redirect was followed")
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	for address, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00:ec2::254":    false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		if got := PublicAddress(net.ParseIP(address)); got != want {
+			t.Errorf("PublicAddress(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{}" keyed with "key"
+	want := "sha256=9d713ed406bb7076d4123f0dc2c39d2df5c654ed4b0cd56b52c8b4c940bd63ae"
+	if got := SignWebhook("key", 1700000000, []byte("{}")); got != want {
+		t.Errorf("SignWebhook = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// AdminService defines administrative operations on rate data
+type AdminService interface {
+	SetOverride(ctx context.Context, req *models.OverrideRequest) (*models.RateOverride, error)
+	ListOverrides(ctx context.Context) ([]*models.RateOverride, error)
+	DeleteOverride(ctx context.Context, baseCurrency, targetCurrency string) error
+	ListCacheKeys(ctx context.Context, pattern string) ([]string, error)
+	GetCacheEntry(ctx context.Context, key string) (*models.CacheEntry, error)
+	InvalidateCache(ctx context.Context, baseCurrency, targetCurrency string) (*models.CacheInvalidation, error)
+	RefreshRates(ctx context.Context, baseCurrency string) (*models.RateTable, error)
+	ImportHistory(ctx context.Context, r io.Reader, format, onConflict string) (*models.HistoryImportResult, error)
+}
+
+// adminService implements AdminService
+type adminService struct {
+	rateRepo repository.RateRepository
+	logger   log.Logger
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(rateRepo repository.RateRepository, logger log.Logger) AdminService {
+	return &adminService{
+		rateRepo: rateRepo,
+		logger:   logger,
+	}
+}
+
+// SetOverride pins the rate of a currency pair
+func (s *adminService) SetOverride(ctx context.Context, req *models.OverrideRequest) (*models.RateOverride, error) {
+	level.Debug(s.logger).Log("method", "SetOverride", "base", req.BaseCurrency, "target", req.TargetCurrency, "author", req.Author)
+
+	override, err := s.buildOverride(req, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rateRepo.SetOverride(ctx, override); err != nil {
+		level.Error(s.logger).Log("error", err, "method", "SetOverride")
+		return nil, errors.NewInternalError("failed to store override", err)
+	}
+
+	return override, nil
+}
+
+// ListOverrides returns all stored overrides
+func (s *adminService) ListOverrides(ctx context.Context) ([]*models.RateOverride, error) {
+	level.Debug(s.logger).Log("method", "ListOverrides")
+
+	overrides, err := s.rateRepo.ListOverrides(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "ListOverrides")
+		return nil, errors.NewInternalError("failed to list overrides", err)
+	}
+
+	return overrides, nil
+}
+
+// DeleteOverride removes the override for a currency pair
+func (s *adminService) DeleteOverride(ctx context.Context, baseCurrency, targetCurrency string) error {
+	level.Debug(s.logger).Log("method", "DeleteOverride", "base", baseCurrency, "target", targetCurrency)
+
+	if err := validateCurrencyPair(baseCurrency, targetCurrency); err != nil {
+		return err
+	}
+
+	if err := s.rateRepo.DeleteOverride(ctx, strings.ToUpper(baseCurrency), strings.ToUpper(targetCurrency)); err != nil {
+		if stderrors.Is(err, repository.ErrOverrideNotFound) {
+			return errors.NewNotFoundError("no override for " + baseCurrency + "/" + targetCurrency)
+		}
+		level.Error(s.logger).Log("error", err, "method", "DeleteOverride")
+		return errors.NewInternalError("failed to delete override", err)
+	}
+
+	return nil
+}
+
+// ListCacheKeys returns the cached keys matching a glob pattern
+func (s *adminService) ListCacheKeys(ctx context.Context, pattern string) ([]string, error) {
+	level.Debug(s.logger).Log("method", "ListCacheKeys", "pattern", pattern)
+
+	keys, err := s.rateRepo.ListCacheKeys(ctx, pattern)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "ListCacheKeys")
+		return nil, errors.NewCacheError("failed to list cache keys", err)
+	}
+
+	return keys, nil
+}
+
+// GetCacheEntry returns a cached value with its remaining TTL
+func (s *adminService) GetCacheEntry(ctx context.Context, key string) (*models.CacheEntry, error) {
+	level.Debug(s.logger).Log("method", "GetCacheEntry", "key", key)
+
+	if key == "" {
+		return nil, errors.NewValidationError("key is required", "key cannot be empty")
+	}
+
+	entry, err := s.rateRepo.GetCacheEntry(ctx, key)
+	if err != nil {
+		if stderrors.Is(err, repository.ErrCacheMiss) {
+			return nil, errors.NewNotFoundError("cache key not found: " + key)
+		}
+		level.Error(s.logger).Log("error", err, "method", "GetCacheEntry")
+		return nil, errors.NewCacheError("failed to read cache entry", err)
+	}
+
+	return entry, nil
+}
+
+// InvalidateCache drops cached data for a pair, a base currency or everything
+func (s *adminService) InvalidateCache(ctx context.Context, baseCurrency, targetCurrency string) (*models.CacheInvalidation, error) {
+	level.Debug(s.logger).Log("method", "InvalidateCache", "base", baseCurrency, "target", targetCurrency)
+
+	if baseCurrency == "" && targetCurrency != "" {
+		return nil, errors.NewValidationError("base currency is required", "target cannot be invalidated without base")
+	}
+	if baseCurrency != "" && targetCurrency != "" {
+		if err := validateCurrencyPair(baseCurrency, targetCurrency); err != nil {
+			return nil, err
+		}
+	}
+	// The codes end up in key patterns, where anything but letters could match other keys
+	if baseCurrency != "" {
+		if err := validateCurrencyCode("base", baseCurrency); err != nil {
+			return nil, err
+		}
+	}
+	if targetCurrency != "" {
+		if err := validateCurrencyCode("target", targetCurrency); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.rateRepo.InvalidateCache(ctx, strings.ToUpper(baseCurrency), strings.ToUpper(targetCurrency))
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "InvalidateCache")
+		return nil, errors.NewCacheError("failed to invalidate cache", err)
+	}
+
+	return result, nil
+}
+
+// RefreshRates forces a fresh provider fetch of the rates for a base currency
+func (s *adminService) RefreshRates(ctx context.Context, baseCurrency string) (*models.RateTable, error) {
+	level.Debug(s.logger).Log("method", "RefreshRates", "base", baseCurrency)
+
+	if baseCurrency == "" {
+		return nil, errors.NewValidationError("base currency is required", "base cannot be empty")
+	}
+	if err := validateCurrencyCode("base", baseCurrency); err != nil {
+		return nil, err
+	}
+
+	table, err := s.rateRepo.RefreshRates(ctx, strings.ToUpper(baseCurrency))
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "RefreshRates")
+		return nil, errors.NewProviderError("failed to refresh rates", err)
+	}
+
+	return table, nil
+}
+
+// ImportHistory loads a CSV, JSON or NDJSON file of historical rates into the
+// history store. The whole file is validated before anything is stored.
+func (s *adminService) ImportHistory(ctx context.Context, r io.Reader, format, onConflict string) (*models.HistoryImportResult, error) {
+	level.Debug(s.logger).Log("method", "ImportHistory", "format", format, "on_conflict", onConflict)
+
+	rates, err := DecodeHistoricalRates(r, format, "import file")
+	if err != nil {
+		return nil, errors.NewValidationError(err.Error(), "the file must be CSV with a date, base_currency, target_currency and rate header, a JSON array or NDJSON")
+	}
+
+	result, err := importHistory(ctx, s.rateRepo.GetStoredRates, s.rateRepo.StoreRates, rates, onConflict)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "ImportHistory")
+		return result, err
+	}
+
+	level.Debug(s.logger).Log("method", "ImportHistory", "received", result.Received, "stored", result.Stored, "skipped", result.Skipped)
+	return result, nil
+}
+
+// buildOverride validates an override request and converts it into an override
+func (s *adminService) buildOverride(req *models.OverrideRequest, now time.Time) (*models.RateOverride, error) {
+	if err := validateCurrencyPair(req.BaseCurrency, req.TargetCurrency); err != nil {
+		return nil, err
+	}
+	if req.Rate <= 0 {
+		return nil, errors.NewValidationError("rate must be positive", "rate must be greater than 0")
+	}
+	if strings.TrimSpace(req.Author) == "" {
+		return nil, errors.NewValidationError("author is required", "author or X-Admin-User header must identify who set the override")
+	}
+
+	override := &models.RateOverride{
+		BaseCurrency:   strings.ToUpper(req.BaseCurrency),
+		TargetCurrency: strings.ToUpper(req.TargetCurrency),
+		Rate:           req.Rate,
+		ValidFrom:      now,
+		Author:         strings.TrimSpace(req.Author),
+		Reason:         req.Reason,
+		CreatedAt:      now,
+	}
+
+	if req.ValidFrom != "" {
+		validFrom, err := parseTimestamp(req.ValidFrom)
+		if err != nil {
+			return nil, errors.NewValidationError("invalid valid_from", "valid_from must be RFC 3339 or YYYY-MM-DD")
+		}
+		override.ValidFrom = validFrom
+	}
+	if req.ValidUntil != "" {
+		validUntil, err := parseTimestamp(req.ValidUntil)
+		if err != nil {
+			return nil, errors.NewValidationError("invalid valid_until", "valid_until must be RFC 3339 or YYYY-MM-DD")
+		}
+		if !validUntil.After(override.ValidFrom) {
+			return nil, errors.NewValidationError("invalid validity window", "valid_until must be after valid_from")
+		}
+		override.ValidUntil = &validUntil
+	}
+
+	return override, nil
+}
+
+// parseTimestamp accepts an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC)
+func parseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+// invalidationRepo records the currencies a cache invalidation was asked for
+type invalidationRepo struct {
+	repository.RateRepository
+	calls int
+}
+
+func (r *invalidationRepo) InvalidateCache(ctx context.Context, base, target string) (*models.CacheInvalidation, error) {
+	r.calls++
+	return &models.CacheInvalidation{Scope: "test"}, nil
+}
+
+func (r *invalidationRepo) RefreshRates(ctx context.Context, base string) (*models.RateTable, error) {
+	r.calls++
+	return &models.RateTable{BaseCurrency: base}, nil
+}
+
+func TestAdminCacheOperationsValidateCurrencyCodes(t *testing.T) {
+	repo := &invalidationRepo{}
+	svc := NewAdminService(repo, log.NewNopLogger())
+	ctx := context.Background()
+
+	for _, tt := range []struct{ base, target string }{
+		{"*", ""},
+		{"US?", ""},
+		{"USD", "E*"},
+		{"USD", "[A-Z]"},
+		{"USDX", ""},
+	} {
+		_, err := svc.InvalidateCache(ctx, tt.base, tt.target)
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Type != errors.ErrorTypeValidation {
+			t.Errorf("InvalidateCache(%q, %q) = %v, want a validation error", tt.base, tt.target, err)
+		}
+	}
+	if _, err := svc.RefreshRates(ctx, "*"); err == nil {
+		t.Error("RefreshRates(*) succeeded, want a validation error")
+	}
+	if repo.calls != 0 {
+		t.Errorf("repository called %d times with invalid codes", repo.calls)
+	}
+
+	if _, err := svc.InvalidateCache(ctx, "usd", "eur"); err != nil {
+		t.Errorf("InvalidateCache(usd, eur) = %v", err)
+	}
+	if _, err := svc.InvalidateCache(ctx, "", ""); err != nil {
+		t.Errorf("InvalidateCache of everything = %v", err)
+	}
+}
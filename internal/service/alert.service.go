@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// defaultAlertWindow applies to change alerts registered without a window
+const defaultAlertWindow = 24 * time.Hour
+
+// AlertService manages rate alerts and delivers them by webhook. Alerts are
+// evaluated against every table the repository refreshes.
+type AlertService interface {
+	CreateAlert(ctx context.Context, req *models.AlertRequest) (*models.RateAlert, error)
+	ListAlerts(ctx context.Context) ([]*models.RateAlert, error)
+	GetAlert(ctx context.Context, id string) (*models.RateAlert, error)
+	DeleteAlert(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, id string) ([]*models.AlertDelivery, error)
+	Run(ctx context.Context)
+}
+
+// pendingDelivery is a triggered alert waiting for a delivery worker
+type pendingDelivery struct {
+	webhookURL string
+	secret     string
+	event      *models.AlertEvent
+}
+
+// alertService implements AlertService
+type alertService struct {
+	rateRepo   repository.RateRepository
+	store      repository.AlertStore
+	webhooks   *repository.WebhookClient
+	config     configs.AlertsConfig
+	logger     log.Logger
+	tables     chan *models.RateTable
+	deliveries chan pendingDelivery
+
+	// mu serializes evaluation with changes to the alert set, so a deleted
+	// alert is not written back with updated state
+	mu sync.Mutex
+}
+
+// NewAlertService creates an alert service fed by the repository's refresh
+// notifications
+func NewAlertService(rateRepo repository.RateRepository, store repository.AlertStore, webhooks *repository.WebhookClient, config configs.AlertsConfig, logger log.Logger) AlertService {
+	s := &alertService{
+		rateRepo:   rateRepo,
+		store:      store,
+		webhooks:   webhooks,
+		config:     config,
+		logger:     logger,
+		tables:     make(chan *models.RateTable, 64),
+		deliveries: make(chan pendingDelivery, 256),
+	}
+	rateRepo.AddRateListener(s.enqueueTable)
+	return s
+}
+
+// CreateAlert registers an alert
+func (s *alertService) CreateAlert(ctx context.Context, req *models.AlertRequest) (*models.RateAlert, error) {
+	level.Debug(s.logger).Log("method", "CreateAlert", "base", req.BaseCurrency, "target", req.TargetCurrency, "condition", req.Condition)
+
+	alert, err := buildAlert(req, time.Now().UTC(), s.config.AllowPrivateWebhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.Put(ctx, alert); err != nil {
+		level.Error(s.logger).Log("error", err, "method", "CreateAlert")
+		return nil, errors.NewInternalError("failed to store alert", err)
+	}
+
+	return alert, nil
+}
+
+// ListAlerts returns all alerts without their secrets
+func (s *alertService) ListAlerts(ctx context.Context) ([]*models.RateAlert, error) {
+	level.Debug(s.logger).Log("method", "ListAlerts")
+
+	alerts, err := s.store.List(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "ListAlerts")
+		return nil, errors.NewInternalError("failed to list alerts", err)
+	}
+	for _, alert := range alerts {
+		alert.Secret = ""
+	}
+
+	return alerts, nil
+}
+
+// GetAlert returns an alert without its secret
+func (s *alertService) GetAlert(ctx context.Context, id string) (*models.RateAlert, error) {
+	level.Debug(s.logger).Log("method", "GetAlert", "id", id)
+
+	alert, err := s.store.Get(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, repository.ErrAlertNotFound) {
+			return nil, errors.NewNotFoundError("alert not found: " + id)
+		}
+		level.Error(s.logger).Log("error", err, "method", "GetAlert")
+		return nil, errors.NewInternalError("failed to read alert", err)
+	}
+	alert.Secret = ""
+
+	return alert, nil
+}
+
+// DeleteAlert removes an alert and its delivery log
+func (s *alertService) DeleteAlert(ctx context.Context, id string) error {
+	level.Debug(s.logger).Log("method", "DeleteAlert", "id", id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.Delete(ctx, id); err != nil {
+		if stderrors.Is(err, repository.ErrAlertNotFound) {
+			return errors.NewNotFoundError("alert not found: " + id)
+		}
+		level.Error(s.logger).Log("error", err, "method", "DeleteAlert")
+		return errors.NewInternalError("failed to delete alert", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the delivery log of an alert, newest first
+func (s *alertService) ListDeliveries(ctx context.Context, id string) ([]*models.AlertDelivery, error) {
+	level.Debug(s.logger).Log("method", "ListDeliveries", "id", id)
+
+	if _, err := s.GetAlert(ctx, id); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.store.ListDeliveries(ctx, id)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "ListDeliveries")
+		return nil, errors.NewInternalError("failed to list deliveries", err)
+	}
+
+	return deliveries, nil
+}
+
+// Run evaluates refreshed tables and delivers triggered alerts until ctx is
+// done. Every evaluation interval it also reads the latest table of each
+// alerted base currency, which refreshes tables whose cache entry expired.
+func (s *alertService) Run(ctx context.Context) {
+	for i := 0; i < s.config.Workers; i++ {
+		go s.deliveryWorker(ctx)
+	}
+
+	ticker := time.NewTicker(s.config.EvaluationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case table := <-s.tables:
+			s.evaluate(ctx, table)
+		case <-ticker.C:
+			s.refreshAlertedBases(ctx)
+		}
+	}
+}
+
+// enqueueTable hands a refreshed table to Run without blocking the repository
+func (s *alertService) enqueueTable(table *models.RateTable) {
+	select {
+	case s.tables <- table:
+	default:
+		level.Warn(s.logger).Log("msg", "alert evaluation queue full, skipping table", "base", table.BaseCurrency)
+	}
+}
+
+func (s *alertService) refreshAlertedBases(ctx context.Context) {
+	alerts, err := s.store.List(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "msg", "failed to list alerts")
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, alert := range alerts {
+		if seen[alert.BaseCurrency] {
+			continue
+		}
+		seen[alert.BaseCurrency] = true
+		if _, err := s.rateRepo.GetLatestRates(ctx, alert.BaseCurrency); err != nil {
+			level.Error(s.logger).Log("error", err, "msg", "failed to refresh alerted rates", "base", alert.BaseCurrency)
+		}
+	}
+}
+
+// evaluate checks every alert on the table's base currency, queues a delivery
+// for each one that fires and stores the updated evaluation state
+func (s *alertService) evaluate(ctx context.Context, table *models.RateTable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alerts, err := s.store.List(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "msg", "failed to list alerts")
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, alert := range alerts {
+		if alert.BaseCurrency != table.BaseCurrency {
+			continue
+		}
+		rate, ok := table.Rates[alert.TargetCurrency]
+		if !ok {
+			continue
+		}
+
+		previous, reference := alert.LastRate, alert.ReferenceRate
+		fired := checkAlert(alert, rate, now)
+		if fired {
+			if alert.Condition == models.AlertConditionChange {
+				previous = reference
+			}
+			triggeredAt := now
+			alert.LastTriggeredAt = &triggeredAt
+			s.queueDelivery(alert, &models.AlertEvent{
+				DeliveryID:     newID(),
+				AlertID:        alert.ID,
+				BaseCurrency:   alert.BaseCurrency,
+				TargetCurrency: alert.TargetCurrency,
+				Condition:      alert.Condition,
+				Threshold:      alert.Threshold,
+				ChangePercent:  alert.ChangePercent,
+				Rate:           rate,
+				PreviousRate:   previous,
+				Provider:       table.Provider,
+				TriggeredAt:    now,
+			})
+		}
+
+		if fired || alert.LastRate != previous || alert.ReferenceRate != reference {
+			if err := s.store.Put(ctx, alert); err != nil {
+				level.Error(s.logger).Log("error", err, "msg", "failed to store alert state", "alert", alert.ID)
+			}
+		}
+	}
+}
+
+func (s *alertService) queueDelivery(alert *models.RateAlert, event *models.AlertEvent) {
+	level.Info(s.logger).Log("msg", "rate alert triggered", "alert", alert.ID, "base", alert.BaseCurrency, "target", alert.TargetCurrency, "rate", event.Rate)
+
+	select {
+	case s.deliveries <- pendingDelivery{webhookURL: alert.WebhookURL, secret: alert.Secret, event: event}:
+	default:
+		level.Warn(s.logger).Log("msg", "alert delivery queue full", "alert", alert.ID)
+		now := time.Now().UTC()
+		s.recordDelivery(&models.AlertDelivery{
+			ID:          event.DeliveryID,
+			AlertID:     alert.ID,
+			Event:       event,
+			Status:      models.AlertDeliveryFailed,
+			Error:       "delivery queue full",
+			CreatedAt:   now,
+			CompletedAt: now,
+		})
+	}
+}
+
+func (s *alertService) deliveryWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pending := <-s.deliveries:
+			s.deliver(ctx, pending)
+		}
+	}
+}
+
+// deliver POSTs an event to its webhook and records the outcome
+func (s *alertService) deliver(ctx context.Context, pending pendingDelivery) {
+	delivery := &models.AlertDelivery{
+		ID:        pending.event.DeliveryID,
+		AlertID:   pending.event.AlertID,
+		Event:     pending.event,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	body, err := json.Marshal(pending.event)
+	if err == nil {
+		var result repository.WebhookResult
+		result, err = s.webhooks.Send(ctx, pending.webhookURL, pending.secret, delivery.ID, body)
+		delivery.Attempts = result.Attempts
+		delivery.StatusCode = result.StatusCode
+	}
+	delivery.CompletedAt = time.Now().UTC()
+
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "msg", "alert delivery failed", "alert", delivery.AlertID, "delivery", delivery.ID, "attempts", delivery.Attempts)
+		delivery.Status = models.AlertDeliveryFailed
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = models.AlertDeliveryDelivered
+	}
+	s.recordDelivery(delivery)
+}
+
+// recordDelivery appends to the delivery log. It doesn't use the Run context
+// so deliveries cut short by shutdown are still logged.
+func (s *alertService) recordDelivery(delivery *models.AlertDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.store.AddDelivery(ctx, delivery, s.config.DeliveryLogSize); err != nil {
+		level.Error(s.logger).Log("error", err, "msg", "failed to record alert delivery", "alert", delivery.AlertID, "delivery", delivery.ID)
+	}
+}
+
+// checkAlert records rate as the alert's latest rate and reports whether the
+// alert fires. Threshold alerts fire when the rate moves across the threshold
+// between two refreshes. Change alerts compare against a reference rate that
+// is reset when the alert fires and at the end of every window.
+func checkAlert(alert *models.RateAlert, rate float64, now time.Time) bool {
+	previous := alert.LastRate
+	alert.LastRate = rate
+
+	above := previous > 0 && previous <= alert.Threshold && rate > alert.Threshold
+	below := previous > 0 && previous >= alert.Threshold && rate < alert.Threshold
+
+	switch alert.Condition {
+	case models.AlertConditionAbove:
+		return above
+	case models.AlertConditionBelow:
+		return below
+	case models.AlertConditionCrosses:
+		return above || below
+	case models.AlertConditionChange:
+		window, err := time.ParseDuration(alert.Window)
+		if err != nil {
+			window = defaultAlertWindow
+		}
+		inWindow := alert.ReferenceRate > 0 && alert.ReferenceAt != nil && now.Sub(*alert.ReferenceAt) < window
+		fired := inWindow && math.Abs(rate-alert.ReferenceRate)/alert.ReferenceRate*100 >= alert.ChangePercent
+		if fired || !inWindow {
+			referenceAt := now
+			alert.ReferenceRate = rate
+			alert.ReferenceAt = &referenceAt
+		}
+		return fired
+	}
+	return false
+}
+
+// buildAlert validates an alert request and turns it into an alert. Unless
+// allowPrivate is set, webhook URLs naming localhost or a non-public IP are
+// rejected; hosts resolving to one fail when the webhook is sent.
+func buildAlert(req *models.AlertRequest, now time.Time, allowPrivate bool) (*models.RateAlert, error) {
+	base := strings.ToUpper(strings.TrimSpace(req.BaseCurrency))
+	target := strings.ToUpper(strings.TrimSpace(req.TargetCurrency))
+	if err := validateCurrencyPair(base, target); err != nil {
+		return nil, err
+	}
+
+	alert := &models.RateAlert{
+		ID:             newID(),
+		BaseCurrency:   base,
+		TargetCurrency: target,
+		Condition:      strings.ToLower(req.Condition),
+		WebhookURL:     req.WebhookURL,
+		Secret:         req.Secret,
+		Description:    req.Description,
+		CreatedAt:      now,
+	}
+
+	switch alert.Condition {
+	case models.AlertConditionAbove, models.AlertConditionBelow, models.AlertConditionCrosses:
+		if req.Threshold <= 0 {
+			return nil, errors.NewValidationError("invalid threshold", "threshold must be greater than 0")
+		}
+		alert.Threshold = req.Threshold
+	case models.AlertConditionChange:
+		if req.ChangePercent <= 0 {
+			return nil, errors.NewValidationError("invalid change_percent", "change_percent must be greater than 0")
+		}
+		window := defaultAlertWindow
+		if req.Window != "" {
+			parsed, err := time.ParseDuration(req.Window)
+			if err != nil || parsed <= 0 {
+				return nil, errors.NewValidationError("invalid window", "window must be a positive duration such as 24h")
+			}
+			window = parsed
+		}
+		alert.ChangePercent = req.ChangePercent
+		alert.Window = window.String()
+	default:
+		return nil, errors.NewValidationError("invalid condition", "condition must be above, below, crosses or change")
+	}
+
+	u, err := url.Parse(req.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.NewValidationError("invalid webhook_url", "webhook_url must be an absolute http(s) URL")
+	}
+	if !allowPrivate {
+		host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+		ip := net.ParseIP(host)
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") || (ip != nil && !repository.PublicAddress(ip)) {
+			return nil, errors.NewValidationError("invalid webhook_url", "webhook_url must not point to a loopback, private or link-local address")
+		}
+	}
+
+	if alert.Secret == "" {
+		alert.Secret = newID() + newID()
+	}
+
+	return alert, nil
+}
+
+// newID returns a random 128-bit hex identifier
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
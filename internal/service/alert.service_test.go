@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+// listenerRepo captures the rate listener so tests can feed refreshed tables
+type listenerRepo struct {
+	repository.RateRepository
+	listener repository.RateListener
+}
+
+func (r *listenerRepo) AddRateListener(listener repository.RateListener) {
+	r.listener = listener
+}
+
+func TestAlertDeliveredToWebhook(t *testing.T) {
+	type received struct {
+		event     models.AlertEvent
+		signature string
+		timestamp int64
+		body      []byte
+	}
+	requests := make(chan received, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var event models.AlertEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		timestamp, _ := strconv.ParseInt(r.Header.Get(repository.WebhookTimestampHeader), 10, 64)
+		requests <- received{event, r.Header.Get(repository.WebhookSignatureHeader), timestamp, body}
+	}))
+	defer receiver.Close()
+
+	config := configs.Default().Alerts
+	config.EvaluationInterval = time.Hour
+	config.AllowPrivateWebhooks = true
+	store, err := repository.NewFileAlertStore(filepath.Join(t.TempDir(), "alerts.json"))
+	if err != nil {
+		t.Fatalf("NewFileAlertStore: %v", err)
+	}
+	repo := &listenerRepo{}
+	svc := NewAlertService(repo, store, repository.NewWebhookClient(config, log.NewNopLogger()), config, log.NewNopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx)
+
+	alert, err := svc.CreateAlert(ctx, &models.AlertRequest{
+		BaseCurrency:   "eur",
+		TargetCurrency: "usd",
+		Condition:      models.AlertConditionCrosses,
+		Threshold:      1.10,
+		WebhookURL:     receiver.URL,
+	})
+	if err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+	if alert.Secret == "" {
+		t.Fatal("expected a generated secret")
+	}
+
+	repo.listener(&models.RateTable{BaseCurrency: "EUR", Rates: map[string]float64{"USD": 1.09}, Provider: "stub"})
+	repo.listener(&models.RateTable{BaseCurrency: "EUR", Rates: map[string]float64{"USD": 1.11}, Provider: "stub"})
+
+	select {
+	case req := <-requests:
+		if req.event.AlertID != alert.ID || req.event.Rate != 1.11 || req.event.PreviousRate != 1.09 {
+			t.Errorf("unexpected event %+v", req.event)
+		}
+		if want := repository.SignWebhook(alert.Secret, req.timestamp, req.body); req.signature != want {
+			t.Errorf("signature = %q, want %q", req.signature, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	// The delivery is logged once the webhook call returns
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := svc.ListDeliveries(ctx, alert.ID)
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if len(deliveries) == 1 {
+			if deliveries[0].Status != models.AlertDeliveryDelivered || deliveries[0].Attempts != 1 {
+				t.Errorf("unexpected delivery %+v", deliveries[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d deliveries, want 1", len(deliveries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case req := <-requests:
+		t.Errorf("unexpected second delivery %+v", req.event)
+	default:
+	}
+}
+
+func TestCheckAlert(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		alert models.RateAlert
+		rates []float64
+		step  time.Duration
+		fired []bool
+	}{
+		{"above", models.RateAlert{Condition: models.AlertConditionAbove, Threshold: 1.1},
+			[]float64{1.2, 1.05, 1.12, 1.15, 1.08}, time.Minute, []bool{false, false, true, false, false}},
+		{"below", models.RateAlert{Condition: models.AlertConditionBelow, Threshold: 1.1},
+			[]float64{1.2, 1.05, 1.12, 1.08}, time.Minute, []bool{false, true, false, true}},
+		{"crosses", models.RateAlert{Condition: models.AlertConditionCrosses, Threshold: 1.1},
+			[]float64{1.05, 1.12, 1.08, 1.09}, time.Minute, []bool{false, true, true, false}},
+		{"change within window", models.RateAlert{Condition: models.AlertConditionChange, ChangePercent: 2, Window: "24h"},
+			[]float64{1.00, 1.01, 1.025, 1.03, 1.0}, time.Hour, []bool{false, false, true, false, true}},
+		{"change across windows", models.RateAlert{Condition: models.AlertConditionChange, ChangePercent: 2, Window: "24h"},
+			[]float64{1.00, 1.015, 1.03}, 13 * time.Hour, []bool{false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := tt.alert
+			for i, rate := range tt.rates {
+				if got := checkAlert(&alert, rate, start.Add(time.Duration(i)*tt.step)); got != tt.fired[i] {
+					t.Errorf("rate %d (%v): fired = %v, want %v", i, rate, got, tt.fired[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildAlertRejectsPrivateWebhooks(t *testing.T) {
+	request := func(webhookURL string) *models.AlertRequest {
+		return &models.AlertRequest{BaseCurrency: "EUR", TargetCurrency: "USD", Condition: models.AlertConditionAbove, Threshold: 1.1, WebhookURL: webhookURL}
+	}
+	for _, webhookURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://api.localhost/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data/",
+	} {
+		if _, err := buildAlert(request(webhookURL), time.Now(), false); err == nil {
+			t.Errorf("webhook %s accepted", webhookURL)
+		}
+		if _, err := buildAlert(request(webhookURL), time.Now(), true); err != nil {
+			t.Errorf("webhook %s rejected with private webhooks allowed: %v", webhookURL, err)
+		}
+	}
+	if _, err := buildAlert(request("https://hooks.example.com/fx"), time.Now(), false); err != nil {
+		t.Errorf("public webhook rejected: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/calendar"
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+)
+
+// carryInDays is how far before a period the stored history is read, so the
+// first business days of the period can carry over the last known rate
+const carryInDays = 14
+
+// ParsePeriod parses a calendar period written as a year ("2024"), a month
+// ("2024-03") or a quarter ("2024-Q1") and returns its first and last day
+func ParsePeriod(period string) (time.Time, time.Time, error) {
+	invalid := errors.NewValidationError("invalid period", "period must be a year (2024), month (2024-03) or quarter (2024-Q1)")
+
+	if year, qtr, ok := strings.Cut(strings.ToUpper(period), "-Q"); ok {
+		y, err := strconv.Atoi(year)
+		q, qerr := strconv.Atoi(qtr)
+		if err != nil || qerr != nil || len(year) != 4 || q < 1 || q > 4 {
+			return time.Time{}, time.Time{}, invalid
+		}
+		start := time.Date(y, time.Month((q-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 3, -1), nil
+	}
+	if start, err := time.Parse("2006-01", period); err == nil {
+		return start, start.AddDate(0, 1, -1), nil
+	}
+	if start, err := time.Parse("2006", period); err == nil {
+		return start, start.AddDate(1, 0, -1), nil
+	}
+	return time.Time{}, time.Time{}, invalid
+}
+
+// GetPeriodAverage computes the average and closing rates of a pair over a
+// calendar period from the stored history
+func (s *exchangeService) GetPeriodAverage(ctx context.Context, baseCurrency, targetCurrency, period, calendarName string) (*models.PeriodAverage, error) {
+	level.Debug(s.logger).Log("method", "GetPeriodAverage", "base", baseCurrency, "target", targetCurrency, "period", period)
+
+	baseCurrency = strings.ToUpper(strings.TrimSpace(baseCurrency))
+	targetCurrency = strings.ToUpper(strings.TrimSpace(targetCurrency))
+	if err := s.validateCurrencies(baseCurrency, targetCurrency); err != nil {
+		return nil, err
+	}
+	start, end, err := ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := s.calendarFor(calendarName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCoverage(cal, start, end); err != nil {
+		return nil, err
+	}
+
+	return s.periodAverage(ctx, baseCurrency, targetCurrency, period, start, end, cal)
+}
+
+// GetPeriodReport computes the period averages of every supported currency
+// against baseCurrency
+func (s *exchangeService) GetPeriodReport(ctx context.Context, baseCurrency, period, calendarName string) (*models.PeriodReport, error) {
+	level.Debug(s.logger).Log("method", "GetPeriodReport", "base", baseCurrency, "period", period)
+
+	baseCurrency = strings.ToUpper(strings.TrimSpace(baseCurrency))
+	if baseCurrency == "" {
+		return nil, errors.NewValidationError("base currency is required", "base_currency cannot be empty")
+	}
+	if err := validateCurrencyCode("base_currency", baseCurrency); err != nil {
+		return nil, err
+	}
+	start, end, err := ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := s.calendarFor(calendarName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCoverage(cal, start, end); err != nil {
+		return nil, err
+	}
+
+	currencies, err := s.rateRepo.GetSupportedCurrencies(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "GetPeriodReport")
+		return nil, err
+	}
+	supported := false
+	for _, currency := range currencies {
+		supported = supported || currency.Code == baseCurrency
+	}
+	if !supported {
+		return nil, errors.NewValidationError("unsupported currency "+baseCurrency, "see /api/v1/currencies for the supported currencies")
+	}
+
+	report := &models.PeriodReport{
+		BaseCurrency: baseCurrency,
+		Period:       period,
+		StartDate:    start.Format("2006-01-02"),
+		EndDate:      end.Format("2006-01-02"),
+		Calendar:     cal.Name(),
+		Averages:     []*models.PeriodAverage{},
+	}
+	for _, currency := range currencies {
+		if currency.Code == baseCurrency {
+			continue
+		}
+		average, err := s.periodAverage(ctx, baseCurrency, currency.Code, period, start, end, cal)
+		if err != nil {
+			if !errors.IsNotFoundError(err) {
+				level.Error(s.logger).Log("error", err, "method", "GetPeriodReport", "target", currency.Code)
+			}
+			report.Missing = append(report.Missing, models.MissingCurrency{Currency: currency.Code, Reason: err.Error()})
+			continue
+		}
+		report.Averages = append(report.Averages, average)
+	}
+
+	return report, nil
+}
+
+// periodAverage reads the stored history of a pair and averages it over the
+// period. Business days after today are not counted.
+func (s *exchangeService) periodAverage(ctx context.Context, baseCurrency, targetCurrency, period string, start, end time.Time, cal calendar.Calendar) (*models.PeriodAverage, error) {
+	rates, err := s.rateRepo.GetStoredRates(ctx, baseCurrency, targetCurrency, start.AddDate(0, 0, -carryInDays), end)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to read stored rates", err)
+	}
+
+	average := &models.PeriodAverage{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Period:         period,
+		StartDate:      start.Format("2006-01-02"),
+		EndDate:        end.Format("2006-01-02"),
+		Calendar:       cal.Name(),
+	}
+
+	var simpleSum float64
+	for _, rate := range rates {
+		if !rate.Date.Before(start) {
+			simpleSum += rate.Rate
+			average.Rates++
+		}
+	}
+	if average.Rates == 0 {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("no stored rates for %s/%s in %s", baseCurrency, targetCurrency, period))
+	}
+	average.SimpleAverage = simpleSum / float64(average.Rates)
+
+	last := end
+	if today := time.Now(); last.After(today) {
+		last = today
+	}
+	var weightedSum float64
+	var current *models.HistoricalRate
+	next := 0
+	for d := start; !d.After(last); d = d.AddDate(0, 0, 1) {
+		for next < len(rates) && !rates[next].Date.After(d) {
+			current = rates[next]
+			next++
+		}
+		if current == nil || !cal.IsBusinessDay(d) {
+			continue
+		}
+		weightedSum += current.Rate
+		average.BusinessDays++
+		average.ClosingRate = current.Rate
+		average.ClosingDate = current.Date.Format("2006-01-02")
+	}
+	if average.BusinessDays > 0 {
+		average.BusinessDayAverage = weightedSum / float64(average.BusinessDays)
+	}
+
+	return average, nil
+}
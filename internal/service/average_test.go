@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+// storedRepo serves a fixed stored history for USD/EUR only
+type storedRepo struct {
+	repository.RateRepository
+	rates []*models.HistoricalRate
+}
+
+func (r *storedRepo) GetStoredRates(_ context.Context, base, target string, start, end time.Time) ([]*models.HistoricalRate, error) {
+	if base != "USD" || target != "EUR" {
+		return nil, nil
+	}
+	var rates []*models.HistoricalRate
+	for _, rate := range r.rates {
+		if !rate.Date.Before(start) && !rate.Date.After(end) {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func (r *storedRepo) GetSupportedCurrencies(context.Context) ([]*models.Currency, error) {
+	return []*models.Currency{{Code: "USD"}, {Code: "EUR"}, {Code: "GBP"}}, nil
+}
+
+func stored(date string, rate float64) *models.HistoricalRate {
+	d, _ := time.Parse("2006-01-02", date)
+	return &models.HistoricalRate{BaseCurrency: "USD", TargetCurrency: "EUR", Rate: rate, Date: d}
+}
+
+func TestGetPeriodAverage(t *testing.T) {
+	// March 2024 has 21 weekdays. The 1st carries over February's last rate
+	// and the 29th (Good Friday) carries over the 28th's.
+	repo := &storedRepo{rates: []*models.HistoricalRate{
+		stored("2024-02-29", 1.0),
+		stored("2024-03-04", 2.0),
+		stored("2024-03-28", 3.0),
+	}}
+	svc := NewExchangeService(repo, historyConfig(31, 2), log.NewNopLogger())
+
+	average, err := svc.GetPeriodAverage(context.Background(), "USD", "EUR", "2024-03", "")
+	if err != nil {
+		t.Fatalf("GetPeriodAverage: %v", err)
+	}
+
+	if average.Rates != 2 || math.Abs(average.SimpleAverage-2.5) > 1e-9 {
+		t.Errorf("simple average = %v over %d rates, want 2.5 over 2", average.SimpleAverage, average.Rates)
+	}
+	// 1st: 1.0; 4th-27th (18 weekdays): 2.0; 28th-29th: 3.0
+	want := (1.0 + 18*2.0 + 2*3.0) / 21
+	if average.BusinessDays != 21 || math.Abs(average.BusinessDayAverage-want) > 1e-9 {
+		t.Errorf("business day average = %v over %d days, want %v over 21", average.BusinessDayAverage, average.BusinessDays, want)
+	}
+	if average.ClosingRate != 3.0 || average.ClosingDate != "2024-03-28" {
+		t.Errorf("closing = %v on %s, want 3 on 2024-03-28", average.ClosingRate, average.ClosingDate)
+	}
+
+	// Under the TARGET calendar Good Friday isn't a business day
+	average, err = svc.GetPeriodAverage(context.Background(), "USD", "EUR", "2024-03", "TARGET")
+	if err != nil {
+		t.Fatalf("GetPeriodAverage: %v", err)
+	}
+	want = (1.0 + 18*2.0 + 3.0) / 20
+	if average.BusinessDays != 20 || math.Abs(average.BusinessDayAverage-want) > 1e-9 {
+		t.Errorf("TARGET business day average = %v over %d days, want %v over 20", average.BusinessDayAverage, average.BusinessDays, want)
+	}
+}
+
+func TestGetPeriodReport(t *testing.T) {
+	repo := &storedRepo{rates: []*models.HistoricalRate{stored("2024-02-05", 0.9)}}
+	svc := NewExchangeService(repo, historyConfig(31, 2), log.NewNopLogger())
+
+	// The base is case-insensitive, and is itself left out of the report
+	report, err := svc.GetPeriodReport(context.Background(), "usd", "2024-Q1", "")
+	if err != nil {
+		t.Fatalf("GetPeriodReport: %v", err)
+	}
+	if report.BaseCurrency != "USD" {
+		t.Errorf("base_currency = %q, want USD", report.BaseCurrency)
+	}
+	if len(report.Averages) != 1 || report.Averages[0].TargetCurrency != "EUR" {
+		t.Errorf("unexpected averages %+v", report.Averages)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].Currency != "GBP" {
+		t.Errorf("unexpected missing currencies %+v", report.Missing)
+	}
+	if report.StartDate != "2024-01-01" || report.EndDate != "2024-03-31" {
+		t.Errorf("quarter spans %s to %s", report.StartDate, report.EndDate)
+	}
+
+	for _, base := range []string{"XYZ", "US", "U$D", ""} {
+		if _, err := svc.GetPeriodReport(context.Background(), base, "2024-Q1", ""); !errors.IsValidationError(err) {
+			t.Errorf("GetPeriodReport(%q): got %v, want a validation error", base, err)
+		}
+	}
+}
+
+func TestParsePeriodRejectsInvalidPeriods(t *testing.T) {
+	for _, period := range []string{"", "2024-13", "2024-Q5", "24-Q1", "March"} {
+		if _, _, err := ParsePeriod(period); !errors.IsValidationError(err) {
+			t.Errorf("ParsePeriod(%q): expected a validation error, got %v", period, err)
+		}
+	}
+}
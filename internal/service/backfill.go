@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"golang.org/x/time/rate"
+)
+
+// BackfillJob describes a range of historical rates to load into the history
+// store, either fetched from a provider or taken from imported rates
+type BackfillJob struct {
+	Start, End time.Time
+	Bases      []string
+	// Targets limits the stored currencies; empty keeps every target
+	Targets []string
+
+	// Provider fetches one rate table per base and date. When it is nil the
+	// job stores Rates instead.
+	Provider repository.ProviderClient
+	Rates    []*models.HistoricalRate
+	// Validation bounds the fetched rates as for live rates; rates outside
+	// them are not stored
+	Validation configs.ValidationConfig
+	// OnConflict resolves imported rates that differ from stored ones; see
+	// the models.Conflict* policies. It defaults to overwrite.
+	OnConflict string
+
+	// RequestsPerSecond caps provider requests to respect its quota
+	RequestsPerSecond float64
+	// StateFile records the last completed date of each base so an
+	// interrupted job resumes where it stopped; empty disables resuming
+	StateFile string
+	// Force refetches dates that are already stored
+	Force bool
+}
+
+// BackfillResult counts what a backfill did
+type BackfillResult struct {
+	Fetched int `json:"fetched"`
+	Stored  int `json:"stored"`
+	Skipped int `json:"skipped"`
+	Missing int `json:"missing"`
+	// Rejected counts fetched rates that failed validation
+	Rejected int `json:"rejected"`
+}
+
+// backfillState is the checkpoint file of a provider backfill. It belongs to
+// one job, so a different range or set of targets starts over.
+type backfillState struct {
+	Job       string            `json:"job"`
+	Completed map[string]string `json:"completed"`
+}
+
+// Backfill loads the job's rates into store. Rates are upserted by pair and
+// date, so running the same job twice doesn't duplicate data.
+func Backfill(ctx context.Context, store repository.HistoryStore, job BackfillJob, logger log.Logger) (*BackfillResult, error) {
+	if len(job.Bases) == 0 {
+		return nil, fmt.Errorf("at least one base currency is required")
+	}
+	if job.End.Before(job.Start) {
+		return nil, fmt.Errorf("end date %s is before start date %s", job.End.Format("2006-01-02"), job.Start.Format("2006-01-02"))
+	}
+	job.Bases = upperAll(job.Bases)
+	job.Targets = upperAll(job.Targets)
+
+	if job.Provider == nil {
+		return backfillFromRates(ctx, store, job, logger)
+	}
+	return backfillFromProvider(ctx, store, job, logger)
+}
+
+func backfillFromRates(ctx context.Context, store repository.HistoryStore, job BackfillJob, logger log.Logger) (*BackfillResult, error) {
+	result := &BackfillResult{}
+	var rates []*models.HistoricalRate
+	for _, r := range job.Rates {
+		if !contains(job.Bases, r.BaseCurrency) || (len(job.Targets) > 0 && !contains(job.Targets, r.TargetCurrency)) ||
+			r.Date.Before(job.Start) || r.Date.After(job.End) {
+			result.Skipped++
+			continue
+		}
+		rates = append(rates, r)
+	}
+
+	imported, err := importHistory(ctx, store.Range, store.Put, rates, job.OnConflict)
+	if err == nil {
+		err = store.Flush(ctx)
+	}
+	if imported != nil {
+		result.Stored = imported.Stored
+		result.Skipped += imported.Unchanged + imported.Skipped + imported.Duplicates
+	}
+	if err != nil {
+		return result, err
+	}
+
+	level.Info(logger).Log("msg", "imported historical rates", "stored", result.Stored, "overwritten", imported.Overwritten, "skipped", result.Skipped)
+	return result, nil
+}
+
+func backfillFromProvider(ctx context.Context, store repository.HistoryStore, job BackfillJob, logger log.Logger) (*BackfillResult, error) {
+	if job.RequestsPerSecond <= 0 {
+		return nil, fmt.Errorf("requests per second must be positive")
+	}
+	limiter := rate.NewLimiter(rate.Limit(job.RequestsPerSecond), 1)
+
+	state, err := loadBackfillState(job)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackfillResult{}
+	for _, base := range job.Bases {
+		from := job.Start
+		if completed, ok := state.Completed[base]; ok {
+			if last, err := time.Parse("2006-01-02", completed); err == nil && !last.Before(from) {
+				level.Info(logger).Log("msg", "resuming backfill", "base", base, "completed", completed)
+				from = last.AddDate(0, 0, 1)
+			}
+		}
+
+		for date := from; !date.After(job.End); date = date.AddDate(0, 0, 1) {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			if !job.Force && len(job.Targets) > 0 && allStored(ctx, store, base, job.Targets, date) {
+				result.Skipped++
+			} else if err := backfillDate(ctx, store, job, limiter, base, date, result, logger); err != nil {
+				if !errors.Is(err, repository.ErrRateNotFound) {
+					return result, fmt.Errorf("%s on %s: %w", base, date.Format("2006-01-02"), err)
+				}
+				level.Info(logger).Log("msg", "no rates published", "base", base, "date", date.Format("2006-01-02"))
+				result.Missing++
+			}
+
+			// The checkpoint must not get ahead of what is on disk
+			if err := store.Flush(ctx); err != nil {
+				return result, fmt.Errorf("failed to store rates: %w", err)
+			}
+			state.Completed[base] = date.Format("2006-01-02")
+			if err := saveBackfillState(job.StateFile, state); err != nil {
+				return result, err
+			}
+		}
+		level.Info(logger).Log("msg", "backfilled base currency", "base", base, "start", job.Start.Format("2006-01-02"), "end", job.End.Format("2006-01-02"))
+	}
+	return result, nil
+}
+
+// backfillDate fetches and stores the rates of one base on one date
+func backfillDate(ctx context.Context, store repository.HistoryStore, job BackfillJob, limiter *rate.Limiter, base string, date time.Time, result *BackfillResult, logger log.Logger) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+	table, err := job.Provider.GetHistoricalRates(ctx, base, date)
+	if err != nil {
+		return err
+	}
+	result.Fetched++
+
+	var rates []*models.HistoricalRate
+	var rejected []string
+	for target, value := range table.Rates {
+		if target == base || (len(job.Targets) > 0 && !contains(job.Targets, target)) {
+			continue
+		}
+		if !repository.ValidRate(job.Validation, value) {
+			rejected = append(rejected, fmt.Sprintf("%s=%g", target, value))
+			continue
+		}
+		rates = append(rates, &models.HistoricalRate{
+			BaseCurrency:   base,
+			TargetCurrency: target,
+			Rate:           value,
+			Date:           date,
+			Provider:       table.Provider,
+			FetchedAt:      table.FetchedAt,
+		})
+	}
+	if len(rejected) > 0 {
+		sort.Strings(rejected)
+		level.Warn(logger).Log("alert", "invalid_rates", "msg", "rejected invalid rates from provider",
+			"provider", table.Provider, "base", base, "date", date.Format("2006-01-02"), "rates", strings.Join(rejected, ","))
+		result.Rejected += len(rejected)
+	}
+	if err := store.Put(ctx, rates...); err != nil {
+		return fmt.Errorf("failed to store rates: %w", err)
+	}
+	result.Stored += len(rates)
+	return nil
+}
+
+// allStored reports whether every target already has a stored rate on date
+func allStored(ctx context.Context, store repository.HistoryStore, base string, targets []string, date time.Time) bool {
+	for _, target := range targets {
+		if _, err := store.Get(ctx, base, target, date); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// backfillJobKey identifies a provider job in its state file
+func backfillJobKey(job BackfillJob) string {
+	targets := append([]string(nil), job.Targets...)
+	sort.Strings(targets)
+	return fmt.Sprintf("%s..%s targets=%s", job.Start.Format("2006-01-02"), job.End.Format("2006-01-02"), strings.Join(targets, ","))
+}
+
+func loadBackfillState(job BackfillJob) (*backfillState, error) {
+	state := &backfillState{Job: backfillJobKey(job), Completed: make(map[string]string)}
+	if job.StateFile == "" || job.Force {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(job.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backfill state: %w", err)
+	}
+
+	var saved backfillState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse backfill state: %w", err)
+	}
+	if saved.Job == state.Job && saved.Completed != nil {
+		state.Completed = saved.Completed
+	}
+	return state, nil
+}
+
+// saveBackfillState writes the checkpoint through a temporary file so an
+// interruption never leaves it half written
+func saveBackfillState(path string, state *backfillState) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write backfill state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write backfill state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write backfill state: %w", err)
+	}
+	return nil
+}
+
+func upperAll(codes []string) []string {
+	upper := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			upper = append(upper, code)
+		}
+	}
+	return upper
+}
+
+func contains(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
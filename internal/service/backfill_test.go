@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+// tableProvider publishes USD tables, or rates when set, failing once on failOn
+type tableProvider struct {
+	repository.ProviderClient
+	calls  []string
+	failOn string
+	rates  map[string]float64
+}
+
+func (p *tableProvider) GetHistoricalRates(_ context.Context, base string, date time.Time) (*models.RateTable, error) {
+	day := date.Format("2006-01-02")
+	p.calls = append(p.calls, day)
+	if day == p.failOn {
+		p.failOn = ""
+		return nil, errors.New("quota exceeded")
+	}
+	rates := p.rates
+	if rates == nil {
+		rates = map[string]float64{"USD": 1, "EUR": 0.9, "GBP": 0.8}
+	}
+	return &models.RateTable{BaseCurrency: base, Rates: rates, Provider: "test"}, nil
+}
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestBackfillResumesAndIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	store, err := repository.NewFileHistoryStore(filepath.Join(dir, "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &tableProvider{failOn: "2024-01-03"}
+	job := BackfillJob{
+		Start:             day("2024-01-01"),
+		End:               day("2024-01-05"),
+		Bases:             []string{"usd"},
+		Targets:           []string{"EUR"},
+		Provider:          provider,
+		RequestsPerSecond: 1000,
+		StateFile:         filepath.Join(dir, "state.json"),
+	}
+
+	if _, err := Backfill(context.Background(), store, job, log.NewNopLogger()); err == nil {
+		t.Fatal("expected the provider failure to stop the backfill")
+	}
+
+	// The rerun picks up at the failed date
+	provider.calls = nil
+	result, err := Backfill(context.Background(), store, job, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if got := strings.Join(provider.calls, ","); got != "2024-01-03,2024-01-04,2024-01-05" {
+		t.Errorf("resumed run fetched %s", got)
+	}
+	if result.Stored != 3 {
+		t.Errorf("stored %d rates, want 3", result.Stored)
+	}
+
+	rates, _ := store.Range(context.Background(), "USD", "EUR", job.Start, job.End)
+	if len(rates) != 5 {
+		t.Errorf("store holds %d USD/EUR rates, want 5", len(rates))
+	}
+	if _, err := store.Get(context.Background(), "USD", "GBP", job.Start); err == nil {
+		t.Error("GBP was stored although only EUR was requested")
+	}
+
+	// Without a checkpoint, already stored dates are skipped
+	provider.calls = nil
+	job.StateFile = ""
+	result, err = Backfill(context.Background(), store, job, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if len(provider.calls) != 0 || result.Skipped != 5 {
+		t.Errorf("rerun fetched %v and skipped %d dates", provider.calls, result.Skipped)
+	}
+}
+
+func TestBackfillRejectsInvalidRates(t *testing.T) {
+	store, err := repository.NewFileHistoryStore(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	validation := configs.Default().Validation
+	validation.MaxRate = 100
+	job := BackfillJob{
+		Start:             day("2024-01-01"),
+		End:               day("2024-01-02"),
+		Bases:             []string{"USD"},
+		Provider:          &tableProvider{rates: map[string]float64{"EUR": 0.9, "GBP": 0, "JPY": -150, "CHF": math.NaN(), "IDR": 15000}},
+		Validation:        validation,
+		RequestsPerSecond: 1000,
+	}
+
+	result, err := Backfill(context.Background(), store, job, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if result.Stored != 2 || result.Rejected != 8 {
+		t.Errorf("stored %d and rejected %d rates, want 2 and 8", result.Stored, result.Rejected)
+	}
+	for _, target := range []string{"GBP", "JPY", "CHF", "IDR"} {
+		if _, err := store.Get(context.Background(), "USD", target, job.Start); err == nil {
+			t.Errorf("invalid USD/%s rate was stored", target)
+		}
+	}
+}
+
+func TestBackfillFromImportedRates(t *testing.T) {
+	input := `date,base_currency,target_currency,rate
+2024-01-01,usd,eur,0.91
+2024-01-02,USD,EUR,0.92
+2024-01-02,GBP,EUR,1.15
+2023-12-31,USD,EUR,0.90
+`
+	rates, err := DecodeHistoricalRates(strings.NewReader(input), FormatCSV, "rates.csv")
+	if err != nil {
+		t.Fatalf("DecodeHistoricalRates: %v", err)
+	}
+
+	store, _ := repository.NewFileHistoryStore(filepath.Join(t.TempDir(), "history.json"))
+	job := BackfillJob{Start: day("2024-01-01"), End: day("2024-01-31"), Bases: []string{"USD"}, Rates: rates}
+	result, err := Backfill(context.Background(), store, job, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if result.Stored != 2 || result.Skipped != 2 {
+		t.Errorf("stored %d and skipped %d, want 2 and 2", result.Stored, result.Skipped)
+	}
+
+	// Importing the same file again leaves the stored rates unchanged
+	result, err = Backfill(context.Background(), store, job, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if result.Stored != 0 || result.Skipped != 4 {
+		t.Errorf("rerun stored %d and skipped %d, want 0 and 4", result.Stored, result.Skipped)
+	}
+
+	stored, _ := store.Range(context.Background(), "USD", "EUR", job.Start, job.End)
+	if len(stored) != 2 || stored[0].Rate != 0.91 || stored[0].Provider != "import" {
+		t.Errorf("unexpected stored rates %+v", stored)
+	}
+}
+
+func TestDecodeHistoricalRatesRejectsBadRows(t *testing.T) {
+	for _, input := range []string{
+		"date,base_currency,rate\n2024-01-01,USD,1\n",
+		"date,base_currency,target_currency,rate\n2024-13-01,USD,EUR,1\n",
+		"date,base_currency,target_currency,rate\n2024-01-01,USD,EUR,-1\n",
+		"date,base_currency,target_currency,rate\n2024-01-01,USD,USD,1\n",
+		"date,base_currency,target_currency,rate\n2024-01-01,U$D,EUR,1\n",
+		"date,base_currency,target_currency,rate\n2024-01-01,USD,=1+,1\n",
+	} {
+		if _, err := DecodeHistoricalRates(strings.NewReader(input), FormatCSV, "rates.csv"); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
@@ -2,20 +2,29 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"exchange-rate-service/configs"
 	"exchange-rate-service/internal/models"
 	"exchange-rate-service/internal/repository"
 	"exchange-rate-service/internal/errors"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 )
 
 // ExchangeService defines the interface for exchange rate operations
 type ExchangeService interface {
 	GetLatestRate(ctx context.Context, baseCurrency, targetCurrency string) (*models.ExchangeRate, error)
 	ConvertCurrency(ctx context.Context, req *models.ConversionRequest) (*models.ConversionResponse, error)
-	GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error)
+	GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time, fill models.FillOptions) (*models.HistoricalRate, error)
+	GetTimeSeries(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time, fill models.FillOptions) (*models.TimeSeries, error)
+	GetStoredRates(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error)
+	ExportHistory(ctx context.Context, baseCurrency string, targets []string, start, end time.Time, emit func(*models.HistoricalRate) error) error
+	GetPeriodAverage(ctx context.Context, baseCurrency, targetCurrency, period, calendar string) (*models.PeriodAverage, error)
+	GetPeriodReport(ctx context.Context, baseCurrency, period, calendar string) (*models.PeriodReport, error)
 	GetSupportedCurrencies(ctx context.Context) ([]*models.Currency, error)
 	HealthCheck(ctx context.Context) (*models.HealthResponse, error)
 }
@@ -23,20 +32,22 @@ type ExchangeService interface {
 // exchangeService implements ExchangeService
 type exchangeService struct {
 	rateRepo repository.RateRepository
+	history  configs.HistoryConfig
 	logger   log.Logger
 }
 
 // NewExchangeService creates a new exchange service
-func NewExchangeService(rateRepo repository.RateRepository, logger log.Logger) ExchangeService {
+func NewExchangeService(rateRepo repository.RateRepository, history configs.HistoryConfig, logger log.Logger) ExchangeService {
 	return &exchangeService{
 		rateRepo: rateRepo,
+		history:  history,
 		logger:   logger,
 	}
 }
 
 // GetLatestRate retrieves the latest exchange rate
 func (s *exchangeService) GetLatestRate(ctx context.Context, baseCurrency, targetCurrency string) (*models.ExchangeRate, error) {
-	s.logger.Log("method", "GetLatestRate", "base", baseCurrency, "target", targetCurrency)
+	level.Debug(s.logger).Log("method", "GetLatestRate", "base", baseCurrency, "target", targetCurrency)
 
 	// Validate currencies
 	if err := s.validateCurrencies(baseCurrency, targetCurrency); err != nil {
@@ -46,7 +57,7 @@ func (s *exchangeService) GetLatestRate(ctx context.Context, baseCurrency, targe
 	// Get rate from repository
 	rate, err := s.rateRepo.GetLatestRate(ctx, baseCurrency, targetCurrency)
 	if err != nil {
-		s.logger.Log("error", err, "method", "GetLatestRate")
+		level.Error(s.logger).Log("error", err, "method", "GetLatestRate")
 		return nil, err
 	}
 
@@ -55,7 +66,7 @@ func (s *exchangeService) GetLatestRate(ctx context.Context, baseCurrency, targe
 
 // ConvertCurrency converts an amount from one currency to another
 func (s *exchangeService) ConvertCurrency(ctx context.Context, req *models.ConversionRequest) (*models.ConversionResponse, error) {
-	s.logger.Log("method", "ConvertCurrency", "from", req.FromCurrency, "to", req.ToCurrency, "amount", req.Amount)
+	level.Debug(s.logger).Log("method", "ConvertCurrency", "from", req.FromCurrency, "to", req.ToCurrency, "amount", req.Amount)
 
 	// Validate request
 	if err := s.validateConversionRequest(req); err != nil {
@@ -77,13 +88,15 @@ func (s *exchangeService) ConvertCurrency(ctx context.Context, req *models.Conve
 	}
 
 	if err != nil {
-		s.logger.Log("error", err, "method", "ConvertCurrency")
+		level.Error(s.logger).Log("error", err, "method", "ConvertCurrency")
 		return nil, err
 	}
 
 	// Calculate converted amount and extract rate info
 	var rateValue float64
 	var provider string
+	var author string
+	var sources []models.RateSource
 	var fetchedAt time.Time
 
 	if req.Date != "" {
@@ -91,6 +104,7 @@ func (s *exchangeService) ConvertCurrency(ctx context.Context, req *models.Conve
 		if histRate, ok := rate.(*models.HistoricalRate); ok {
 			rateValue = histRate.Rate
 			provider = histRate.Provider
+			author = histRate.Author
 			fetchedAt = histRate.FetchedAt
 		}
 	} else {
@@ -98,6 +112,8 @@ func (s *exchangeService) ConvertCurrency(ctx context.Context, req *models.Conve
 		if latestRate, ok := rate.(*models.ExchangeRate); ok {
 			rateValue = latestRate.Rate
 			provider = latestRate.Provider
+			author = latestRate.Author
+			sources = latestRate.Sources
 			fetchedAt = latestRate.FetchedAt
 		}
 	}
@@ -111,38 +127,74 @@ func (s *exchangeService) ConvertCurrency(ctx context.Context, req *models.Conve
 		ConvertedAmount: convertedAmount,
 		Rate:            rateValue,
 		Provider:        provider,
+		Author:          author,
+		Sources:         sources,
 		FetchedAt:       fetchedAt,
 	}
 
 	return response, nil
 }
 
-// GetHistoricalRate retrieves a historical exchange rate
-func (s *exchangeService) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time) (*models.HistoricalRate, error) {
-	s.logger.Log("method", "GetHistoricalRate", "base", baseCurrency, "target", targetCurrency, "date", date.Format("2006-01-02"))
+// GetHistoricalRate retrieves a historical exchange rate. Under a fill policy
+// other than none, dates that are not business days get a filled rate.
+func (s *exchangeService) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time, fill models.FillOptions) (*models.HistoricalRate, error) {
+	level.Debug(s.logger).Log("method", "GetHistoricalRate", "base", baseCurrency, "target", targetCurrency, "date", date.Format("2006-01-02"))
 
 	// Validate currencies
 	if err := s.validateCurrencies(baseCurrency, targetCurrency); err != nil {
 		return nil, err
 	}
+	policy, cal, err := s.resolveFill(fill)
+	if err != nil {
+		return nil, err
+	}
 
-	// Get historical rate from repository
-	rate, err := s.rateRepo.GetHistoricalRate(ctx, baseCurrency, targetCurrency, date)
+	var rate *models.HistoricalRate
+	if policy == models.FillNone {
+		rate, err = s.rateRepo.GetHistoricalRate(ctx, baseCurrency, targetCurrency, date)
+	} else if err = checkCoverage(cal, date, date); err == nil {
+		rate, err = s.getFilledRate(ctx, baseCurrency, targetCurrency, date, policy, cal)
+	}
 	if err != nil {
-		s.logger.Log("error", err, "method", "GetHistoricalRate")
+		level.Error(s.logger).Log("error", err, "method", "GetHistoricalRate")
 		return nil, err
 	}
 
 	return rate, nil
 }
 
+// GetStoredRates retrieves the daily rates held in the history store for a
+// date range, in date order; dates that were never fetched are left out
+func (s *exchangeService) GetStoredRates(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error) {
+	level.Debug(s.logger).Log("method", "GetStoredRates", "base", baseCurrency, "target", targetCurrency, "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
+
+	if err := s.validateCurrencies(baseCurrency, targetCurrency); err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, errors.NewValidationError("invalid date range", "end_date must not be before start_date")
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > s.history.MaxBucketRangeDays {
+		return nil, errors.NewValidationError("invalid date range",
+			fmt.Sprintf("bucketed time series may span at most %d days", s.history.MaxBucketRangeDays))
+	}
+
+	rates, err := s.rateRepo.GetStoredRates(ctx, baseCurrency, targetCurrency, start, end)
+	if err != nil {
+		level.Error(s.logger).Log("error", err, "method", "GetStoredRates")
+		return nil, errors.NewInternalError("failed to read stored rates", err)
+	}
+
+	return rates, nil
+}
+
 // GetSupportedCurrencies retrieves list of supported currencies
 func (s *exchangeService) GetSupportedCurrencies(ctx context.Context) ([]*models.Currency, error) {
-	s.logger.Log("method", "GetSupportedCurrencies")
+	level.Debug(s.logger).Log("method", "GetSupportedCurrencies")
 
 	currencies, err := s.rateRepo.GetSupportedCurrencies(ctx)
 	if err != nil {
-		s.logger.Log("error", err, "method", "GetSupportedCurrencies")
+		level.Error(s.logger).Log("error", err, "method", "GetSupportedCurrencies")
 		return nil, err
 	}
 
@@ -151,27 +203,75 @@ func (s *exchangeService) GetSupportedCurrencies(ctx context.Context) ([]*models
 
 // HealthCheck performs a health check
 func (s *exchangeService) HealthCheck(ctx context.Context) (*models.HealthResponse, error) {
-	s.logger.Log("method", "HealthCheck")
+	level.Debug(s.logger).Log("method", "HealthCheck")
 
 	// Check repository health
 	providers, err := s.rateRepo.HealthCheck(ctx)
 	if err != nil {
-		s.logger.Log("error", err, "method", "HealthCheck")
+		level.Error(s.logger).Log("error", err, "method", "HealthCheck")
 		return nil, err
 	}
 
+	cache := "connected"
+	if providers[repository.HealthComponentCache] != models.HealthStatusHealthy {
+		cache = "disconnected"
+	}
+
 	response := &models.HealthResponse{
-		Status:    "healthy",
+		Status:    aggregateHealth(providers),
 		Timestamp: time.Now(),
 		Providers: providers,
-		Cache:     "connected",
+		Cache:     cache,
 	}
 
 	return response, nil
 }
 
+// aggregateHealth derives the overall status from the per-component map. The
+// service is unhealthy when no provider can serve requests (each one is failing
+// its probe or has an open circuit) and degraded when any component is impaired.
+func aggregateHealth(components map[string]string) string {
+	var providers, usable int
+	degraded := false
+
+	for name, state := range components {
+		switch {
+		case strings.HasSuffix(name, repository.CircuitSuffix):
+			if state != repository.CircuitClosed.String() {
+				degraded = true
+			}
+		case name == repository.HealthComponentCache, name == repository.HealthComponentValidation:
+			if state != models.HealthStatusHealthy {
+				degraded = true
+			}
+		default:
+			providers++
+			circuit := components[name+repository.CircuitSuffix]
+			if state == models.HealthStatusHealthy && circuit != repository.CircuitOpen.String() {
+				usable++
+			} else {
+				degraded = true
+			}
+		}
+	}
+
+	switch {
+	case providers == 0 || usable == 0:
+		return models.HealthStatusUnhealthy
+	case degraded:
+		return models.HealthStatusDegraded
+	default:
+		return models.HealthStatusHealthy
+	}
+}
+
 // validateCurrencies validates currency codes
 func (s *exchangeService) validateCurrencies(baseCurrency, targetCurrency string) error {
+	return validateCurrencyPair(baseCurrency, targetCurrency)
+}
+
+// validateCurrencyPair validates the currency codes of a pair
+func validateCurrencyPair(baseCurrency, targetCurrency string) error {
 	if baseCurrency == "" {
 		return errors.NewValidationError("base currency is required", "base_currency cannot be empty")
 	}
@@ -184,6 +284,49 @@ func (s *exchangeService) validateCurrencies(baseCurrency, targetCurrency string
 	return nil
 }
 
+// isCurrencyCode reports whether code is three ASCII letters, in either case
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if c := code[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateCurrencyCode checks that the named field holds an ISO 4217 style code
+func validateCurrencyCode(field, code string) error {
+	if !isCurrencyCode(code) {
+		return errors.NewValidationError("invalid "+field, field+" must be a three-letter currency code")
+	}
+	return nil
+}
+
+// validateSupportedCurrencies checks that every code is one of the currencies
+// the providers quote. If the list can't be loaded only the format of the
+// codes has been checked, and they are let through.
+func validateSupportedCurrencies(ctx context.Context, rateRepo repository.RateRepository, logger log.Logger, codes ...string) error {
+	currencies, err := rateRepo.GetSupportedCurrencies(ctx)
+	if err != nil {
+		level.Warn(logger).Log("error", err, "msg", "cannot check currencies against the supported list")
+		return nil
+	}
+
+	supported := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		supported[currency.Code] = true
+	}
+	for _, code := range codes {
+		if !supported[code] {
+			return errors.NewValidationError("unsupported currency "+code, "see /api/v1/currencies for the supported currencies")
+		}
+	}
+	return nil
+}
+
 // validateConversionRequest validates conversion request
 func (s *exchangeService) validateConversionRequest(req *models.ConversionRequest) error {
 	if req.FromCurrency == "" {
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+func TestAggregateHealth(t *testing.T) {
+	const healthy, unhealthy = models.HealthStatusHealthy, models.HealthStatusUnhealthy
+
+	tests := []struct {
+		name       string
+		components map[string]string
+		want       string
+	}{
+		{"all healthy", map[string]string{
+			"cache": healthy, "rate_validation": healthy,
+			"primary": healthy, "primary_circuit": "closed",
+			"backup": healthy, "backup_circuit": "closed",
+		}, healthy},
+		{"one provider failing its probe", map[string]string{
+			"cache": healthy, "primary": unhealthy, "primary_circuit": "closed",
+			"backup": healthy, "backup_circuit": "closed",
+		}, models.HealthStatusDegraded},
+		{"one circuit half-open", map[string]string{
+			"cache": healthy, "primary": healthy, "primary_circuit": "half-open",
+		}, models.HealthStatusDegraded},
+		{"cache disconnected", map[string]string{
+			"cache": unhealthy, "primary": healthy, "primary_circuit": "closed",
+		}, models.HealthStatusDegraded},
+		{"rates quarantined", map[string]string{
+			"cache": healthy, "rate_validation": models.HealthStatusDegraded, "primary": healthy, "primary_circuit": "closed",
+		}, models.HealthStatusDegraded},
+		{"every provider failing", map[string]string{
+			"cache": healthy, "primary": unhealthy, "primary_circuit": "closed",
+			"backup": unhealthy, "backup_circuit": "closed",
+		}, unhealthy},
+		{"probe passes but circuit open", map[string]string{
+			"cache": healthy, "primary": healthy, "primary_circuit": "open",
+		}, unhealthy},
+		{"no providers", map[string]string{"cache": healthy}, unhealthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregateHealth(tt.components); got != tt.want {
+				t.Errorf("aggregateHealth(%v) = %s, want %s", tt.components, got, tt.want)
+			}
+		})
+	}
+}
+
+// overrideRepo answers with the provider rate 0.9 unless an override is set
+// for the pair, which it applies like the repository does
+type overrideRepo struct {
+	repository.RateRepository
+	override *models.RateOverride
+}
+
+func (r *overrideRepo) SetOverride(_ context.Context, override *models.RateOverride) error {
+	r.override = override
+	return nil
+}
+
+func (r *overrideRepo) GetLatestRate(_ context.Context, base, target string) (*models.ExchangeRate, error) {
+	rate := &models.ExchangeRate{BaseCurrency: base, TargetCurrency: target, Rate: 0.9, Provider: "p"}
+	if o := r.override; o != nil && o.BaseCurrency == base && o.TargetCurrency == target {
+		rate.Rate, rate.Provider, rate.Author = o.Rate, repository.OverrideProvider, o.Author
+	}
+	return rate, nil
+}
+
+func (r *overrideRepo) GetHistoricalRate(_ context.Context, base, target string, date time.Time) (*models.HistoricalRate, error) {
+	rate := &models.HistoricalRate{BaseCurrency: base, TargetCurrency: target, Rate: 0.9, Date: date, Provider: "p"}
+	if o := r.override; o != nil && o.BaseCurrency == base && o.TargetCurrency == target {
+		rate.Rate, rate.Provider, rate.Author = o.Rate, repository.OverrideProvider, o.Author
+	}
+	return rate, nil
+}
+
+func TestConvertCurrencyReportsOverrides(t *testing.T) {
+	repo := &overrideRepo{}
+	svc := NewExchangeService(repo, historyConfig(31, 2), log.NewNopLogger())
+	ctx := context.Background()
+	override := &models.RateOverride{BaseCurrency: "USD", TargetCurrency: "EUR", Rate: 1.5, Author: "ops"}
+	if err := repo.SetOverride(ctx, override); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, date := range []string{"", "2024-03-01"} {
+		conversion, err := svc.ConvertCurrency(ctx, &models.ConversionRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 10, Date: date})
+		if err != nil {
+			t.Fatalf("date %q: ConvertCurrency: %v", date, err)
+		}
+		if conversion.ConvertedAmount != 15 || conversion.Provider != repository.OverrideProvider || conversion.Author != "ops" {
+			t.Errorf("date %q: conversion = %+v, want 15 from the override by ops", date, conversion)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"exchange-rate-service/internal/calendar"
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+)
+
+// calendarFor returns the named calendar, or the configured default
+func (s *exchangeService) calendarFor(name string) (calendar.Calendar, error) {
+	if name == "" {
+		name = s.history.Calendar
+	}
+	cal, err := calendar.Get(name)
+	if err != nil {
+		return nil, errors.NewValidationError("invalid calendar", err.Error())
+	}
+	return cal, nil
+}
+
+// resolveFill applies the configured defaults to opts and looks up its calendar
+func (s *exchangeService) resolveFill(opts models.FillOptions) (string, calendar.Calendar, error) {
+	policy := opts.Policy
+	if policy == "" {
+		policy = s.history.Fill
+	}
+
+	switch policy {
+	case models.FillNone, models.FillPrevious, models.FillNext, models.FillLinear:
+	default:
+		return "", nil, errors.NewValidationError("invalid fill policy", "fill must be one of none, previous, next, linear")
+	}
+
+	cal, err := s.calendarFor(opts.Calendar)
+	if err != nil {
+		return "", nil, err
+	}
+	return policy, cal, nil
+}
+
+// checkCoverage rejects dates the calendar has no holidays for, which would
+// otherwise silently be treated as weekends-only
+func checkCoverage(cal calendar.Calendar, start, end time.Time) error {
+	if err := calendar.CheckRange(cal, start, end); err != nil {
+		return errors.NewValidationError("date outside calendar", err.Error()+"; use calendar=weekends for other dates")
+	}
+	return nil
+}
+
+// fillNeighbours returns the business days whose rates are needed to fill date
+func fillNeighbours(cal calendar.Calendar, policy string, date time.Time) []time.Time {
+	var dates []time.Time
+	if policy == models.FillPrevious || policy == models.FillLinear {
+		if prev, ok := calendar.PreviousBusinessDay(cal, date); ok {
+			dates = append(dates, prev)
+		}
+	}
+	if policy == models.FillNext || policy == models.FillLinear {
+		// Rates for days after today haven't been published yet
+		if next, ok := calendar.NextBusinessDay(cal, date); ok && !next.After(time.Now()) {
+			dates = append(dates, next)
+		}
+	}
+	return dates
+}
+
+// fillRate derives the rate for a date that is not a business day from the
+// rates of the surrounding business days, either of which may be nil
+func fillRate(policy string, date time.Time, prev, next *models.HistoricalRate) (*models.HistoricalRate, error) {
+	var source *models.HistoricalRate
+	switch policy {
+	case models.FillPrevious:
+		source = prev
+	case models.FillNext:
+		source = next
+	case models.FillLinear:
+		if prev != nil && next != nil {
+			span := next.Date.Sub(prev.Date).Hours()
+			weight := date.Sub(prev.Date).Hours() / span
+			filled := *prev
+			filled.Rate = prev.Rate + (next.Rate-prev.Rate)*weight
+			filled.Date = date
+			filled.Point = models.PointFilled
+			filled.FilledFrom = []string{prev.Date.Format("2006-01-02"), next.Date.Format("2006-01-02")}
+			return &filled, nil
+		}
+	}
+
+	if source == nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("%s is not a business day and no %s business day rate is available to fill it",
+			date.Format("2006-01-02"), fillDirection(policy)))
+	}
+	filled := *source
+	filled.Date = date
+	filled.Point = models.PointFilled
+	filled.FilledFrom = []string{source.Date.Format("2006-01-02")}
+	return &filled, nil
+}
+
+func fillDirection(policy string) string {
+	switch policy {
+	case models.FillPrevious:
+		return "previous"
+	case models.FillNext:
+		return "next"
+	}
+	return "surrounding"
+}
+
+// getFilledRate answers a single date under a fill policy other than none
+func (s *exchangeService) getFilledRate(ctx context.Context, baseCurrency, targetCurrency string, date time.Time, policy string, cal calendar.Calendar) (*models.HistoricalRate, error) {
+	if cal.IsBusinessDay(date) {
+		rate, err := s.rateRepo.GetHistoricalRate(ctx, baseCurrency, targetCurrency, date)
+		if err != nil {
+			return nil, err
+		}
+		rate.Point = models.PointActual
+		return rate, nil
+	}
+
+	var prev, next *models.HistoricalRate
+	for _, d := range fillNeighbours(cal, policy, date) {
+		rate, err := s.rateRepo.GetHistoricalRate(ctx, baseCurrency, targetCurrency, d)
+		if err != nil {
+			return nil, err
+		}
+		if d.Before(date) {
+			prev = rate
+		} else {
+			next = rate
+		}
+	}
+	return fillRate(policy, date, prev, next)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+)
+
+// exportWindowDays is how many days of one pair are read from the history
+// store at a time while exporting, which bounds the memory an export uses
+const exportWindowDays = 31
+
+// ExportHistory streams the stored rates of baseCurrency against targets from
+// start to end inclusive to emit, pair by pair in date order. With no targets
+// every pair stored for the base is exported. Arguments are validated before
+// emit is first called, so callers can still report errors normally.
+func (s *exchangeService) ExportHistory(ctx context.Context, baseCurrency string, targets []string, start, end time.Time, emit func(*models.HistoricalRate) error) error {
+	level.Debug(s.logger).Log("method", "ExportHistory", "base", baseCurrency, "targets", strings.Join(targets, ","), "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
+
+	// The base ends up in a key pattern when listing stored targets
+	if err := validateCurrencyCode("base currency", baseCurrency); err != nil {
+		return err
+	}
+	for _, target := range targets {
+		if err := s.validateCurrencies(baseCurrency, target); err != nil {
+			return err
+		}
+	}
+	if end.Before(start) {
+		return errors.NewValidationError("invalid date range", "end_date must not be before start_date")
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > s.history.MaxExportRangeDays {
+		return errors.NewValidationError("date range too long",
+			fmt.Sprintf("an export may span at most %d days", s.history.MaxExportRangeDays))
+	}
+
+	if len(targets) == 0 {
+		var err error
+		if targets, err = s.rateRepo.GetStoredTargets(ctx, baseCurrency); err != nil {
+			level.Error(s.logger).Log("error", err, "method", "ExportHistory")
+			return errors.NewInternalError("failed to list stored pairs", err)
+		}
+	}
+
+	for _, target := range targets {
+		for from := start; !from.After(end); from = from.AddDate(0, 0, exportWindowDays) {
+			to := from.AddDate(0, 0, exportWindowDays-1)
+			if to.After(end) {
+				to = end
+			}
+			rates, err := s.rateRepo.GetStoredRates(ctx, baseCurrency, target, from, to)
+			if err != nil {
+				level.Error(s.logger).Log("error", err, "method", "ExportHistory")
+				return errors.NewInternalError("failed to read stored rates", err)
+			}
+			for _, rate := range rates {
+				if err := emit(rate); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// HistoryWriter encodes historical rates one at a time
+type HistoryWriter interface {
+	Write(rate *models.HistoricalRate) error
+	// Flush writes out buffered rates
+	Flush() error
+}
+
+// HistoryContentType returns the media type of a history export format
+func HistoryContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewHistoryWriter returns a writer for the csv or ndjson export format. CSV
+// output starts with a header and can be imported again as is.
+func NewHistoryWriter(w io.Writer, format string) (HistoryWriter, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(historyCSVHeader); err != nil {
+			return nil, err
+		}
+		return &csvHistoryWriter{w: cw}, nil
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonHistoryWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q, use csv or ndjson", format)
+}
+
+type csvHistoryWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvHistoryWriter) Write(rate *models.HistoricalRate) error {
+	return c.w.Write([]string{
+		rate.Date.Format("2006-01-02"),
+		rate.BaseCurrency,
+		rate.TargetCurrency,
+		strconv.FormatFloat(rate.Rate, 'f', -1, 64),
+		csvCell(rate.Provider),
+	})
+}
+
+// csvCell quotes free text that a spreadsheet would otherwise run as a
+// formula; parseCSVCell undoes it on import
+func csvCell(value string) string {
+	if needsCSVQuote(value) {
+		return "'" + value
+	}
+	return value
+}
+
+func parseCSVCell(cell string) string {
+	if strings.HasPrefix(cell, "'") && needsCSVQuote(cell[1:]) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// needsCSVQuote also quotes text that looks already quoted, so a value
+// starting with ' survives the round trip
+func needsCSVQuote(value string) bool {
+	for strings.HasPrefix(value, "'") {
+		value = value[1:]
+	}
+	return value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0]))
+}
+
+func (c *csvHistoryWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonHistoryWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonHistoryWriter) Write(rate *models.HistoricalRate) error {
+	return n.enc.Encode(rate)
+}
+
+func (n *ndjsonHistoryWriter) Flush() error {
+	return n.w.Flush()
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+)
+
+// Historical rate file formats
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// importBatchSize is how many imported rates are stored at once
+const importBatchSize = 500
+
+// maxListedConflicts caps the conflicts listed in an import result
+const maxListedConflicts = 100
+
+// historyCSVHeader is the column layout of historical rate CSV files; the
+// provider column is optional on import
+var historyCSVHeader = []string{"date", "base_currency", "target_currency", "rate", "provider"}
+
+// FormatFromPath guesses a historical rate file format from its extension
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	return FormatCSV
+}
+
+// DecodeHistoricalRates reads historical rates in CSV or JSON. CSV files start
+// with a header naming the date, base_currency, target_currency and rate
+// columns, in any order, and optionally provider; JSON files hold an array of
+// historical rates and NDJSON files one rate per line. Every rate is
+// validated, and source names the input in error messages.
+func DecodeHistoricalRates(r io.Reader, format, source string) ([]*models.HistoricalRate, error) {
+	var rates []*models.HistoricalRate
+	switch format {
+	case FormatJSON, FormatNDJSON:
+		if format == FormatJSON {
+			if err := json.NewDecoder(r).Decode(&rates); err != nil {
+				return nil, fmt.Errorf("%s: invalid JSON: %w", source, err)
+			}
+		} else {
+			decoder := json.NewDecoder(r)
+			for {
+				var rate models.HistoricalRate
+				if err := decoder.Decode(&rate); err == io.EOF {
+					break
+				} else if err != nil {
+					return nil, fmt.Errorf("%s: rate %d: invalid JSON: %w", source, len(rates)+1, err)
+				}
+				rates = append(rates, &rate)
+			}
+		}
+		for i, rate := range rates {
+			if rate == nil {
+				return nil, fmt.Errorf("%s: rate %d: empty entry", source, i+1)
+			}
+			if err := checkImportedRate(rate); err != nil {
+				return nil, fmt.Errorf("%s: rate %d: %w", source, i+1, err)
+			}
+			rate.Date = truncateDay(rate.Date)
+		}
+	case FormatCSV:
+		var err error
+		if rates, err = decodeHistoryCSV(r, source); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q, use csv, json or ndjson", format)
+	}
+
+	now := time.Now()
+	for _, rate := range rates {
+		rate.BaseCurrency = strings.ToUpper(rate.BaseCurrency)
+		rate.TargetCurrency = strings.ToUpper(rate.TargetCurrency)
+		if rate.Provider == "" {
+			rate.Provider = "import"
+		}
+		if rate.FetchedAt.IsZero() {
+			rate.FetchedAt = now
+		}
+	}
+	return rates, nil
+}
+
+func decodeHistoryCSV(r io.Reader, source string) ([]*models.HistoricalRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: missing header: %w", source, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range historyCSVHeader[:4] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s: header must include %s", source, strings.Join(historyCSVHeader[:4], ", "))
+		}
+	}
+
+	var rates []*models.HistoricalRate
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+
+		date, err := time.Parse("2006-01-02", record[columns["date"]])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid date %q", source, line, record[columns["date"]])
+		}
+		value, err := strconv.ParseFloat(record[columns["rate"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rate %q", source, line, record[columns["rate"]])
+		}
+		rate := &models.HistoricalRate{
+			BaseCurrency:   record[columns["base_currency"]],
+			TargetCurrency: record[columns["target_currency"]],
+			Rate:           value,
+			Date:           date,
+		}
+		if i, ok := columns["provider"]; ok {
+			rate.Provider = parseCSVCell(record[i])
+		}
+		if err := checkImportedRate(rate); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", source, line, err)
+		}
+		rates = append(rates, rate)
+	}
+}
+
+// importHistory stores imported rates, resolving rates that differ from the
+// stored ones by policy: skip keeps the stored rate, overwrite replaces it and
+// fail stores nothing and returns a conflict error. Stored rates are read and
+// written through lookup and put so the same rules apply to the repository
+// and to a bare history store.
+func importHistory(ctx context.Context, lookup storedRatesFunc, put storeRatesFunc, rates []*models.HistoricalRate, policy string) (*models.HistoryImportResult, error) {
+	if policy == "" {
+		policy = models.ConflictOverwrite
+	}
+	if policy != models.ConflictSkip && policy != models.ConflictOverwrite && policy != models.ConflictFail {
+		return nil, errors.NewValidationError("invalid conflict policy", "on_conflict must be skip, overwrite or fail")
+	}
+	result := &models.HistoryImportResult{Policy: policy, Received: len(rates)}
+
+	// Later rows for the same pair and date replace earlier ones
+	type span struct{ start, end time.Time }
+	pairs := make(map[string]span)
+	latest := make(map[string]*models.HistoricalRate)
+	var order []string
+	for _, rate := range rates {
+		key := rate.BaseCurrency + ":" + rate.TargetCurrency + ":" + rate.Date.Format("2006-01-02")
+		if _, seen := latest[key]; !seen {
+			order = append(order, key)
+		}
+		latest[key] = rate
+
+		pair := rate.BaseCurrency + ":" + rate.TargetCurrency
+		sp, ok := pairs[pair]
+		if !ok || rate.Date.Before(sp.start) {
+			sp.start = rate.Date
+		}
+		if !ok || rate.Date.After(sp.end) {
+			sp.end = rate.Date
+		}
+		pairs[pair] = sp
+	}
+	result.Duplicates = len(rates) - len(order)
+
+	stored := make(map[string]*models.HistoricalRate)
+	for pair, sp := range pairs {
+		base, target, _ := strings.Cut(pair, ":")
+		existing, err := lookup(ctx, base, target, sp.start, sp.end)
+		if err != nil {
+			return nil, errors.NewInternalError("failed to read stored rates", err)
+		}
+		for _, rate := range existing {
+			stored[pair+":"+rate.Date.Format("2006-01-02")] = rate
+		}
+	}
+
+	var pending []*models.HistoricalRate
+	var conflicts int
+	for _, key := range order {
+		rate := latest[key]
+		if old, exists := stored[key]; exists {
+			if math.Abs(old.Rate-rate.Rate) <= 1e-12*math.Abs(old.Rate) {
+				result.Unchanged++
+				continue
+			}
+			conflicts++
+			if len(result.Conflicts) < maxListedConflicts {
+				result.Conflicts = append(result.Conflicts, models.HistoryConflict{
+					Date:           rate.Date.Format("2006-01-02"),
+					BaseCurrency:   rate.BaseCurrency,
+					TargetCurrency: rate.TargetCurrency,
+					StoredRate:     old.Rate,
+					ImportedRate:   rate.Rate,
+				})
+			}
+			switch policy {
+			case models.ConflictSkip:
+				result.Skipped++
+				continue
+			case models.ConflictOverwrite:
+				result.Overwritten++
+			}
+		}
+		pending = append(pending, rate)
+	}
+
+	if policy == models.ConflictFail && conflicts > 0 {
+		first := result.Conflicts[0]
+		return result, errors.NewConflictError(
+			fmt.Sprintf("%d imported rates differ from stored rates, first %s/%s on %s: stored %v, imported %v",
+				conflicts, first.BaseCurrency, first.TargetCurrency, first.Date, first.StoredRate, first.ImportedRate),
+			"nothing was imported; use on_conflict=skip or overwrite to import anyway")
+	}
+
+	for start := 0; start < len(pending); start += importBatchSize {
+		batch := pending[start:min(start+importBatchSize, len(pending))]
+		if err := put(ctx, batch...); err != nil {
+			return result, errors.NewInternalError("failed to store rates", err)
+		}
+		result.Stored += len(batch)
+	}
+	return result, nil
+}
+
+// storedRatesFunc and storeRatesFunc read and write the history store
+type (
+	storedRatesFunc func(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time) ([]*models.HistoricalRate, error)
+	storeRatesFunc  func(ctx context.Context, rates ...*models.HistoricalRate) error
+)
+
+// checkImportedRate rejects rates that can't be stored
+func checkImportedRate(rate *models.HistoricalRate) error {
+	if !isCurrencyCode(rate.BaseCurrency) || !isCurrencyCode(rate.TargetCurrency) {
+		return fmt.Errorf("currencies must be 3-letter codes, got %q and %q", rate.BaseCurrency, rate.TargetCurrency)
+	}
+	if strings.EqualFold(rate.BaseCurrency, rate.TargetCurrency) {
+		return fmt.Errorf("base and target currency are both %s", rate.BaseCurrency)
+	}
+	if rate.Rate <= 0 || math.IsInf(rate.Rate, 0) || math.IsNaN(rate.Rate) {
+		return fmt.Errorf("rate must be a positive number, got %v", rate.Rate)
+	}
+	if rate.Date.IsZero() {
+		return fmt.Errorf("date is required")
+	}
+	return nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+// historyStoreRepo serves stored history from a real history store
+type historyStoreRepo struct {
+	repository.RateRepository
+	store repository.HistoryStore
+}
+
+func (r *historyStoreRepo) GetStoredRates(ctx context.Context, base, target string, start, end time.Time) ([]*models.HistoricalRate, error) {
+	return r.store.Range(ctx, base, target, start, end)
+}
+
+func (r *historyStoreRepo) GetStoredTargets(ctx context.Context, base string) ([]string, error) {
+	return r.store.Targets(ctx, base)
+}
+
+func (r *historyStoreRepo) StoreRates(ctx context.Context, rates ...*models.HistoricalRate) error {
+	return r.store.Put(ctx, rates...)
+}
+
+func newHistoryStoreRepo(t *testing.T) *historyStoreRepo {
+	store, err := repository.NewFileHistoryStore(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &historyStoreRepo{store: store}
+}
+
+func TestImportHistoryConflictPolicies(t *testing.T) {
+	repo := newHistoryStoreRepo(t)
+	svc := NewAdminService(repo, log.NewNopLogger())
+	ctx := context.Background()
+
+	initial := "date,base_currency,target_currency,rate\n2024-01-01,USD,EUR,0.91\n2024-01-02,USD,EUR,0.92\n"
+	if _, err := svc.ImportHistory(ctx, strings.NewReader(initial), FormatCSV, ""); err != nil {
+		t.Fatalf("ImportHistory: %v", err)
+	}
+
+	update := `{"date":"2024-01-01T00:00:00Z","base_currency":"USD","target_currency":"EUR","rate":0.91}
+{"date":"2024-01-02T00:00:00Z","base_currency":"USD","target_currency":"EUR","rate":0.95}
+{"date":"2024-01-03T00:00:00Z","base_currency":"USD","target_currency":"EUR","rate":0.93}
+`
+	result, err := svc.ImportHistory(ctx, strings.NewReader(update), FormatNDJSON, models.ConflictFail)
+	if !errors.IsConflictError(err) {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].StoredRate != 0.92 || result.Stored != 0 {
+		t.Errorf("unexpected fail result %+v", result)
+	}
+
+	result, err = svc.ImportHistory(ctx, strings.NewReader(update), FormatNDJSON, models.ConflictSkip)
+	if err != nil {
+		t.Fatalf("ImportHistory: %v", err)
+	}
+	if result.Stored != 1 || result.Skipped != 1 || result.Unchanged != 1 {
+		t.Errorf("unexpected skip result %+v", result)
+	}
+	if rate, _ := repo.store.Get(ctx, "USD", "EUR", day("2024-01-02")); rate.Rate != 0.92 {
+		t.Errorf("skip replaced the stored rate with %v", rate.Rate)
+	}
+
+	result, err = svc.ImportHistory(ctx, strings.NewReader(update), FormatNDJSON, models.ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("ImportHistory: %v", err)
+	}
+	if result.Stored != 1 || result.Overwritten != 1 || result.Unchanged != 2 {
+		t.Errorf("unexpected overwrite result %+v", result)
+	}
+	if rate, _ := repo.store.Get(ctx, "USD", "EUR", day("2024-01-02")); rate.Rate != 0.95 {
+		t.Errorf("overwrite kept the stored rate %v", rate.Rate)
+	}
+
+	if _, err := svc.ImportHistory(ctx, strings.NewReader(initial), FormatCSV, "merge"); !errors.IsValidationError(err) {
+		t.Errorf("expected a validation error for an unknown policy, got %v", err)
+	}
+}
+
+func TestExportHistoryRoundTrips(t *testing.T) {
+	repo := newHistoryStoreRepo(t)
+	ctx := context.Background()
+	var rates []*models.HistoricalRate
+	for d := day("2024-01-01"); d.Before(day("2024-03-01")); d = d.AddDate(0, 0, 1) {
+		rates = append(rates,
+			&models.HistoricalRate{BaseCurrency: "USD", TargetCurrency: "EUR", Rate: 0.9, Date: d, Provider: "test"},
+			&models.HistoricalRate{BaseCurrency: "USD", TargetCurrency: "GBP", Rate: 0.8, Date: d, Provider: "test"})
+	}
+	if err := repo.store.Put(ctx, rates...); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewExchangeService(repo, historyConfig(31, 2), log.NewNopLogger())
+
+	var buf bytes.Buffer
+	writer, err := NewHistoryWriter(&buf, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = svc.ExportHistory(ctx, "USD", nil, day("2024-01-15"), day("2024-02-14"), writer.Write)
+	if err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := DecodeHistoricalRates(&buf, FormatCSV, "export")
+	if err != nil {
+		t.Fatalf("exported CSV doesn't import: %v", err)
+	}
+	if len(exported) != 62 {
+		t.Fatalf("exported %d rates, want 62", len(exported))
+	}
+	if first := exported[0]; first.TargetCurrency != "EUR" || !first.Date.Equal(day("2024-01-15")) || first.Provider != "test" {
+		t.Errorf("unexpected first row %+v", first)
+	}
+	if last := exported[61]; last.TargetCurrency != "GBP" || !last.Date.Equal(day("2024-02-14")) {
+		t.Errorf("unexpected last row %+v", last)
+	}
+
+	for _, tt := range []struct {
+		base       string
+		targets    []string
+		start, end time.Time
+	}{
+		{"USD", []string{"USD"}, day("2024-01-01"), day("2024-01-02")},
+		{"*", nil, day("2024-01-01"), day("2024-01-02")},
+		{"US?", nil, day("2024-01-01"), day("2024-01-02")},
+		{"USD", nil, day("0001-01-01"), day("9999-12-31")},
+	} {
+		if err := svc.ExportHistory(ctx, tt.base, tt.targets, tt.start, tt.end, writer.Write); !errors.IsValidationError(err) {
+			t.Errorf("%s %v: expected a validation error, got %v", tt.base, tt.targets, err)
+		}
+	}
+}
+
+func TestCSVHistoryWriterNeutralisesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewHistoryWriter(&buf, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, provider := range []string{"=HYPERLINK(\"x\")", "+1", "-1", "@SUM(A1)", "test"} {
+		rate := &models.HistoricalRate{BaseCurrency: "USD", TargetCurrency: "EUR", Rate: 0.9, Date: day("2024-01-01"), Provider: provider}
+		if err := writer.Write(rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")[1:]
+	want := []string{`'=HYPERLINK(""x"")"`, "'+1", "'-1", "'@SUM(A1)", "test"}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Errorf("row %d = %q, want it to end with %q", i, line, want[i])
+		}
+	}
+}
+
+func TestCSVHistoryRoundTripsQuotedProviders(t *testing.T) {
+	repo := newHistoryStoreRepo(t)
+	ctx := context.Background()
+	providers := []string{"=HYPERLINK(\"x\")", "+1", "-1", "@SUM(A1)", "'=quoted", "'plain", "test"}
+	var rates []*models.HistoricalRate
+	for i, provider := range providers {
+		rates = append(rates, &models.HistoricalRate{BaseCurrency: "USD", TargetCurrency: "EUR", Rate: 0.9, Date: day("2024-01-01").AddDate(0, 0, i), Provider: provider})
+	}
+	if err := repo.store.Put(ctx, rates...); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewExchangeService(repo, historyConfig(31, 2), log.NewNopLogger())
+
+	var buf bytes.Buffer
+	writer, err := NewHistoryWriter(&buf, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ExportHistory(ctx, "USD", nil, day("2024-01-01"), day("2024-01-31"), writer.Write); err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := DecodeHistoricalRates(&buf, FormatCSV, "export")
+	if err != nil {
+		t.Fatalf("exported CSV doesn't import: %v", err)
+	}
+	if len(imported) != len(providers) {
+		t.Fatalf("imported %d rates, want %d", len(imported), len(providers))
+	}
+	for i, rate := range imported {
+		if rate.Provider != providers[i] || rate.Rate != 0.9 || !rate.Date.Equal(rates[i].Date) {
+			t.Errorf("row %d imported as %+v, want provider %q", i, rate, providers[i])
+		}
+	}
+
+	result, err := importHistory(ctx, repo.GetStoredRates, repo.StoreRates, imported, models.ConflictFail)
+	if err != nil {
+		t.Fatalf("re-importing the export: %v", err)
+	}
+	if result.Unchanged != len(providers) {
+		t.Errorf("re-import left %d rates unchanged, want %d: %+v", result.Unchanged, len(providers), result)
+	}
+}
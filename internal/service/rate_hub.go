@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// RateHub fans rate changes out to subscribers. Each subscribed base currency
+// is refreshed once per interval however many clients watch it, and tables the
+// repository refreshes for other reasons are forwarded as well.
+type RateHub interface {
+	Subscribe(ctx context.Context, filter models.RateFilter, lastEventID uint64) (*RateSubscription, error)
+	Run(ctx context.Context)
+}
+
+// RateSubscription delivers the updates matching its filter on Updates. The
+// channel is closed when the subscription ends: its context is done, Close is
+// called, or the subscriber fell so far behind that updates had to be dropped.
+type RateSubscription struct {
+	Updates <-chan *models.RateUpdate
+
+	hub     *rateHub
+	updates chan *models.RateUpdate
+	filter  rateFilter
+	closed  bool
+}
+
+// Close ends the subscription
+func (s *RateSubscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+// Add extends the subscription and sends a snapshot of the rates added
+func (s *RateSubscription) Add(ctx context.Context, filter models.RateFilter) error {
+	added, err := normalizeRateFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	h := s.hub
+	h.mu.Lock()
+	current := make(rateFilter, len(s.filter))
+	for base := range s.filter {
+		current[base] = nil
+	}
+	h.mu.Unlock()
+	if err := h.checkFilter(ctx, added, current); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	if !s.closed {
+		for base, targets := range added {
+			current, exists := s.filter[base]
+			switch {
+			case exists && current == nil:
+				// Already subscribed to every rate of the base
+			case !exists || targets == nil:
+				s.filter[base] = targets
+			default:
+				for target := range targets {
+					current[target] = true
+				}
+			}
+		}
+		h.snapshotLocked(s, added)
+	}
+	h.mu.Unlock()
+
+	h.requestRefresh(added)
+	return nil
+}
+
+// Remove narrows the subscription. Removing a base drops all of its rates;
+// removing a single pair of a base subscribed as a whole has no effect.
+func (s *RateSubscription) Remove(filter models.RateFilter) error {
+	removed, err := normalizeRateFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for base, targets := range removed {
+		current, exists := s.filter[base]
+		if !exists || (current == nil && targets != nil) {
+			continue
+		}
+		for target := range targets {
+			delete(current, target)
+		}
+		if targets == nil || len(current) == 0 {
+			delete(s.filter, base)
+		}
+	}
+	return nil
+}
+
+// rateFilter maps a base currency to the targets subscribed; a nil target set
+// subscribes to every rate of the base
+type rateFilter map[string]map[string]bool
+
+// apply narrows an update to the rates the filter selects, or returns nil if
+// none of them are in it
+func (f rateFilter) apply(update *models.RateUpdate) *models.RateUpdate {
+	targets, ok := f[update.BaseCurrency]
+	if !ok {
+		return nil
+	}
+	if targets == nil {
+		return update
+	}
+
+	rates := make(map[string]float64)
+	for currency, rate := range update.Rates {
+		if targets[currency] {
+			rates[currency] = rate
+		}
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+	filtered := *update
+	filtered.Rates = rates
+	return &filtered
+}
+
+// rateHub implements RateHub
+type rateHub struct {
+	rateRepo repository.RateRepository
+	logger   log.Logger
+	config   configs.StreamingConfig
+	refresh  chan string
+
+	mu          sync.Mutex
+	lastID      uint64
+	latest      map[string]*models.RateTable
+	history     []*models.RateUpdate
+	subscribers map[*RateSubscription]struct{}
+}
+
+// NewRateHub creates a rate hub fed by the repository's refresh notifications
+func NewRateHub(rateRepo repository.RateRepository, config configs.StreamingConfig, logger log.Logger) RateHub {
+	h := &rateHub{
+		rateRepo:    rateRepo,
+		logger:      logger,
+		config:      config,
+		refresh:     make(chan string, 64),
+		latest:      make(map[string]*models.RateTable),
+		subscribers: make(map[*RateSubscription]struct{}),
+	}
+	rateRepo.AddRateListener(h.publish)
+	return h
+}
+
+// Subscribe starts a subscription. With a lastEventID the updates missed since
+// that event are replayed when they are still in the hub's history; otherwise
+// the subscription starts with a snapshot of each subscribed base.
+func (h *rateHub) Subscribe(ctx context.Context, filter models.RateFilter, lastEventID uint64) (*RateSubscription, error) {
+	level.Debug(h.logger).Log("method", "Subscribe", "bases", strings.Join(filter.Bases, ","), "pairs", len(filter.Pairs), "last_event_id", lastEventID)
+
+	normalized, err := normalizeRateFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.checkFilter(ctx, normalized, nil); err != nil {
+		return nil, err
+	}
+
+	updates := make(chan *models.RateUpdate, h.config.SubscriberBuffer)
+	sub := &RateSubscription{Updates: updates, hub: h, updates: updates, filter: normalized}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	if lastEventID > 0 && h.canResumeLocked(lastEventID) {
+		for _, update := range h.history {
+			if update.ID > lastEventID {
+				if filtered := sub.filter.apply(update); filtered != nil {
+					h.deliverLocked(sub, filtered)
+				}
+			}
+		}
+	} else {
+		h.snapshotLocked(sub, normalized)
+	}
+	h.mu.Unlock()
+
+	h.requestRefresh(normalized)
+
+	go func() {
+		<-ctx.Done()
+		sub.Close()
+	}()
+
+	return sub, nil
+}
+
+// checkFilter rejects a filter that, together with the bases a subscription
+// already has, covers more than the configured number of base currencies or
+// names a currency the providers don't quote. Each base costs a refresh per
+// interval, so an unbounded or made-up list would only load the providers.
+func (h *rateHub) checkFilter(ctx context.Context, filter, current rateFilter) error {
+	bases := len(current)
+	for base := range filter {
+		if _, ok := current[base]; !ok {
+			bases++
+		}
+	}
+	if bases > h.config.MaxBases {
+		return errors.NewValidationError("too many base currencies",
+			fmt.Sprintf("a subscription may cover at most %d base currencies", h.config.MaxBases))
+	}
+
+	var codes []string
+	for base, targets := range filter {
+		codes = append(codes, base)
+		for target := range targets {
+			codes = append(codes, target)
+		}
+	}
+	return validateSupportedCurrencies(ctx, h.rateRepo, h.logger, codes...)
+}
+
+// Run refreshes every subscribed base currency once per refresh interval
+// until ctx is done, then ends every subscription
+func (h *rateHub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.mu.Lock()
+			for sub := range h.subscribers {
+				h.removeLocked(sub)
+			}
+			h.mu.Unlock()
+			return
+		case base := <-h.refresh:
+			h.refreshBase(ctx, base)
+		case <-ticker.C:
+			for _, base := range h.subscribedBases() {
+				h.refreshBase(ctx, base)
+			}
+		}
+	}
+}
+
+// requestRefresh asks Run to fetch the bases right away instead of on the
+// next tick, so new subscribers don't wait a full interval for rates
+func (h *rateHub) requestRefresh(filter rateFilter) {
+	for base := range filter {
+		select {
+		case h.refresh <- base:
+		default:
+		}
+	}
+}
+
+// refreshBase reads the latest table, which also picks up tables another
+// replica wrote to the shared cache
+func (h *rateHub) refreshBase(ctx context.Context, base string) {
+	table, err := h.rateRepo.GetLatestRates(ctx, base)
+	if err != nil {
+		level.Error(h.logger).Log("error", err, "msg", "failed to refresh streamed rates", "base", base)
+		return
+	}
+	h.publish(table)
+}
+
+func (h *rateHub) subscribedBases() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var bases []string
+	for sub := range h.subscribers {
+		for base := range sub.filter {
+			if !seen[base] {
+				seen[base] = true
+				bases = append(bases, base)
+			}
+		}
+	}
+	return bases
+}
+
+// publish records a table and sends the rates that changed since the previous
+// one for the same base to every interested subscriber
+func (h *rateHub) publish(table *models.RateTable) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.latest[table.BaseCurrency]
+	changed := make(map[string]float64)
+	for currency, rate := range table.Rates {
+		if previous == nil || previous.Rates[currency] != rate {
+			changed[currency] = rate
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	h.latest[table.BaseCurrency] = table
+
+	h.lastID++
+	update := &models.RateUpdate{
+		ID:           h.lastID,
+		BaseCurrency: table.BaseCurrency,
+		Rates:        changed,
+		Provider:     table.Provider,
+		FetchedAt:    table.FetchedAt,
+		IsStale:      table.IsStale,
+	}
+	h.history = append(h.history, update)
+	if len(h.history) > h.config.HistorySize {
+		h.history = h.history[len(h.history)-h.config.HistorySize:]
+	}
+
+	for sub := range h.subscribers {
+		if filtered := sub.filter.apply(update); filtered != nil {
+			h.deliverLocked(sub, filtered)
+		}
+	}
+}
+
+// canResumeLocked reports whether every update after lastEventID is still in
+// the history; callers hold mu
+func (h *rateHub) canResumeLocked(lastEventID uint64) bool {
+	if lastEventID > h.lastID {
+		return false
+	}
+	if lastEventID == h.lastID {
+		return true
+	}
+	return len(h.history) > 0 && h.history[0].ID <= lastEventID+1
+}
+
+// snapshotLocked sends the subscriber the current rates the filter selects;
+// callers hold mu
+func (h *rateHub) snapshotLocked(sub *RateSubscription, filter rateFilter) {
+	for base := range filter {
+		table, ok := h.latest[base]
+		if !ok {
+			continue
+		}
+		snapshot := &models.RateUpdate{
+			ID:           h.lastID,
+			BaseCurrency: base,
+			Rates:        table.Rates,
+			Provider:     table.Provider,
+			FetchedAt:    table.FetchedAt,
+			IsStale:      table.IsStale,
+			Snapshot:     true,
+		}
+		if filtered := filter.apply(snapshot); filtered != nil {
+			h.deliverLocked(sub, filtered)
+		}
+	}
+}
+
+// deliverLocked queues an update without blocking. A subscriber whose buffer
+// is full is dropped so one slow client can't hold up the others; it can
+// reconnect and resume from its last event. Callers hold mu.
+func (h *rateHub) deliverLocked(sub *RateSubscription, update *models.RateUpdate) {
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.updates <- update:
+	default:
+		level.Warn(h.logger).Log("msg", "dropping slow rate subscriber", "buffer", cap(sub.updates))
+		h.removeLocked(sub)
+	}
+}
+
+// removeLocked ends a subscription; callers hold mu
+func (h *rateHub) removeLocked(sub *RateSubscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subscribers, sub)
+	close(sub.updates)
+}
+
+// normalizeRateFilter validates a filter and upper-cases its currencies
+func normalizeRateFilter(filter models.RateFilter) (rateFilter, error) {
+	if len(filter.Bases) == 0 && len(filter.Pairs) == 0 {
+		return nil, errors.NewValidationError("no currencies given", "give a base currency or a list of pairs")
+	}
+
+	normalized := make(rateFilter)
+	for _, base := range filter.Bases {
+		base = strings.ToUpper(strings.TrimSpace(base))
+		if base == "" {
+			return nil, errors.NewValidationError("base currency is required", "base currency cannot be empty")
+		}
+		if err := validateCurrencyCode("base currency", base); err != nil {
+			return nil, err
+		}
+		normalized[base] = nil
+	}
+	for _, pair := range filter.Pairs {
+		base := strings.ToUpper(strings.TrimSpace(pair.BaseCurrency))
+		target := strings.ToUpper(strings.TrimSpace(pair.TargetCurrency))
+		if err := validateCurrencyPair(base, target); err != nil {
+			return nil, err
+		}
+		if err := validateCurrencyCode("base currency", base); err != nil {
+			return nil, err
+		}
+		if err := validateCurrencyCode("target currency", target); err != nil {
+			return nil, err
+		}
+		targets, exists := normalized[base]
+		if exists && targets == nil {
+			continue
+		}
+		if targets == nil {
+			targets = make(map[string]bool)
+			normalized[base] = targets
+		}
+		targets[target] = true
+	}
+	return normalized, nil
+}
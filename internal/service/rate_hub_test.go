@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+// hubRepo supports a fixed set of currencies; tables reach the hub through
+// its listener
+type hubRepo struct {
+	repository.RateRepository
+	currencies []string
+}
+
+func (r *hubRepo) AddRateListener(repository.RateListener) {}
+
+func (r *hubRepo) GetSupportedCurrencies(context.Context) ([]*models.Currency, error) {
+	var currencies []*models.Currency
+	for _, code := range r.currencies {
+		currencies = append(currencies, &models.Currency{Code: code})
+	}
+	return currencies, nil
+}
+
+func newTestHub(t *testing.T, historySize, buffer int) *rateHub {
+	t.Helper()
+	config := configs.Default().Streaming
+	config.HistorySize = historySize
+	config.SubscriberBuffer = buffer
+	config.MaxBases = 2
+	repo := &hubRepo{currencies: []string{"USD", "EUR", "GBP", "JPY"}}
+	return NewRateHub(repo, config, log.NewNopLogger()).(*rateHub)
+}
+
+func subscribe(t *testing.T, h *rateHub, filter models.RateFilter, lastEventID uint64) *RateSubscription {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	sub, err := h.Subscribe(ctx, filter, lastEventID)
+	if err != nil {
+		t.Fatalf("Subscribe(%+v): %v", filter, err)
+	}
+	return sub
+}
+
+func usdTable(eur, gbp float64) *models.RateTable {
+	return &models.RateTable{BaseCurrency: "USD", Rates: map[string]float64{"EUR": eur, "GBP": gbp}, Provider: "p", FetchedAt: time.Now()}
+}
+
+// received drains the updates queued on a subscription
+func received(sub *RateSubscription) []*models.RateUpdate {
+	var updates []*models.RateUpdate
+	for {
+		select {
+		case update, ok := <-sub.Updates:
+			if !ok {
+				return updates
+			}
+			updates = append(updates, update)
+		default:
+			return updates
+		}
+	}
+}
+
+func TestRateHubPublishesChangedRates(t *testing.T) {
+	h := newTestHub(t, 16, 16)
+	all := subscribe(t, h, models.RateFilter{Bases: []string{"usd"}}, 0)
+	pair := subscribe(t, h, models.RateFilter{Pairs: []models.CurrencyPair{{BaseCurrency: "USD", TargetCurrency: "GBP"}}}, 0)
+
+	h.publish(usdTable(0.90, 0.80))
+	h.publish(usdTable(0.91, 0.80))
+	h.publish(usdTable(0.91, 0.80))
+
+	updates := received(all)
+	if len(updates) != 2 {
+		t.Fatalf("got %d updates, want 2 (an unchanged table sends nothing)", len(updates))
+	}
+	if len(updates[0].Rates) != 2 || updates[1].ID != updates[0].ID+1 {
+		t.Errorf("first updates = %+v, %+v", updates[0], updates[1])
+	}
+	if rates := updates[1].Rates; len(rates) != 1 || rates["EUR"] != 0.91 {
+		t.Errorf("second update rates = %v, want only the EUR change", rates)
+	}
+
+	// The pair subscriber only sees GBP, which changed once
+	if updates := received(pair); len(updates) != 1 || len(updates[0].Rates) != 1 || updates[0].Rates["GBP"] != 0.80 {
+		t.Errorf("pair subscriber got %+v", updates)
+	}
+
+	// A new subscriber starts from a snapshot of the current table
+	late := subscribe(t, h, models.RateFilter{Bases: []string{"USD"}}, 0)
+	if updates := received(late); len(updates) != 1 || !updates[0].Snapshot || updates[0].Rates["EUR"] != 0.91 {
+		t.Errorf("late subscriber got %+v, want a snapshot", updates)
+	}
+}
+
+func TestRateHubResumesFromLastEventID(t *testing.T) {
+	h := newTestHub(t, 2, 16)
+	h.publish(usdTable(0.90, 0.80)) // id 1
+	h.publish(usdTable(0.91, 0.80)) // id 2
+	h.publish(usdTable(0.91, 0.81)) // id 3
+
+	sub := subscribe(t, h, models.RateFilter{Bases: []string{"USD"}}, 1)
+	updates := received(sub)
+	if len(updates) != 2 || updates[0].ID != 2 || updates[1].ID != 3 || updates[0].Snapshot {
+		t.Errorf("resuming after 1 replayed %+v, want events 2 and 3", updates)
+	}
+
+	sub = subscribe(t, h, models.RateFilter{Bases: []string{"USD"}}, 3)
+	if updates := received(sub); len(updates) != 0 {
+		t.Errorf("resuming from the latest event replayed %+v", updates)
+	}
+
+	// Event 1 has left the history, so there is a gap to fill with a snapshot
+	h.publish(usdTable(0.92, 0.81)) // id 4
+	sub = subscribe(t, h, models.RateFilter{Bases: []string{"USD"}}, 1)
+	if updates := received(sub); len(updates) != 1 || !updates[0].Snapshot || updates[0].ID != 4 {
+		t.Errorf("resuming past the history got %+v, want a snapshot", updates)
+	}
+}
+
+func TestRateHubDropsSlowSubscribers(t *testing.T) {
+	h := newTestHub(t, 16, 1)
+	slow := subscribe(t, h, models.RateFilter{Bases: []string{"USD"}}, 0)
+	fast := subscribe(t, h, models.RateFilter{Bases: []string{"USD"}}, 0)
+
+	h.publish(usdTable(0.90, 0.80))
+	if updates := received(fast); len(updates) != 1 {
+		t.Fatalf("fast subscriber got %d updates", len(updates))
+	}
+	h.publish(usdTable(0.91, 0.80))
+
+	// The slow subscriber's buffer held the first update, so it was dropped
+	// and its channel closed after what it had queued
+	first, ok := <-slow.Updates
+	if !ok || first.ID != 1 {
+		t.Fatalf("slow subscriber's queued update = %+v, %v", first, ok)
+	}
+	if _, ok := <-slow.Updates; ok {
+		t.Error("slow subscriber's channel still open")
+	}
+	if updates := received(fast); len(updates) != 1 || updates[0].ID != 2 {
+		t.Errorf("fast subscriber got %+v after the slow one was dropped", updates)
+	}
+	if len(h.subscribers) != 1 {
+		t.Errorf("%d subscribers left, want 1", len(h.subscribers))
+	}
+}
+
+func TestRateHubValidatesSubscriptions(t *testing.T) {
+	h := newTestHub(t, 16, 16)
+	ctx := context.Background()
+
+	for name, filter := range map[string]models.RateFilter{
+		"unsupported base":   {Bases: []string{"XYZ"}},
+		"malformed base":     {Bases: []string{"US*"}},
+		"unsupported target": {Pairs: []models.CurrencyPair{{BaseCurrency: "USD", TargetCurrency: "ABC"}}},
+		"too many bases":     {Bases: []string{"USD", "EUR", "GBP"}},
+		"too many with pair": {Bases: []string{"USD", "EUR"}, Pairs: []models.CurrencyPair{{BaseCurrency: "GBP", TargetCurrency: "JPY"}}},
+	} {
+		if _, err := h.Subscribe(ctx, filter, 0); err == nil {
+			t.Errorf("%s: Subscribe(%+v) succeeded", name, filter)
+		}
+	}
+	if len(h.subscribers) != 0 {
+		t.Errorf("rejected subscriptions left %d subscribers", len(h.subscribers))
+	}
+
+	// Adding counts the bases already subscribed
+	sub := subscribe(t, h, models.RateFilter{Bases: []string{"USD", "EUR"}}, 0)
+	if err := sub.Add(ctx, models.RateFilter{Pairs: []models.CurrencyPair{{BaseCurrency: "USD", TargetCurrency: "JPY"}}}); err != nil {
+		t.Errorf("adding a pair of a subscribed base: %v", err)
+	}
+	if err := sub.Add(ctx, models.RateFilter{Bases: []string{"GBP"}}); err == nil {
+		t.Error("adding a third base succeeded")
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+)
+
+// ValidGranularity reports whether g is a supported time series granularity
+func ValidGranularity(g string) bool {
+	switch g {
+	case models.GranularityDay, models.GranularityWeek, models.GranularityMonth, models.GranularityQuarter, models.GranularityYear:
+		return true
+	}
+	return false
+}
+
+// periodBounds returns the first and last day of the calendar period holding date
+func periodBounds(date time.Time, granularity string) (time.Time, time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	switch granularity {
+	case models.GranularityWeek:
+		// ISO weeks start on Monday
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6)
+	case models.GranularityMonth:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 1, -1)
+	case models.GranularityQuarter:
+		month := time.Month((int(day.Month())-1)/3*3 + 1)
+		start := time.Date(day.Year(), month, 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 3, -1)
+	case models.GranularityYear:
+		start := time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(1, 0, -1)
+	}
+	return day, day
+}
+
+// ResampleRates aggregates daily rates, which must be in date order, into one
+// OHLC bucket per calendar period. Periods without rates produce no bucket.
+func ResampleRates(rates []*models.HistoricalRate, granularity string) ([]*models.RateBucket, error) {
+	if !ValidGranularity(granularity) {
+		return nil, errors.NewValidationError("invalid granularity", "granularity must be one of day, week, month, quarter, year")
+	}
+
+	var buckets []*models.RateBucket
+	var current *models.RateBucket
+	var sum float64
+	for _, rate := range rates {
+		start, end := periodBounds(rate.Date, granularity)
+		if current == nil || !current.Start.Equal(start) {
+			if current != nil {
+				current.Average = sum / float64(current.Count)
+			}
+			current = &models.RateBucket{Start: start, End: end, Open: rate.Rate, High: rate.Rate, Low: rate.Rate}
+			buckets = append(buckets, current)
+			sum = 0
+		}
+		if rate.Rate > current.High {
+			current.High = rate.Rate
+		}
+		if rate.Rate < current.Low {
+			current.Low = rate.Rate
+		}
+		current.Close = rate.Rate
+		current.Count++
+		if rate.FetchedAt.After(current.FetchedAt) {
+			current.FetchedAt = rate.FetchedAt
+		}
+		sum += rate.Rate
+	}
+	if current != nil {
+		current.Average = sum / float64(current.Count)
+	}
+	return buckets, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+func TestResampleRatesWeekly(t *testing.T) {
+	// Thursday 2024-02-29 to Tuesday 2024-03-05 spans two ISO weeks
+	start := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	var rates []*models.HistoricalRate
+	for i, rate := range []float64{1.0, 1.2, 0.8, 1.1, 0.9, 0.95} {
+		rates = append(rates, &models.HistoricalRate{Rate: rate, Date: start.AddDate(0, 0, i)})
+	}
+
+	buckets, err := ResampleRates(rates, models.GranularityWeek)
+	if err != nil {
+		t.Fatalf("ResampleRates: %v", err)
+	}
+	if len(buckets) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(buckets))
+	}
+
+	first := buckets[0]
+	if want := time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC); !first.Start.Equal(want) {
+		t.Errorf("first bucket starts %v, want %v", first.Start, want)
+	}
+	if want := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC); !first.End.Equal(want) {
+		t.Errorf("first bucket ends %v, want %v", first.End, want)
+	}
+	if first.Open != 1.0 || first.High != 1.2 || first.Low != 0.8 || first.Close != 1.1 || first.Count != 4 {
+		t.Errorf("unexpected first bucket %+v", first)
+	}
+	if math.Abs(first.Average-1.025) > 1e-9 {
+		t.Errorf("average = %v, want 1.025", first.Average)
+	}
+
+	second := buckets[1]
+	if second.Open != 0.9 || second.Close != 0.95 || second.Count != 2 {
+		t.Errorf("unexpected second bucket %+v", second)
+	}
+}
+
+func TestResampleRatesQuarterBounds(t *testing.T) {
+	rates := []*models.HistoricalRate{{Rate: 1, Date: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)}}
+
+	buckets, err := ResampleRates(rates, models.GranularityQuarter)
+	if err != nil {
+		t.Fatalf("ResampleRates: %v", err)
+	}
+	if got := buckets[0].Start.Format("2006-01-02") + "/" + buckets[0].End.Format("2006-01-02"); got != "2024-04-01/2024-06-30" {
+		t.Errorf("quarter bounds = %s", got)
+	}
+}
+
+func TestResampleRatesInvalidGranularity(t *testing.T) {
+	if _, err := ResampleRates(nil, "hour"); err == nil {
+		t.Fatal("expected an error for an unknown granularity")
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"exchange-rate-service/internal/models"
+)
+
+// TimeSeriesStatistics computes summary statistics over rates, which must be
+// in date order. Points filled in by a fill policy are left out, so the
+// statistics only describe published rates. It returns nil when no rate is
+// left.
+func TimeSeriesStatistics(rates []*models.HistoricalRate) *models.TimeSeriesStats {
+	rates = publishedRates(rates)
+	if len(rates) == 0 {
+		return nil
+	}
+
+	first, last := rates[0], rates[len(rates)-1]
+	stats := &models.TimeSeriesStats{
+		Count:   len(rates),
+		Min:     first.Rate,
+		MinDate: first.Date,
+		Max:     first.Rate,
+		MaxDate: first.Date,
+		Start:   first.Rate,
+		End:     last.Rate,
+		Change:  last.Rate - first.Rate,
+	}
+	if first.Rate != 0 {
+		stats.ChangePercent = (last.Rate - first.Rate) / first.Rate * 100
+	}
+
+	values := make([]float64, len(rates))
+	var logReturns []float64
+	for i, rate := range rates {
+		values[i] = rate.Rate
+		if rate.Rate < stats.Min {
+			stats.Min, stats.MinDate = rate.Rate, rate.Date
+		}
+		if rate.Rate > stats.Max {
+			stats.Max, stats.MaxDate = rate.Rate, rate.Date
+		}
+		if i > 0 && rates[i-1].Rate > 0 && rate.Rate > 0 {
+			logReturns = append(logReturns, math.Log(rate.Rate/rates[i-1].Rate))
+		}
+	}
+
+	stats.Mean = mean(values)
+	stats.StdDev = sampleStdDev(values)
+	stats.Median = median(values)
+	stats.Volatility = sampleStdDev(logReturns)
+
+	return stats
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// sampleStdDev returns the sample standard deviation, 0 for fewer than two values
+func sampleStdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	var squares float64
+	for _, v := range values {
+		squares += (v - m) * (v - m)
+	}
+	return math.Sqrt(squares / float64(len(values)-1))
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// publishedRates drops the filled points of rates
+func publishedRates(rates []*models.HistoricalRate) []*models.HistoricalRate {
+	published := make([]*models.HistoricalRate, 0, len(rates))
+	for _, rate := range rates {
+		if rate.Point != models.PointFilled {
+			published = append(published, rate)
+		}
+	}
+	return published
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+func TestTimeSeriesStatistics(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rates []*models.HistoricalRate
+	for i, rate := range []float64{1.0, 1.1, 0.9, 1.2} {
+		rates = append(rates, &models.HistoricalRate{Rate: rate, Date: start.AddDate(0, 0, i)})
+	}
+
+	stats := TimeSeriesStatistics(rates)
+	if stats == nil {
+		t.Fatal("expected statistics")
+	}
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"min", stats.Min, 0.9},
+		{"max", stats.Max, 1.2},
+		{"mean", stats.Mean, 1.05},
+		{"median", stats.Median, 1.05},
+		{"std_dev", stats.StdDev, 0.12909944487358055},
+		{"change", stats.Change, 0.2},
+		{"change_percent", stats.ChangePercent, 20},
+		{"volatility", stats.Volatility, 0.2460013717893569},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if stats.Count != 4 || !stats.MinDate.Equal(start.AddDate(0, 0, 2)) || !stats.MaxDate.Equal(start.AddDate(0, 0, 3)) {
+		t.Errorf("unexpected count or extreme dates: %+v", stats)
+	}
+
+	if TimeSeriesStatistics(nil) != nil {
+		t.Error("expected nil statistics for an empty series")
+	}
+}
+
+func TestTimeSeriesStatisticsSkipsFilledPoints(t *testing.T) {
+	start := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	rates := []*models.HistoricalRate{
+		{Rate: 1.0, Date: start, Point: models.PointActual},
+		{Rate: 1.0, Date: start.AddDate(0, 0, 1), Point: models.PointFilled},
+		{Rate: 1.0, Date: start.AddDate(0, 0, 2), Point: models.PointFilled},
+		{Rate: 1.2, Date: start.AddDate(0, 0, 3), Point: models.PointActual},
+		{Rate: 1.2, Date: start.AddDate(0, 0, 4), Point: models.PointFilled},
+	}
+
+	stats := TimeSeriesStatistics(rates)
+	if stats.Count != 2 || stats.Mean != 1.1 || !stats.MaxDate.Equal(start.AddDate(0, 0, 3)) {
+		t.Errorf("stats = %+v, want the two published rates only", stats)
+	}
+	if TimeSeriesStatistics(rates[1:3]) != nil {
+		t.Error("expected nil statistics for a series of filled points")
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/calendar"
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log/level"
+)
+
+// GetTimeSeries retrieves the daily rates from start to end inclusive. Dates
+// are fetched concurrently by a bounded pool of workers; dates that fail are
+// reported in Missing rather than failing the series, unless none succeed.
+// Under a fill policy other than none only business days are fetched and the
+// other days are filled from them.
+func (s *exchangeService) GetTimeSeries(ctx context.Context, baseCurrency, targetCurrency string, start, end time.Time, fill models.FillOptions) (*models.TimeSeries, error) {
+	level.Debug(s.logger).Log("method", "GetTimeSeries", "base", baseCurrency, "target", targetCurrency, "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
+
+	if err := s.validateCurrencies(baseCurrency, targetCurrency); err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, errors.NewValidationError("invalid date range", "end_date must not be before start_date")
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+	if days > s.history.MaxRangeDays {
+		return nil, errors.NewValidationError("invalid date range",
+			fmt.Sprintf("time series may span at most %d days; use a coarser granularity for longer ranges", s.history.MaxRangeDays))
+	}
+	policy, cal, err := s.resolveFill(fill)
+	if err != nil {
+		return nil, err
+	}
+	if policy != models.FillNone {
+		if err := checkCoverage(cal, start, end); err != nil {
+			return nil, err
+		}
+	}
+
+	dates := make([]time.Time, 0, days)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+
+	// Work out which dates to look up: every date, or the business days and
+	// the neighbours needed to fill the rest
+	fetch := dates
+	if policy != models.FillNone {
+		fetch = nil
+		seen := make(map[time.Time]bool)
+		add := func(d time.Time) {
+			if !seen[d] {
+				seen[d] = true
+				fetch = append(fetch, d)
+			}
+		}
+		for _, d := range dates {
+			if cal.IsBusinessDay(d) {
+				add(d)
+				continue
+			}
+			for _, n := range fillNeighbours(cal, policy, d) {
+				add(n)
+			}
+		}
+	}
+
+	rates, failures := s.fetchHistoricalRates(ctx, baseCurrency, targetCurrency, fetch)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fetched := make(map[time.Time]int, len(fetch))
+	for i, d := range fetch {
+		fetched[d] = i
+	}
+
+	series := &models.TimeSeries{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		StartDate:      start.Format("2006-01-02"),
+		EndDate:        end.Format("2006-01-02"),
+		Rates:          make([]*models.HistoricalRate, 0, len(dates)),
+	}
+	var firstErr error
+	for _, d := range dates {
+		rate, err := s.seriesPoint(cal, policy, d, fetched, rates, failures)
+		if err != nil {
+			level.Error(s.logger).Log("error", err, "method", "GetTimeSeries", "date", d.Format("2006-01-02"))
+			if firstErr == nil {
+				firstErr = err
+			}
+			series.Missing = append(series.Missing, models.MissingRate{Date: d.Format("2006-01-02"), Reason: err.Error()})
+			continue
+		}
+		series.Rates = append(series.Rates, rate)
+	}
+
+	if len(series.Rates) == 0 {
+		return nil, firstErr
+	}
+	return series, nil
+}
+
+// seriesPoint answers one date of a time series from the fetched rates
+func (s *exchangeService) seriesPoint(cal calendar.Calendar, policy string, date time.Time, fetched map[time.Time]int, rates []*models.HistoricalRate, failures []error) (*models.HistoricalRate, error) {
+	if policy == models.FillNone || cal.IsBusinessDay(date) {
+		i := fetched[date]
+		if failures[i] != nil {
+			return nil, failures[i]
+		}
+		rate := rates[i]
+		if policy != models.FillNone {
+			rate.Point = models.PointActual
+		}
+		return rate, nil
+	}
+
+	var prev, next *models.HistoricalRate
+	for _, n := range fillNeighbours(cal, policy, date) {
+		i, ok := fetched[n]
+		if !ok {
+			continue
+		}
+		if failures[i] != nil {
+			return nil, fmt.Errorf("cannot fill from %s: %w", n.Format("2006-01-02"), failures[i])
+		}
+		if n.Before(date) {
+			prev = rates[i]
+		} else {
+			next = rates[i]
+		}
+	}
+	return fillRate(policy, date, prev, next)
+}
+
+// fetchHistoricalRates looks up the rate of each date using at most
+// FetchWorkers concurrent lookups. Results and errors are indexed like dates.
+func (s *exchangeService) fetchHistoricalRates(ctx context.Context, baseCurrency, targetCurrency string, dates []time.Time) ([]*models.HistoricalRate, []error) {
+	rates := make([]*models.HistoricalRate, len(dates))
+	failures := make([]error, len(dates))
+
+	workers := s.history.FetchWorkers
+	if workers > len(dates) {
+		workers = len(dates)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rates[i], failures[i] = s.rateRepo.GetHistoricalRate(ctx, baseCurrency, targetCurrency, dates[i])
+			}
+		}()
+	}
+	for i := range dates {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return rates, failures
+}
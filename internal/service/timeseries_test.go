@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/configs"
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/repository"
+
+	"github.com/go-kit/log"
+)
+
+// historyRepo serves a fixed rate for every date except weekends and records
+// how many lookups ran at once
+type historyRepo struct {
+	repository.RateRepository
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (r *historyRepo) GetHistoricalRate(_ context.Context, base, target string, date time.Time) (*models.HistoricalRate, error) {
+	r.mu.Lock()
+	r.inFlight++
+	if r.inFlight > r.peak {
+		r.peak = r.inFlight
+	}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.inFlight--
+		r.mu.Unlock()
+	}()
+
+	time.Sleep(time.Millisecond)
+	if day := date.Weekday(); day == time.Saturday || day == time.Sunday {
+		return nil, fmt.Errorf("%w on %s", repository.ErrRateNotFound, date.Format("2006-01-02"))
+	}
+	return &models.HistoricalRate{BaseCurrency: base, TargetCurrency: target, Rate: 0.9, Date: date}, nil
+}
+
+func historyConfig(maxRangeDays, workers int) configs.HistoryConfig {
+	config := configs.Default().History
+	config.MaxRangeDays = maxRangeDays
+	config.FetchWorkers = workers
+	return config
+}
+
+func TestGetTimeSeriesReportsMissingDates(t *testing.T) {
+	repo := &historyRepo{}
+	config := historyConfig(31, 3)
+	svc := NewExchangeService(repo, config, log.NewNopLogger())
+
+	// 2024-03-01 is a Friday, so the 2nd and 3rd are a weekend
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	series, err := svc.GetTimeSeries(context.Background(), "USD", "EUR", start, start.AddDate(0, 0, 13), models.FillOptions{})
+	if err != nil {
+		t.Fatalf("GetTimeSeries: %v", err)
+	}
+
+	if len(series.Rates) != 10 {
+		t.Errorf("got %d rates, want 10", len(series.Rates))
+	}
+	for i := 1; i < len(series.Rates); i++ {
+		if !series.Rates[i].Date.After(series.Rates[i-1].Date) {
+			t.Fatalf("rates out of order at %d", i)
+		}
+	}
+	if len(series.Missing) != 4 || series.Missing[0].Date != "2024-03-02" || series.Missing[0].Reason == "" {
+		t.Errorf("unexpected missing dates %+v", series.Missing)
+	}
+	if repo.peak > config.FetchWorkers {
+		t.Errorf("%d lookups ran at once, want at most %d", repo.peak, config.FetchWorkers)
+	}
+}
+
+func TestGetTimeSeriesRangeLimit(t *testing.T) {
+	svc := NewExchangeService(&historyRepo{}, historyConfig(7, 2), log.NewNopLogger())
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	_, err := svc.GetTimeSeries(context.Background(), "USD", "EUR", start, start.AddDate(0, 0, 7), models.FillOptions{})
+	if !errors.IsValidationError(err) {
+		t.Fatalf("expected a validation error for an 8 day range, got %v", err)
+	}
+
+	// Far too long ranges are rejected by length, before the calendar is asked
+	_, err = svc.GetTimeSeries(context.Background(), "USD", "EUR", start.AddDate(-2000, 0, 0), start.AddDate(7000, 0, 0), models.FillOptions{Policy: models.FillPrevious})
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || !strings.Contains(appErr.Details, "at most 7 days") {
+		t.Errorf("expected the range limit error for a 9000 year range, got %v", err)
+	}
+}
+
+func TestGetStoredRatesRangeLimit(t *testing.T) {
+	config := historyConfig(7, 2)
+	config.MaxBucketRangeDays = 30
+	svc := NewExchangeService(&storedRepo{}, config, log.NewNopLogger())
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := svc.GetStoredRates(context.Background(), "USD", "EUR", start, start.AddDate(0, 0, 29)); err != nil {
+		t.Fatalf("30 day range: %v", err)
+	}
+	for _, end := range []time.Time{start.AddDate(0, 0, 30), start.AddDate(8000, 0, 0), start.AddDate(0, 0, -1)} {
+		if _, err := svc.GetStoredRates(context.Background(), "USD", "EUR", start, end); !errors.IsValidationError(err) {
+			t.Errorf("range to %s: got %v, want a validation error", end.Format("2006-01-02"), err)
+		}
+	}
+}
+
+func TestGetTimeSeriesFailsWhenNoDateSucceeds(t *testing.T) {
+	svc := NewExchangeService(&historyRepo{}, historyConfig(7, 2), log.NewNopLogger())
+
+	saturday := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := svc.GetTimeSeries(context.Background(), "USD", "EUR", saturday, saturday.AddDate(0, 0, 1), models.FillOptions{}); err == nil {
+		t.Fatal("expected an error when every date is missing")
+	}
+}
+
+func TestGetTimeSeriesFillsWeekends(t *testing.T) {
+	repo := &rampRepo{}
+	svc := NewExchangeService(repo, historyConfig(31, 2), log.NewNopLogger())
+
+	// Friday 2024-03-01 to Monday 2024-03-04
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		policy   string
+		saturday float64
+		from     []string
+	}{
+		{models.FillPrevious, 1, []string{"2024-03-01"}},
+		{models.FillNext, 4, []string{"2024-03-04"}},
+		{models.FillLinear, 2, []string{"2024-03-01", "2024-03-04"}},
+	}
+	for _, c := range cases {
+		series, err := svc.GetTimeSeries(context.Background(), "USD", "EUR", start, start.AddDate(0, 0, 3), models.FillOptions{Policy: c.policy})
+		if err != nil {
+			t.Fatalf("%s: GetTimeSeries: %v", c.policy, err)
+		}
+		if len(series.Rates) != 4 || len(series.Missing) != 0 {
+			t.Fatalf("%s: got %d rates and %d missing, want 4 and 0", c.policy, len(series.Rates), len(series.Missing))
+		}
+
+		friday, saturday := series.Rates[0], series.Rates[1]
+		if friday.Point != models.PointActual {
+			t.Errorf("%s: friday point = %q, want actual", c.policy, friday.Point)
+		}
+		if saturday.Point != models.PointFilled || math.Abs(saturday.Rate-c.saturday) > 1e-9 {
+			t.Errorf("%s: saturday = %v (%s), want %v filled", c.policy, saturday.Rate, saturday.Point, c.saturday)
+		}
+		if len(saturday.FilledFrom) != len(c.from) || saturday.FilledFrom[0] != c.from[0] {
+			t.Errorf("%s: saturday filled from %v, want %v", c.policy, saturday.FilledFrom, c.from)
+		}
+	}
+	if repo.weekendLookups > 0 {
+		t.Errorf("looked up %d weekend dates, want none", repo.weekendLookups)
+	}
+}
+
+func TestGetHistoricalRateUsesHolidayCalendar(t *testing.T) {
+	svc := NewExchangeService(&rampRepo{}, historyConfig(31, 2), log.NewNopLogger())
+
+	// Good Friday is a TARGET holiday but not a weekend
+	goodFriday := time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)
+	rate, err := svc.GetHistoricalRate(context.Background(), "USD", "EUR", goodFriday, models.FillOptions{Policy: models.FillPrevious, Calendar: "TARGET"})
+	if err != nil {
+		t.Fatalf("GetHistoricalRate: %v", err)
+	}
+	if rate.Point != models.PointFilled || rate.FilledFrom[0] != "2024-03-28" {
+		t.Errorf("got %+v, want a rate filled from 2024-03-28", rate)
+	}
+
+	if _, err := svc.GetHistoricalRate(context.Background(), "USD", "EUR", goodFriday, models.FillOptions{Policy: "sideways"}); !errors.IsValidationError(err) {
+		t.Errorf("expected a validation error for an unknown policy, got %v", err)
+	}
+}
+
+func TestHolidayCalendarsRejectUncoveredYears(t *testing.T) {
+	svc := NewExchangeService(&rampRepo{}, historyConfig(31, 2), log.NewNopLogger())
+	ctx := context.Background()
+	target := models.FillOptions{Policy: models.FillPrevious, Calendar: "TARGET"}
+	newYear := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := svc.GetHistoricalRate(ctx, "USD", "EUR", newYear, target); !errors.IsValidationError(err) {
+		t.Errorf("GetHistoricalRate in 2030: got %v, want a validation error", err)
+	}
+	if _, err := svc.GetTimeSeries(ctx, "USD", "EUR", newYear.AddDate(0, 0, -3), newYear, target); !errors.IsValidationError(err) {
+		t.Errorf("GetTimeSeries into 2030: got %v, want a validation error", err)
+	}
+	if _, err := svc.GetPeriodAverage(ctx, "USD", "EUR", "2019-12", "TARGET"); !errors.IsValidationError(err) {
+		t.Errorf("GetPeriodAverage in 2019: got %v, want a validation error", err)
+	}
+
+	// Without a fill policy, or with the weekends calendar, the year doesn't matter
+	if _, err := svc.GetHistoricalRate(ctx, "USD", "EUR", newYear, models.FillOptions{Policy: models.FillNone, Calendar: "TARGET"}); err != nil {
+		t.Errorf("GetHistoricalRate without a fill policy: %v", err)
+	}
+	if _, err := svc.GetHistoricalRate(ctx, "USD", "EUR", newYear, models.FillOptions{Policy: models.FillPrevious, Calendar: "weekends"}); err != nil {
+		t.Errorf("GetHistoricalRate with the weekends calendar: %v", err)
+	}
+}
+
+// rampRepo returns the day of the month as the rate and counts weekend lookups
+type rampRepo struct {
+	repository.RateRepository
+
+	mu             sync.Mutex
+	weekendLookups int
+}
+
+func (r *rampRepo) GetHistoricalRate(_ context.Context, base, target string, date time.Time) (*models.HistoricalRate, error) {
+	if day := date.Weekday(); day == time.Saturday || day == time.Sunday {
+		r.mu.Lock()
+		r.weekendLookups++
+		r.mu.Unlock()
+	}
+	return &models.HistoricalRate{BaseCurrency: base, TargetCurrency: target, Rate: float64(date.Day()), Date: date}, nil
+}
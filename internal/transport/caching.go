@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"exchange-rate-service/internal/models"
+)
+
+// The go-kit HTTP responses implement models.Cacheable so successful ones get
+// caching headers; failed responses are not cacheable
+
+func (r GetLatestRateResponse) CacheInfo() models.CacheInfo {
+	if rate, ok := r.Rate.(*models.ExchangeRate); ok && r.Error == "" {
+		return rate.CacheInfo()
+	}
+	return uncacheable
+}
+
+// CacheInfo marks a series with missing dates for revalidation, since the
+// dates may be filled in later, and likewise buckets of stored rates that may
+// still change
+func (r GetHistoricalRatesResponse) CacheInfo() models.CacheInfo {
+	if r.Error != "" {
+		return uncacheable
+	}
+	if r.bucketed {
+		info := r.stored.CacheInfo()
+		info.Revalidate = r.revalidate
+		return info
+	}
+	rates := make(models.HistoricalRates, 0, len(r.Rates))
+	for _, rate := range r.Rates {
+		if hr, ok := rate.(*models.HistoricalRate); ok {
+			rates = append(rates, hr)
+		}
+	}
+	info := rates.CacheInfo()
+	info.Revalidate = len(r.Missing) > 0
+	return info
+}
+
+func (r GetSupportedCurrenciesResponse) CacheInfo() models.CacheInfo {
+	if r.Error != "" {
+		return uncacheable
+	}
+	return models.CacheInfo{}
+}
+
+var uncacheable = models.CacheInfo{NoStore: true}
@@ -5,17 +5,21 @@ import (
 	"fmt"
 	"time"
 
+	"exchange-rate-service/internal/calendar"
+	"exchange-rate-service/internal/errors"
 	"exchange-rate-service/internal/models"
 	"exchange-rate-service/internal/service"
 
 	kitendpoint "github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 )
 
 // Endpoints aggregates all go-kit endpoints for the service.
 type Endpoints struct {
 	GetLatestRateEndpoint          kitendpoint.Endpoint
 	ConvertCurrencyEndpoint        kitendpoint.Endpoint
+	GetHistoricalRateEndpoint      kitendpoint.Endpoint
 	GetHistoricalRatesEndpoint     kitendpoint.Endpoint
 	GetSupportedCurrenciesEndpoint kitendpoint.Endpoint
 }
@@ -36,6 +40,13 @@ func MakeEndpoints(svc service.ExchangeService, logger log.Logger) Endpoints {
 		convertCurrencyEndpoint = RecoveryMiddleware(logger)(convertCurrencyEndpoint)
 	}
 
+	var getHistoricalRateEndpoint kitendpoint.Endpoint
+	{
+		getHistoricalRateEndpoint = makeGetHistoricalRateEndpoint(svc)
+		getHistoricalRateEndpoint = LoggingMiddleware(log.With(logger, "method", "GetHistoricalRate"))(getHistoricalRateEndpoint)
+		getHistoricalRateEndpoint = RecoveryMiddleware(logger)(getHistoricalRateEndpoint)
+	}
+
 	var getHistoricalRatesEndpoint kitendpoint.Endpoint
 	{
 		getHistoricalRatesEndpoint = makeGetHistoricalRatesEndpoint(svc)
@@ -53,20 +64,25 @@ func MakeEndpoints(svc service.ExchangeService, logger log.Logger) Endpoints {
 	return Endpoints{
 		GetLatestRateEndpoint:          getLatestRateEndpoint,
 		ConvertCurrencyEndpoint:        convertCurrencyEndpoint,
+		GetHistoricalRateEndpoint:      getHistoricalRateEndpoint,
 		GetHistoricalRatesEndpoint:     getHistoricalRatesEndpoint,
 		GetSupportedCurrenciesEndpoint: getSupportedCurrenciesEndpoint,
 	}
 }
 
-// Request/Response DTOs
+// Request/Response DTOs. Failed responses carry the error message in Error for
+// HTTP clients and the original error in err, from which the gRPC transport
+// derives a status code. The openapi tag names the models type held by an
+// interface field, for the OpenAPI document.
 type GetLatestRateRequest struct {
 	From string `json:"from"`
 	To   string `json:"to"`
 }
 
 type GetLatestRateResponse struct {
-	Rate  interface{} `json:"rate,omitempty"`
+	Rate  interface{} `json:"rate,omitempty" openapi:"ExchangeRate"`
 	Error string      `json:"error,omitempty"`
+	err   error
 }
 
 type ConvertCurrencyRequest struct {
@@ -77,27 +93,64 @@ type ConvertCurrencyRequest struct {
 }
 
 type ConvertCurrencyResponse struct {
-	Conversion interface{} `json:"conversion,omitempty"`
+	Conversion interface{} `json:"conversion,omitempty" openapi:"ConversionResponse"`
 	Error      string      `json:"error,omitempty"`
+	err        error
+}
+
+// GetHistoricalRateRequest asks for the rate of one date. Fill and Calendar
+// select how dates that are not business days are answered.
+type GetHistoricalRateRequest struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Date     string `json:"date"`
+	Fill     string `json:"fill,omitempty"`
+	Calendar string `json:"calendar,omitempty"`
 }
 
+type GetHistoricalRateResponse struct {
+	Rate  interface{} `json:"rate,omitempty" openapi:"HistoricalRate"`
+	Error string      `json:"error,omitempty"`
+	err   error
+}
+
+// GetHistoricalRatesRequest asks for the rates of a date range. Stats is
+// "true" to add statistics to the rates or "only" to return just statistics.
+// Granularities coarser than "day" return OHLC buckets built from the stored
+// history instead of daily rates. Fill and Calendar select how dates that are
+// not business days are answered in a daily series.
 type GetHistoricalRatesRequest struct {
-	From      string `json:"from"`
-	To        string `json:"to"`
-	StartDate string `json:"start_date"`
-	EndDate   string `json:"end_date"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Stats       string `json:"stats,omitempty"`
+	Granularity string `json:"granularity,omitempty"`
+	Fill        string `json:"fill,omitempty"`
+	Calendar    string `json:"calendar,omitempty"`
 }
 
 type GetHistoricalRatesResponse struct {
-	Rates []interface{} `json:"rates,omitempty"`
-	Error string        `json:"error,omitempty"`
+	Rates   []interface{}        `json:"rates,omitempty" openapi:"HistoricalRate"`
+	Buckets interface{}          `json:"buckets,omitempty" openapi:"[]RateBucket"`
+	Stats   interface{}          `json:"stats,omitempty" openapi:"TimeSeriesStats"`
+	Missing []models.MissingRate `json:"missing,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	err     error
+
+	// Bucketed responses keep the stored rates they were computed from, and
+	// whether those rates may still change
+	bucketed   bool
+	stored     models.HistoricalRates
+	revalidate bool
 }
 
 type GetSupportedCurrenciesRequest struct{}
 
 type GetSupportedCurrenciesResponse struct {
-	Currencies interface{} `json:"currencies,omitempty"`
+	Currencies interface{} `json:"currencies,omitempty" openapi:"[]Currency"`
 	Error      string      `json:"error,omitempty"`
+	err        error
 }
 
 // Endpoint makers
@@ -106,7 +159,7 @@ func makeGetLatestRateEndpoint(svc service.ExchangeService) kitendpoint.Endpoint
 		req := request.(GetLatestRateRequest)
 		rate, err := svc.GetLatestRate(ctx, req.From, req.To)
 		if err != nil {
-			return GetLatestRateResponse{Error: err.Error()}, nil
+			return GetLatestRateResponse{Error: err.Error(), err: err}, nil
 		}
 		return GetLatestRateResponse{Rate: rate}, nil
 	}
@@ -123,45 +176,126 @@ func makeConvertCurrencyEndpoint(svc service.ExchangeService) kitendpoint.Endpoi
 		}
 		conversion, err := svc.ConvertCurrency(ctx, cr)
 		if err != nil {
-			return ConvertCurrencyResponse{Error: err.Error()}, nil
+			return ConvertCurrencyResponse{Error: err.Error(), err: err}, nil
 		}
 		return ConvertCurrencyResponse{Conversion: conversion}, nil
 	}
 }
 
+func makeGetHistoricalRateEndpoint(svc service.ExchangeService) kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetHistoricalRateRequest)
+		date, err := parseDate(req.Date)
+		if err != nil {
+			return GetHistoricalRateResponse{Error: err.Error(), err: errors.NewValidationError("invalid date", err.Error())}, nil
+		}
+		rate, err := svc.GetHistoricalRate(ctx, req.From, req.To, date, models.FillOptions{Policy: req.Fill, Calendar: req.Calendar})
+		if err != nil {
+			return GetHistoricalRateResponse{Error: err.Error(), err: err}, nil
+		}
+		return GetHistoricalRateResponse{Rate: rate}, nil
+	}
+}
+
 func makeGetHistoricalRatesEndpoint(svc service.ExchangeService) kitendpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetHistoricalRatesRequest)
 		start, err := parseDate(req.StartDate)
 		if err != nil {
-			return GetHistoricalRatesResponse{Error: err.Error()}, nil
+			return GetHistoricalRatesResponse{Error: err.Error(), err: errors.NewValidationError("invalid date", err.Error())}, nil
 		}
 		end, err := parseDate(req.EndDate)
 		if err != nil {
-			return GetHistoricalRatesResponse{Error: err.Error()}, nil
+			return GetHistoricalRatesResponse{Error: err.Error(), err: errors.NewValidationError("invalid date", err.Error())}, nil
 		}
 		if end.Before(start) {
-			return GetHistoricalRatesResponse{Error: "end_date must be after start_date"}, nil
+			msg := "end_date must be after start_date"
+			return GetHistoricalRatesResponse{Error: msg, err: errors.NewValidationError("invalid date range", msg)}, nil
+		}
+		switch req.Stats {
+		case models.StatsNone, models.StatsInclude, models.StatsOnly:
+		default:
+			msg := "stats must be true or only"
+			return GetHistoricalRatesResponse{Error: msg, err: errors.NewValidationError("invalid stats", msg)}, nil
+		}
+		if req.Granularity == "" {
+			req.Granularity = models.GranularityDay
+		}
+		if !service.ValidGranularity(req.Granularity) {
+			msg := "granularity must be one of day, week, month, quarter, year"
+			return GetHistoricalRatesResponse{Error: msg, err: errors.NewValidationError("invalid granularity", msg)}, nil
 		}
 
-		var rates []interface{}
-		for d := start; !d.After(end); d = d.Add(24 * time.Hour) {
-			rate, rerr := svc.GetHistoricalRate(ctx, req.From, req.To, d)
-			if rerr != nil {
-				return GetHistoricalRatesResponse{Error: rerr.Error()}, nil
+		if req.Granularity != models.GranularityDay {
+			series, serr := svc.GetStoredRates(ctx, req.From, req.To, start, end)
+			if serr != nil {
+				return GetHistoricalRatesResponse{Error: serr.Error(), err: serr}, nil
+			}
+			resp := GetHistoricalRatesResponse{
+				bucketed:   true,
+				stored:     series,
+				revalidate: storedRatesMayChange(series, start, end, req.Calendar),
+			}
+			if req.Stats != models.StatsOnly {
+				buckets, berr := service.ResampleRates(series, req.Granularity)
+				if berr != nil {
+					return GetHistoricalRatesResponse{Error: berr.Error(), err: berr}, nil
+				}
+				resp.Buckets = buckets
+			}
+			if req.Stats != models.StatsNone {
+				resp.Stats = service.TimeSeriesStatistics(series)
 			}
-			rates = append(rates, rate)
+			return resp, nil
 		}
 
-		return GetHistoricalRatesResponse{Rates: rates}, nil
+		series, err := svc.GetTimeSeries(ctx, req.From, req.To, start, end, models.FillOptions{Policy: req.Fill, Calendar: req.Calendar})
+		if err != nil {
+			return GetHistoricalRatesResponse{Error: err.Error(), err: err}, nil
+		}
+
+		resp := GetHistoricalRatesResponse{Missing: series.Missing}
+		if req.Stats != models.StatsOnly {
+			resp.Rates = make([]interface{}, 0, len(series.Rates))
+			for _, rate := range series.Rates {
+				resp.Rates = append(resp.Rates, rate)
+			}
+		}
+		if req.Stats != models.StatsNone {
+			resp.Stats = service.TimeSeriesStatistics(series.Rates)
+		}
+		return resp, nil
 	}
 }
 
+// storedRatesMayChange reports whether the stored rates of a range can still
+// change: the range reaches today, or some of its business days have no
+// stored rate yet, as while a backfill is running
+func storedRatesMayChange(stored []*models.HistoricalRate, start, end time.Time, calendarName string) bool {
+	if !end.Before(models.Today()) {
+		return true
+	}
+	cal, err := calendar.Get(calendarName)
+	if err != nil {
+		cal, _ = calendar.Get(calendar.Weekends)
+	}
+	have := make(map[time.Time]bool, len(stored))
+	for _, rate := range stored {
+		have[rate.Date] = true
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if cal.IsBusinessDay(d) && !have[d] {
+			return true
+		}
+	}
+	return false
+}
+
 func makeGetSupportedCurrenciesEndpoint(svc service.ExchangeService) kitendpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		currencies, err := svc.GetSupportedCurrencies(ctx)
 		if err != nil {
-			return GetSupportedCurrenciesResponse{Error: err.Error()}, nil
+			return GetSupportedCurrenciesResponse{Error: err.Error(), err: err}, nil
 		}
 		return GetSupportedCurrenciesResponse{Currencies: currencies}, nil
 	}
@@ -194,18 +328,21 @@ func LoggingMiddleware(logger log.Logger) EndpointMiddleware {
 		return func(ctx context.Context, request interface{}) (interface{}, error) {
 			start := time.Now()
 			resp, err := next(ctx, request)
-			_ = logger.Log("took", time.Since(start))
+			_ = level.Debug(logger).Log("took", time.Since(start))
 			return resp, err
 		}
 	}
 }
 
+// RecoveryMiddleware turns a panic in the endpoint into an internal error,
+// so the transports never see a nil response
 func RecoveryMiddleware(logger log.Logger) EndpointMiddleware {
 	return func(next kitendpoint.Endpoint) kitendpoint.Endpoint {
-		return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 			defer func() {
 				if r := recover(); r != nil {
-					_ = logger.Log("panic", r)
+					_ = level.Error(logger).Log("panic", r)
+					response, err = nil, errors.NewInternalError("internal error", fmt.Errorf("panic: %v", r))
 				}
 			}()
 			return next(ctx, request)
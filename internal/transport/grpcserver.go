@@ -0,0 +1,287 @@
+package transport
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/transport/pb"
+
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/go-kit/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcServer implements pb.ExchangeRateServiceServer on top of the go-kit endpoints
+type grpcServer struct {
+	pb.UnimplementedExchangeRateServiceServer
+
+	getLatestRate          kitgrpc.Handler
+	convertCurrency        kitgrpc.Handler
+	getHistoricalRate      kitgrpc.Handler
+	getTimeSeries          kitgrpc.Handler
+	getSupportedCurrencies kitgrpc.Handler
+}
+
+// NewGRPCServer creates a gRPC server exposing the endpoints as ExchangeRateService
+func NewGRPCServer(eps Endpoints, logger log.Logger) *grpc.Server {
+	options := []kitgrpc.ServerOption{
+		kitgrpc.ServerErrorLogger(logger),
+	}
+
+	srv := grpc.NewServer()
+	pb.RegisterExchangeRateServiceServer(srv, &grpcServer{
+		getLatestRate:          kitgrpc.NewServer(eps.GetLatestRateEndpoint, decodeGRPCGetLatestRateRequest, encodeGRPCGetLatestRateResponse, options...),
+		convertCurrency:        kitgrpc.NewServer(eps.ConvertCurrencyEndpoint, decodeGRPCConvertCurrencyRequest, encodeGRPCConvertCurrencyResponse, options...),
+		getHistoricalRate:      kitgrpc.NewServer(eps.GetHistoricalRateEndpoint, decodeGRPCGetHistoricalRateRequest, encodeGRPCGetHistoricalRateResponse, options...),
+		getTimeSeries:          kitgrpc.NewServer(eps.GetHistoricalRatesEndpoint, decodeGRPCGetTimeSeriesRequest, encodeGRPCGetTimeSeriesResponse, options...),
+		getSupportedCurrencies: kitgrpc.NewServer(eps.GetSupportedCurrenciesEndpoint, decodeGRPCGetSupportedCurrenciesRequest, encodeGRPCGetSupportedCurrenciesResponse, options...),
+	})
+	return srv
+}
+
+func (s *grpcServer) GetLatestRate(ctx context.Context, req *pb.GetLatestRateRequest) (*pb.ExchangeRate, error) {
+	_, resp, err := s.getLatestRate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.ExchangeRate), nil
+}
+
+func (s *grpcServer) ConvertCurrency(ctx context.Context, req *pb.ConvertCurrencyRequest) (*pb.Conversion, error) {
+	_, resp, err := s.convertCurrency.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.Conversion), nil
+}
+
+func (s *grpcServer) GetHistoricalRate(ctx context.Context, req *pb.GetHistoricalRateRequest) (*pb.HistoricalRate, error) {
+	_, resp, err := s.getHistoricalRate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.HistoricalRate), nil
+}
+
+func (s *grpcServer) GetTimeSeries(ctx context.Context, req *pb.GetTimeSeriesRequest) (*pb.TimeSeries, error) {
+	_, resp, err := s.getTimeSeries.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.TimeSeries), nil
+}
+
+func (s *grpcServer) GetSupportedCurrencies(ctx context.Context, req *pb.GetSupportedCurrenciesRequest) (*pb.SupportedCurrencies, error) {
+	_, resp, err := s.getSupportedCurrencies.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.SupportedCurrencies), nil
+}
+
+// decoders
+func decodeGRPCGetLatestRateRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetLatestRateRequest)
+	return GetLatestRateRequest{From: req.From, To: req.To}, nil
+}
+
+func decodeGRPCConvertCurrencyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ConvertCurrencyRequest)
+	return ConvertCurrencyRequest{From: req.From, To: req.To, Amount: req.Amount, Date: req.Date}, nil
+}
+
+func decodeGRPCGetHistoricalRateRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetHistoricalRateRequest)
+	return GetHistoricalRateRequest{From: req.From, To: req.To, Date: req.Date, Fill: req.Fill, Calendar: req.Calendar}, nil
+}
+
+func decodeGRPCGetTimeSeriesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetTimeSeriesRequest)
+	return GetHistoricalRatesRequest{From: req.From, To: req.To, StartDate: req.StartDate, EndDate: req.EndDate, Fill: req.Fill, Calendar: req.Calendar}, nil
+}
+
+func decodeGRPCGetSupportedCurrenciesRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return GetSupportedCurrenciesRequest{}, nil
+}
+
+// encoders
+func encodeGRPCGetLatestRateResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp, ok := response.(GetLatestRateResponse)
+	if !ok {
+		return nil, unexpectedResponse(response)
+	}
+	if resp.err != nil {
+		return nil, grpcError(resp.err)
+	}
+	rate, ok := resp.Rate.(*models.ExchangeRate)
+	if !ok {
+		return nil, unexpectedResponse(resp.Rate)
+	}
+	return &pb.ExchangeRate{
+		BaseCurrency:   rate.BaseCurrency,
+		TargetCurrency: rate.TargetCurrency,
+		Rate:           rate.Rate,
+		Provider:       rate.Provider,
+		Author:         rate.Author,
+		Sources:        toPBSources(rate.Sources),
+		FetchedAt:      toPBTimestamp(rate.FetchedAt),
+		IsStale:        rate.IsStale,
+	}, nil
+}
+
+func encodeGRPCConvertCurrencyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp, ok := response.(ConvertCurrencyResponse)
+	if !ok {
+		return nil, unexpectedResponse(response)
+	}
+	if resp.err != nil {
+		return nil, grpcError(resp.err)
+	}
+	conversion, ok := resp.Conversion.(*models.ConversionResponse)
+	if !ok {
+		return nil, unexpectedResponse(resp.Conversion)
+	}
+	return &pb.Conversion{
+		FromCurrency:    conversion.FromCurrency,
+		ToCurrency:      conversion.ToCurrency,
+		Amount:          conversion.Amount,
+		ConvertedAmount: conversion.ConvertedAmount,
+		Rate:            conversion.Rate,
+		Provider:        conversion.Provider,
+		Author:          conversion.Author,
+		Sources:         toPBSources(conversion.Sources),
+		FetchedAt:       toPBTimestamp(conversion.FetchedAt),
+	}, nil
+}
+
+func encodeGRPCGetHistoricalRateResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp, ok := response.(GetHistoricalRateResponse)
+	if !ok {
+		return nil, unexpectedResponse(response)
+	}
+	if resp.err != nil {
+		return nil, grpcError(resp.err)
+	}
+	rate, ok := resp.Rate.(*models.HistoricalRate)
+	if !ok {
+		return nil, unexpectedResponse(resp.Rate)
+	}
+	return toPBHistoricalRate(rate), nil
+}
+
+func encodeGRPCGetTimeSeriesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp, ok := response.(GetHistoricalRatesResponse)
+	if !ok {
+		return nil, unexpectedResponse(response)
+	}
+	if resp.err != nil {
+		return nil, grpcError(resp.err)
+	}
+	series := &pb.TimeSeries{Rates: make([]*pb.HistoricalRate, 0, len(resp.Rates))}
+	for _, item := range resp.Rates {
+		rate, ok := item.(*models.HistoricalRate)
+		if !ok {
+			return nil, unexpectedResponse(item)
+		}
+		series.Rates = append(series.Rates, toPBHistoricalRate(rate))
+	}
+	for _, missing := range resp.Missing {
+		series.Missing = append(series.Missing, &pb.MissingRate{Date: missing.Date, Reason: missing.Reason})
+	}
+	return series, nil
+}
+
+func encodeGRPCGetSupportedCurrenciesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp, ok := response.(GetSupportedCurrenciesResponse)
+	if !ok {
+		return nil, unexpectedResponse(response)
+	}
+	if resp.err != nil {
+		return nil, grpcError(resp.err)
+	}
+	currencies, ok := resp.Currencies.([]*models.Currency)
+	if !ok {
+		return nil, unexpectedResponse(resp.Currencies)
+	}
+	result := &pb.SupportedCurrencies{Currencies: make([]*pb.Currency, 0, len(currencies))}
+	for _, c := range currencies {
+		result.Currencies = append(result.Currencies, &pb.Currency{
+			Code:        c.Code,
+			Name:        c.Name,
+			Symbol:      c.Symbol,
+			IsBase:      c.IsBase,
+			IsSupported: c.IsSupported,
+		})
+	}
+	return result, nil
+}
+
+func toPBHistoricalRate(rate *models.HistoricalRate) *pb.HistoricalRate {
+	return &pb.HistoricalRate{
+		BaseCurrency:   rate.BaseCurrency,
+		TargetCurrency: rate.TargetCurrency,
+		Rate:           rate.Rate,
+		Date:           rate.Date.Format("2006-01-02"),
+		Provider:       rate.Provider,
+		Author:         rate.Author,
+		FetchedAt:      toPBTimestamp(rate.FetchedAt),
+		Point:          rate.Point,
+		FilledFrom:     rate.FilledFrom,
+	}
+}
+
+func toPBSources(sources []models.RateSource) []*pb.RateSource {
+	if len(sources) == 0 {
+		return nil
+	}
+	result := make([]*pb.RateSource, 0, len(sources))
+	for _, s := range sources {
+		result = append(result, &pb.RateSource{Provider: s.Provider, Rate: s.Rate, Rejected: s.Rejected})
+	}
+	return result
+}
+
+func toPBTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// unexpectedResponse reports an endpoint response the encoders can't handle
+func unexpectedResponse(response interface{}) error {
+	return status.Errorf(codes.Internal, "unexpected endpoint response %T", response)
+}
+
+// grpcError maps service errors onto gRPC status codes, mirroring the HTTP
+// status codes used for the same errors; status errors pass through
+func grpcError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := codes.Internal
+	switch appErr.Type {
+	case errors.ErrorTypeValidation:
+		code = codes.InvalidArgument
+	case errors.ErrorTypeNotFound:
+		code = codes.NotFound
+	case errors.ErrorTypeUnauthorized:
+		code = codes.Unauthenticated
+	case errors.ErrorTypeForbidden:
+		code = codes.PermissionDenied
+	case errors.ErrorTypeProvider, errors.ErrorTypeCache:
+		code = codes.Unavailable
+	}
+	return status.Error(code, appErr.Error())
+}
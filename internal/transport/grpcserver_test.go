@@ -0,0 +1,247 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/errors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/transport/pb"
+
+	"github.com/go-kit/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var fetchedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// stubService answers with fixed rates; "XXX" is treated as an unknown currency
+// and "PNC" makes it panic
+type stubService struct{}
+
+func (stubService) GetLatestRate(_ context.Context, base, target string) (*models.ExchangeRate, error) {
+	if base == "PNC" {
+		panic("stub panic")
+	}
+	if base == "XXX" || target == "XXX" {
+		return nil, errors.NewValidationError("unsupported currency", "XXX is not supported")
+	}
+	return &models.ExchangeRate{BaseCurrency: base, TargetCurrency: target, Rate: 0.9, Provider: "stub", FetchedAt: fetchedAt}, nil
+}
+
+func (stubService) ConvertCurrency(_ context.Context, req *models.ConversionRequest) (*models.ConversionResponse, error) {
+	return &models.ConversionResponse{
+		FromCurrency:    req.FromCurrency,
+		ToCurrency:      req.ToCurrency,
+		Amount:          req.Amount,
+		ConvertedAmount: req.Amount * 0.9,
+		Rate:            0.9,
+		Provider:        "stub",
+		FetchedAt:       fetchedAt,
+	}, nil
+}
+
+// GetHistoricalRate fills Saturdays from the Friday under the previous policy
+func (stubService) GetHistoricalRate(_ context.Context, base, target string, date time.Time, fill models.FillOptions) (*models.HistoricalRate, error) {
+	if date.Year() < 2000 {
+		return nil, errors.NewNotFoundError("no rate for " + date.Format("2006-01-02"))
+	}
+	rate := &models.HistoricalRate{BaseCurrency: base, TargetCurrency: target, Rate: 0.8, Date: date, Provider: "stub", FetchedAt: fetchedAt}
+	if fill.Policy == models.FillPrevious {
+		rate.Point = models.PointActual
+		if date.Weekday() == time.Saturday {
+			rate.Point, rate.FilledFrom = models.PointFilled, []string{date.AddDate(0, 0, -1).Format("2006-01-02")}
+		}
+	}
+	return rate, nil
+}
+
+func (svc stubService) GetTimeSeries(ctx context.Context, base, target string, start, end time.Time, fill models.FillOptions) (*models.TimeSeries, error) {
+	series := &models.TimeSeries{BaseCurrency: base, TargetCurrency: target}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		rate, err := svc.GetHistoricalRate(ctx, base, target, d, fill)
+		if err != nil {
+			series.Missing = append(series.Missing, models.MissingRate{Date: d.Format("2006-01-02"), Reason: err.Error()})
+			continue
+		}
+		series.Rates = append(series.Rates, rate)
+	}
+	return series, nil
+}
+
+// GetStoredRates has a rate for every weekday, except in 2023, which is yet
+// to be backfilled
+func (stubService) GetStoredRates(_ context.Context, base, target string, start, end time.Time) ([]*models.HistoricalRate, error) {
+	var rates []*models.HistoricalRate
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday && d.Year() != 2023 {
+			rates = append(rates, &models.HistoricalRate{BaseCurrency: base, TargetCurrency: target, Rate: 0.8, Date: d, Provider: "stub", FetchedAt: fetchedAt})
+		}
+	}
+	return rates, nil
+}
+
+func (stubService) ExportHistory(_ context.Context, base string, targets []string, start, end time.Time, emit func(*models.HistoricalRate) error) error {
+	return nil
+}
+
+func (stubService) GetPeriodAverage(_ context.Context, base, target, period, _ string) (*models.PeriodAverage, error) {
+	return nil, errors.NewNotFoundError("no stored rates")
+}
+
+func (stubService) GetPeriodReport(_ context.Context, base, period, _ string) (*models.PeriodReport, error) {
+	return nil, errors.NewNotFoundError("no stored rates")
+}
+
+func (stubService) GetSupportedCurrencies(_ context.Context) ([]*models.Currency, error) {
+	return []*models.Currency{{Code: "USD", Name: "US Dollar", IsSupported: true}, {Code: "EUR", Name: "Euro", IsSupported: true}}, nil
+}
+
+func (stubService) HealthCheck(_ context.Context) (*models.HealthResponse, error) {
+	return &models.HealthResponse{Status: models.HealthStatusHealthy}, nil
+}
+
+// newTestClient serves the stub service over an in-process bufconn listener
+func newTestClient(t *testing.T) pb.ExchangeRateServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(MakeEndpoints(stubService{}, log.NewNopLogger()), log.NewNopLogger())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewExchangeRateServiceClient(conn)
+}
+
+func TestGRPCGetLatestRate(t *testing.T) {
+	client := newTestClient(t)
+
+	rate, err := client.GetLatestRate(context.Background(), &pb.GetLatestRateRequest{From: "USD", To: "EUR"})
+	if err != nil {
+		t.Fatalf("GetLatestRate: %v", err)
+	}
+	if rate.BaseCurrency != "USD" || rate.TargetCurrency != "EUR" || rate.Rate != 0.9 || rate.Provider != "stub" {
+		t.Errorf("unexpected rate: %v", rate)
+	}
+	if !rate.FetchedAt.AsTime().Equal(fetchedAt) {
+		t.Errorf("fetched_at = %v, want %v", rate.FetchedAt.AsTime(), fetchedAt)
+	}
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"validation error", func() error {
+			_, err := client.GetLatestRate(ctx, &pb.GetLatestRateRequest{From: "XXX", To: "EUR"})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid date", func() error {
+			_, err := client.GetHistoricalRate(ctx, &pb.GetHistoricalRateRequest{From: "USD", To: "EUR", Date: "yesterday"})
+			return err
+		}, codes.InvalidArgument},
+		{"not found", func() error {
+			_, err := client.GetHistoricalRate(ctx, &pb.GetHistoricalRateRequest{From: "USD", To: "EUR", Date: "1999-12-31"})
+			return err
+		}, codes.NotFound},
+		{"panic", func() error {
+			_, err := client.GetLatestRate(ctx, &pb.GetLatestRateRequest{From: "PNC", To: "EUR"})
+			return err
+		}, codes.Internal},
+		{"inverted range", func() error {
+			_, err := client.GetTimeSeries(ctx, &pb.GetTimeSeriesRequest{From: "USD", To: "EUR", StartDate: "2024-01-05", EndDate: "2024-01-01"})
+			return err
+		}, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCConvertCurrency(t *testing.T) {
+	client := newTestClient(t)
+
+	conversion, err := client.ConvertCurrency(context.Background(), &pb.ConvertCurrencyRequest{From: "USD", To: "EUR", Amount: 100})
+	if err != nil {
+		t.Fatalf("ConvertCurrency: %v", err)
+	}
+	if conversion.ConvertedAmount != 90 || conversion.Rate != 0.9 {
+		t.Errorf("unexpected conversion: %v", conversion)
+	}
+}
+
+func TestGRPCGetTimeSeries(t *testing.T) {
+	client := newTestClient(t)
+
+	series, err := client.GetTimeSeries(context.Background(), &pb.GetTimeSeriesRequest{From: "USD", To: "EUR", StartDate: "2024-01-01", EndDate: "2024-01-03"})
+	if err != nil {
+		t.Fatalf("GetTimeSeries: %v", err)
+	}
+	if len(series.Rates) != 3 {
+		t.Fatalf("got %d rates, want 3", len(series.Rates))
+	}
+	for i, want := range []string{"2024-01-01", "2024-01-02", "2024-01-03"} {
+		if series.Rates[i].Date != want {
+			t.Errorf("rates[%d].date = %s, want %s", i, series.Rates[i].Date, want)
+		}
+	}
+}
+
+func TestGRPCGetTimeSeriesReportsFilledAndMissingDates(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	// 2024-01-06 is a Saturday
+	series, err := client.GetTimeSeries(ctx, &pb.GetTimeSeriesRequest{From: "USD", To: "EUR", StartDate: "2024-01-05", EndDate: "2024-01-06", Fill: models.FillPrevious})
+	if err != nil {
+		t.Fatalf("GetTimeSeries: %v", err)
+	}
+	if len(series.Rates) != 2 || series.Rates[0].Point != models.PointActual || series.Rates[1].Point != models.PointFilled ||
+		len(series.Rates[1].FilledFrom) != 1 || series.Rates[1].FilledFrom[0] != "2024-01-05" {
+		t.Errorf("unexpected rates: %v", series.Rates)
+	}
+
+	series, err = client.GetTimeSeries(ctx, &pb.GetTimeSeriesRequest{From: "USD", To: "EUR", StartDate: "1999-12-31", EndDate: "2000-01-01"})
+	if err != nil {
+		t.Fatalf("GetTimeSeries: %v", err)
+	}
+	if len(series.Rates) != 1 || len(series.Missing) != 1 || series.Missing[0].Date != "1999-12-31" || series.Missing[0].Reason == "" {
+		t.Errorf("rates = %v, missing = %v, want 1999-12-31 missing", series.Rates, series.Missing)
+	}
+}
+
+func TestGRPCGetSupportedCurrencies(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.GetSupportedCurrencies(context.Background(), &pb.GetSupportedCurrenciesRequest{})
+	if err != nil {
+		t.Fatalf("GetSupportedCurrencies: %v", err)
+	}
+	if len(resp.Currencies) != 2 || resp.Currencies[0].Code != "USD" || resp.Currencies[1].Code != "EUR" {
+		t.Errorf("unexpected currencies: %v", resp.Currencies)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"exchange-rate-service/internal/models"
+
 	kitendpoint "github.com/go-kit/kit/endpoint"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/go-kit/log"
@@ -45,10 +47,14 @@ func decodeGetHistoricalRatesRequest(_ context.Context, r *http.Request) (interf
 	vars := mux.Vars(r)
 	q := r.URL.Query()
 	return GetHistoricalRatesRequest{
-		From:      vars["base"],
-		To:        vars["target"],
-		StartDate: q.Get("start_date"),
-		EndDate:   q.Get("end_date"),
+		From:        vars["base"],
+		To:          vars["target"],
+		StartDate:   q.Get("start_date"),
+		EndDate:     q.Get("end_date"),
+		Stats:       q.Get("stats"),
+		Granularity: q.Get("granularity"),
+		Fill:        q.Get("fill"),
+		Calendar:    q.Get("calendar"),
 	}, nil
 }
 
@@ -56,8 +62,8 @@ func decodeGetSupportedCurrenciesRequest(_ context.Context, _ *http.Request) (in
 	return GetSupportedCurrenciesRequest{}, nil
 }
 
-// encoder
+// encoder writes the response in the format negotiated for w, JSON by default
 func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	w.Header().Set("Content-Type", "application/json")
-	return json.NewEncoder(w).Encode(response)
+	models.Write(w, http.StatusOK, response)
+	return nil
 }
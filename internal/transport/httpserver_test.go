@@ -0,0 +1,161 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+)
+
+// negotiated serves the latest rate handler with the format negotiated from
+// the request, as the router does
+func negotiated(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format, ok := models.NegotiateFormat(r)
+		if !ok {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		h.ServeHTTP(models.WithFormat(w, format), r)
+	})
+}
+
+func TestHTTPResponsesNegotiateFormat(t *testing.T) {
+	eps := MakeEndpoints(stubService{}, log.NewNopLogger())
+	router := mux.NewRouter()
+	router.Handle("/rates/{base}/{target}", negotiated(NewGetLatestRateHTTPHandler(eps.GetLatestRateEndpoint, log.NewNopLogger())))
+
+	tests := []struct {
+		name, url, accept string
+		status            int
+		contentType, body string
+	}{
+		{"default", "/rates/USD/EUR", "", http.StatusOK, "application/json", `"rate":0.9`},
+		{"csv accept", "/rates/USD/EUR", "text/csv", http.StatusOK, "text/csv", "base_currency,target_currency,rate,provider,fetched_at,is_stale\nUSD,EUR,0.9,stub,2024-03-01T12:00:00Z,false\n"},
+		{"csv query", "/rates/USD/EUR?format=csv", "application/json", http.StatusOK, "text/csv", "USD,EUR,0.9"},
+		{"xml by preference", "/rates/USD/EUR", "text/csv;q=0.5, application/xml", http.StatusOK, "application/xml", "<rate><base_currency>USD</base_currency>"},
+		{"wildcard", "/rates/USD/EUR", "*/*", http.StatusOK, "application/json", `"rate":0.9`},
+		{"csv error", "/rates/USD/XXX?format=csv", "", http.StatusOK, "text/csv", "error\n"},
+		{"unsupported", "/rates/USD/EUR", "application/pdf", http.StatusNotAcceptable, "", ""},
+		{"unknown format", "/rates/USD/EUR?format=yaml", "", http.StatusNotAcceptable, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if !strings.HasPrefix(rec.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("Content-Type = %q, want %q", rec.Header().Get("Content-Type"), tt.contentType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("body %q does not contain %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestHTTPResponsesSupportConditionalRequests(t *testing.T) {
+	eps := MakeEndpoints(stubService{}, log.NewNopLogger())
+	handler := NewGetLatestRateHTTPHandler(eps.GetLatestRateEndpoint, log.NewNopLogger())
+	router := mux.NewRouter()
+	router.Handle("/rates/{base}/{target}", negotiated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(models.WithCaching(w, r, models.CachePolicy{MaxAge: time.Hour}), r)
+	})))
+
+	get := func(url string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/rates/USD/EUR")
+	etag := first.Header().Get("ETag")
+	if etag == "" || first.Header().Get("Cache-Control") != "public, max-age=3600" {
+		t.Fatalf("missing caching headers: %v", first.Header())
+	}
+	if lm := first.Header().Get("Last-Modified"); lm != "Fri, 01 Mar 2024 12:00:00 GMT" {
+		t.Errorf("Last-Modified = %q", lm)
+	}
+	if csv := get("/rates/USD/EUR?format=csv"); csv.Header().Get("ETag") == etag {
+		t.Error("CSV and JSON representations share an ETag")
+	}
+
+	tests := []struct {
+		name   string
+		header []string
+		status int
+	}{
+		{"matching etag", []string{"If-None-Match", `"other", W/` + etag}, http.StatusNotModified},
+		{"changed etag", []string{"If-None-Match", `"other"`}, http.StatusOK},
+		{"not modified since", []string{"If-Modified-Since", "Fri, 01 Mar 2024 12:00:00 GMT"}, http.StatusNotModified},
+		{"modified since", []string{"If-Modified-Since", "Fri, 01 Mar 2024 11:59:59 GMT"}, http.StatusOK},
+		{"etag wins", []string{"If-None-Match", `"other"`, "If-Modified-Since", "Fri, 01 Mar 2024 12:00:00 GMT"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get("/rates/USD/EUR", tt.header...)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 response has a body: %q", rec.Body.String())
+			}
+		})
+	}
+
+	if rec := get("/rates/USD/XXX"); rec.Header().Get("Cache-Control") != "no-store" || rec.Header().Get("ETag") != "" {
+		t.Errorf("error response is cacheable: %v", rec.Header())
+	}
+}
+
+func TestBucketedSeriesRevalidateWhileRatesMayChange(t *testing.T) {
+	eps := MakeEndpoints(stubService{}, log.NewNopLogger())
+	today := models.Today().Format("2006-01-02")
+
+	tests := []struct {
+		name, start, end string
+		stats            string
+		revalidate       bool
+	}{
+		{"complete past range", "2024-01-01", "2024-03-31", "", false},
+		{"stats of a complete range", "2024-01-01", "2024-03-31", models.StatsOnly, false},
+		{"range with rates still to backfill", "2023-12-01", "2024-01-31", "", true},
+		{"range reaching today", "2024-01-01", today, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := eps.GetHistoricalRatesEndpoint(context.Background(), GetHistoricalRatesRequest{
+				From: "USD", To: "EUR", StartDate: tt.start, EndDate: tt.end, Granularity: models.GranularityMonth, Stats: tt.stats,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := resp.(GetHistoricalRatesResponse).CacheInfo()
+			if info.NoStore || info.Revalidate != tt.revalidate {
+				t.Errorf("cache info = %+v, want Revalidate %v", info, tt.revalidate)
+			}
+			if !tt.revalidate && !info.LastModified.Equal(fetchedAt) {
+				t.Errorf("LastModified = %v, want %v", info.LastModified, fetchedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+// Package pb holds the protobuf definition of the gRPC API and the code
+// generated from it. Regenerate after editing exchange.proto with go generate.
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative exchange.proto
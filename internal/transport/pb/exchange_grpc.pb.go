@@ -0,0 +1,279 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: exchange.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ExchangeRateService_GetLatestRate_FullMethodName          = "/exchangerate.v1.ExchangeRateService/GetLatestRate"
+	ExchangeRateService_ConvertCurrency_FullMethodName        = "/exchangerate.v1.ExchangeRateService/ConvertCurrency"
+	ExchangeRateService_GetHistoricalRate_FullMethodName      = "/exchangerate.v1.ExchangeRateService/GetHistoricalRate"
+	ExchangeRateService_GetTimeSeries_FullMethodName          = "/exchangerate.v1.ExchangeRateService/GetTimeSeries"
+	ExchangeRateService_GetSupportedCurrencies_FullMethodName = "/exchangerate.v1.ExchangeRateService/GetSupportedCurrencies"
+)
+
+// ExchangeRateServiceClient is the client API for ExchangeRateService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ExchangeRateService exposes the exchange rate API over gRPC. It is served by
+// the same go-kit endpoints as the HTTP API.
+type ExchangeRateServiceClient interface {
+	GetLatestRate(ctx context.Context, in *GetLatestRateRequest, opts ...grpc.CallOption) (*ExchangeRate, error)
+	ConvertCurrency(ctx context.Context, in *ConvertCurrencyRequest, opts ...grpc.CallOption) (*Conversion, error)
+	GetHistoricalRate(ctx context.Context, in *GetHistoricalRateRequest, opts ...grpc.CallOption) (*HistoricalRate, error)
+	GetTimeSeries(ctx context.Context, in *GetTimeSeriesRequest, opts ...grpc.CallOption) (*TimeSeries, error)
+	GetSupportedCurrencies(ctx context.Context, in *GetSupportedCurrenciesRequest, opts ...grpc.CallOption) (*SupportedCurrencies, error)
+}
+
+type exchangeRateServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExchangeRateServiceClient(cc grpc.ClientConnInterface) ExchangeRateServiceClient {
+	return &exchangeRateServiceClient{cc}
+}
+
+func (c *exchangeRateServiceClient) GetLatestRate(ctx context.Context, in *GetLatestRateRequest, opts ...grpc.CallOption) (*ExchangeRate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExchangeRate)
+	err := c.cc.Invoke(ctx, ExchangeRateService_GetLatestRate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeRateServiceClient) ConvertCurrency(ctx context.Context, in *ConvertCurrencyRequest, opts ...grpc.CallOption) (*Conversion, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Conversion)
+	err := c.cc.Invoke(ctx, ExchangeRateService_ConvertCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeRateServiceClient) GetHistoricalRate(ctx context.Context, in *GetHistoricalRateRequest, opts ...grpc.CallOption) (*HistoricalRate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoricalRate)
+	err := c.cc.Invoke(ctx, ExchangeRateService_GetHistoricalRate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeRateServiceClient) GetTimeSeries(ctx context.Context, in *GetTimeSeriesRequest, opts ...grpc.CallOption) (*TimeSeries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TimeSeries)
+	err := c.cc.Invoke(ctx, ExchangeRateService_GetTimeSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeRateServiceClient) GetSupportedCurrencies(ctx context.Context, in *GetSupportedCurrenciesRequest, opts ...grpc.CallOption) (*SupportedCurrencies, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SupportedCurrencies)
+	err := c.cc.Invoke(ctx, ExchangeRateService_GetSupportedCurrencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExchangeRateServiceServer is the server API for ExchangeRateService service.
+// All implementations must embed UnimplementedExchangeRateServiceServer
+// for forward compatibility.
+//
+// ExchangeRateService exposes the exchange rate API over gRPC. It is served by
+// the same go-kit endpoints as the HTTP API.
+type ExchangeRateServiceServer interface {
+	GetLatestRate(context.Context, *GetLatestRateRequest) (*ExchangeRate, error)
+	ConvertCurrency(context.Context, *ConvertCurrencyRequest) (*Conversion, error)
+	GetHistoricalRate(context.Context, *GetHistoricalRateRequest) (*HistoricalRate, error)
+	GetTimeSeries(context.Context, *GetTimeSeriesRequest) (*TimeSeries, error)
+	GetSupportedCurrencies(context.Context, *GetSupportedCurrenciesRequest) (*SupportedCurrencies, error)
+	mustEmbedUnimplementedExchangeRateServiceServer()
+}
+
+// UnimplementedExchangeRateServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedExchangeRateServiceServer struct{}
+
+func (UnimplementedExchangeRateServiceServer) GetLatestRate(context.Context, *GetLatestRateRequest) (*ExchangeRate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatestRate not implemented")
+}
+func (UnimplementedExchangeRateServiceServer) ConvertCurrency(context.Context, *ConvertCurrencyRequest) (*Conversion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertCurrency not implemented")
+}
+func (UnimplementedExchangeRateServiceServer) GetHistoricalRate(context.Context, *GetHistoricalRateRequest) (*HistoricalRate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistoricalRate not implemented")
+}
+func (UnimplementedExchangeRateServiceServer) GetTimeSeries(context.Context, *GetTimeSeriesRequest) (*TimeSeries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTimeSeries not implemented")
+}
+func (UnimplementedExchangeRateServiceServer) GetSupportedCurrencies(context.Context, *GetSupportedCurrenciesRequest) (*SupportedCurrencies, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSupportedCurrencies not implemented")
+}
+func (UnimplementedExchangeRateServiceServer) mustEmbedUnimplementedExchangeRateServiceServer() {}
+func (UnimplementedExchangeRateServiceServer) testEmbeddedByValue()                             {}
+
+// UnsafeExchangeRateServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExchangeRateServiceServer will
+// result in compilation errors.
+type UnsafeExchangeRateServiceServer interface {
+	mustEmbedUnimplementedExchangeRateServiceServer()
+}
+
+func RegisterExchangeRateServiceServer(s grpc.ServiceRegistrar, srv ExchangeRateServiceServer) {
+	// If the following call pancis, it indicates UnimplementedExchangeRateServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ExchangeRateService_ServiceDesc, srv)
+}
+
+func _ExchangeRateService_GetLatestRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLatestRateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeRateServiceServer).GetLatestRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeRateService_GetLatestRate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeRateServiceServer).GetLatestRate(ctx, req.(*GetLatestRateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeRateService_ConvertCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertCurrencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeRateServiceServer).ConvertCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeRateService_ConvertCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeRateServiceServer).ConvertCurrency(ctx, req.(*ConvertCurrencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeRateService_GetHistoricalRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoricalRateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeRateServiceServer).GetHistoricalRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeRateService_GetHistoricalRate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeRateServiceServer).GetHistoricalRate(ctx, req.(*GetHistoricalRateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeRateService_GetTimeSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTimeSeriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeRateServiceServer).GetTimeSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeRateService_GetTimeSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeRateServiceServer).GetTimeSeries(ctx, req.(*GetTimeSeriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeRateService_GetSupportedCurrencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSupportedCurrenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeRateServiceServer).GetSupportedCurrencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeRateService_GetSupportedCurrencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeRateServiceServer).GetSupportedCurrencies(ctx, req.(*GetSupportedCurrenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ExchangeRateService_ServiceDesc is the grpc.ServiceDesc for ExchangeRateService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ExchangeRateService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchangerate.v1.ExchangeRateService",
+	HandlerType: (*ExchangeRateServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLatestRate",
+			Handler:    _ExchangeRateService_GetLatestRate_Handler,
+		},
+		{
+			MethodName: "ConvertCurrency",
+			Handler:    _ExchangeRateService_ConvertCurrency_Handler,
+		},
+		{
+			MethodName: "GetHistoricalRate",
+			Handler:    _ExchangeRateService_GetHistoricalRate_Handler,
+		},
+		{
+			MethodName: "GetTimeSeries",
+			Handler:    _ExchangeRateService_GetTimeSeries_Handler,
+		},
+		{
+			MethodName: "GetSupportedCurrencies",
+			Handler:    _ExchangeRateService_GetSupportedCurrencies_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "exchange.proto",
+}
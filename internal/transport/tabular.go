@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"exchange-rate-service/internal/models"
+)
+
+// The go-kit HTTP responses implement models.Tabular so they can be
+// negotiated as CSV; failed responses become a single error row
+
+func errorTable(msg string) ([]string, [][]string) {
+	return []string{"error"}, [][]string{{msg}}
+}
+
+func (r GetLatestRateResponse) Table() ([]string, [][]string) {
+	if rate, ok := r.Rate.(*models.ExchangeRate); ok && r.Error == "" {
+		return rate.Table()
+	}
+	return errorTable(r.Error)
+}
+
+// Table lists the daily rates, or the buckets of a coarser granularity. With
+// stats=only the statistics are the single row.
+func (r GetHistoricalRatesResponse) Table() ([]string, [][]string) {
+	if r.Error != "" {
+		return errorTable(r.Error)
+	}
+	if buckets, ok := r.Buckets.([]*models.RateBucket); ok {
+		return models.RateBuckets(buckets).Table()
+	}
+	if stats, ok := r.Stats.(*models.TimeSeriesStats); ok && r.Rates == nil {
+		return stats.Table()
+	}
+
+	rates := make(models.HistoricalRates, 0, len(r.Rates))
+	for _, rate := range r.Rates {
+		if hr, ok := rate.(*models.HistoricalRate); ok {
+			rates = append(rates, hr)
+		}
+	}
+	return rates.Table()
+}
+
+func (r GetSupportedCurrenciesResponse) Table() ([]string, [][]string) {
+	if currencies, ok := r.Currencies.([]*models.Currency); ok && r.Error == "" {
+		return models.Currencies(currencies).Table()
+	}
+	return errorTable(r.Error)
+}
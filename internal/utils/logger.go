@@ -1,20 +1,82 @@
 package utils
 
 import (
+	"io"
 	"os"
+	"strings"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 )
 
-// NewLogger creates a new Go-Kit logger
-func NewLogger() log.Logger {
+// NewLogger creates a new Go-Kit logger writing lines at or above minLevel
+// (debug, info, warn or error; info when unrecognised) to stderr. Any of the
+// given secrets appearing in a logged value is replaced before the line is
+// written. The returned LogSettings changes the level and secrets while the
+// logger is in use.
+func NewLogger(minLevel string, secrets ...string) (log.Logger, *LogSettings) {
+	return NewLoggerTo(os.Stderr, minLevel, secrets...)
+}
+
+// NewLoggerTo is NewLogger writing to w
+func NewLoggerTo(w io.Writer, minLevel string, secrets ...string) (log.Logger, *LogSettings) {
+	settings := &LogSettings{output: log.NewLogfmtLogger(w)}
+	settings.Update(minLevel, secrets...)
+
 	var logger log.Logger
 	{
-		logger = log.NewLogfmtLogger(os.Stderr)
-		logger = level.NewFilter(logger, level.AllowInfo())
+		logger = &settings.filtered
 		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
 		logger = log.With(logger, "caller", log.DefaultCaller)
 	}
-	return logger
-}
\ No newline at end of file
+	return logger, settings
+}
+
+// LogSettings holds the runtime-adjustable part of a logger from NewLogger
+type LogSettings struct {
+	output   log.Logger
+	filtered log.SwapLogger
+}
+
+// Update sets the minimum level and the secrets to redact, e.g. after a
+// configuration reload
+func (s *LogSettings) Update(minLevel string, secrets ...string) {
+	logger := NewRedactingLogger(s.output, secrets...)
+	logger = level.NewFilter(logger, level.Allow(level.ParseDefault(minLevel, level.InfoValue())))
+	s.filtered.Swap(logger)
+}
+
+// redactingLogger masks secrets in every value before passing them on
+type redactingLogger struct {
+	next     log.Logger
+	replacer *strings.Replacer
+}
+
+// NewRedactingLogger wraps next so the given secrets never reach the output
+func NewRedactingLogger(next log.Logger, secrets ...string) log.Logger {
+	var oldnew []string
+	for _, secret := range secrets {
+		if secret != "" {
+			oldnew = append(oldnew, secret, "[REDACTED]")
+		}
+	}
+	if len(oldnew) == 0 {
+		return next
+	}
+	return &redactingLogger{next: next, replacer: strings.NewReplacer(oldnew...)}
+}
+
+func (l *redactingLogger) Log(keyvals ...interface{}) error {
+	redacted := make([]interface{}, len(keyvals))
+	for i, v := range keyvals {
+		switch v := v.(type) {
+		case string:
+			redacted[i] = l.replacer.Replace(v)
+		case error:
+			redacted[i] = l.replacer.Replace(v.Error())
+		default:
+			redacted[i] = v
+		}
+	}
+	return l.next.Log(redacted...)
+}
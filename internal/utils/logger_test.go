@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestRedactingLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewRedactingLogger(log.NewLogfmtLogger(&buf), "k3y", "", "t0ken")
+
+	logger.Log("msg", "calling https://provider/k3y/latest", "err", errors.New("bad token t0ken"), "attempt", 2)
+
+	got := buf.String()
+	if strings.Contains(got, "k3y") || strings.Contains(got, "t0ken") {
+		t.Errorf("secrets reached the output: %s", got)
+	}
+	for _, want := range []string{"https://provider/[REDACTED]/latest", "bad token [REDACTED]", "attempt=2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q lacks %q", got, want)
+		}
+	}
+}
+
+func TestRedactingLoggerWithoutSecrets(t *testing.T) {
+	next := log.NewNopLogger()
+	if logger := NewRedactingLogger(next, "", ""); logger != next {
+		t.Error("expected the logger to be returned unwrapped when there is nothing to redact")
+	}
+}